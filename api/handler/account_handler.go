@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// RequestAccountDeletionCode godoc
+// @Summary      Send account deletion code
+// @Description  Parolsiz (Google/OTP) akkauntlar uchun: emailga tasdiqlash kodi yuboriladi, so‘ng DELETE /user/me
+// @Tags         user
+// @Produce      json
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/delete/code [post]
+// @Security     ApiKeyAuth
+func (h Handler) RequestAccountDeletionCode(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.Account().RequestDeletionCode(ctx, userID.(string)); err != nil {
+		if errors.Is(err, service.ErrOTPCooldown) {
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to send deletion code", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "deletion code sent", http.StatusOK, nil)
+}
+
+// DeleteMe godoc
+// @Summary      Delete my account
+// @Description  Parol yoki emaildagi kod bilan tasdiqlanadi. Akkaunt yashiriladi va barcha sessiyalar bekor qilinadi;
+// @Description  grace muddati ichida qayta login qilinsa tiklanadi, aks holda shaxsiy ma'lumotlar o‘chiriladi
+// @Tags         user
+// @Accept       json
+// @Produce      json
+// @Param        data body models.DeleteAccountRequest true "Password or code"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me [delete]
+// @Security     ApiKeyAuth
+func (h Handler) DeleteMe(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.Account().Delete(ctx, userID.(string), req.Password, req.Code); err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeleteConfirmRequired):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrDeleteConfirmInvalid),
+			errors.Is(err, service.ErrOTPInvalid):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		case errors.Is(err, service.ErrOTPTooManyAttempts):
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+		case errors.Is(err, service.ErrAccountAlreadyDeleted):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		default:
+			handleResponse(c, h.log, "failed to delete account", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.audit(c, models.AuditAccountDeleted, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "account deleted", http.StatusOK, nil)
+}
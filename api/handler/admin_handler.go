@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// UpdateUserRole godoc
+// @Summary      Change a user's role
+// @Description  Faqat users.manage_roles huquqi bor (DB'dan qayta tekshiriladi) admin; o‘z rolini o‘zgartira olmaydi
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id   path string                   true "User ID"
+// @Param        data body models.UpdateRoleRequest true "New role"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /admin/users/{id}/role [put]
+// @Security     ApiKeyAuth
+func (h Handler) UpdateUserRole(c *gin.Context) {
+	actorID := c.GetString("user_id")
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.User().SetRole(ctx, actorID, c.Param("id"), req.Role); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrCannotChangeOwnRole):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrUserNotFound):
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+		default:
+			handleResponse(c, h.log, "failed to update role", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.audit(c, models.AuditRoleChanged, actorID, c.Param("id"), gin.H{"role": req.Role})
+	handleResponse(c, h.log, "role updated", http.StatusOK, nil)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/pkg/security"
+	"speakpall/service"
+)
+
+// GetMySecurityEvents godoc
+// @Summary      My security events
+// @Description  Loginlar, xato urinishlar, parol/2FA/passkey o‘zgarishlari va h.k. (yangilari birinchi); keyingi sahifa uchun before=next_before
+// @Tags         user
+// @Produce      json
+// @Param        type   query string false "Event type (e.g. login.failed)"
+// @Param        from   query string false "RFC3339 time, inclusive"
+// @Param        to     query string false "RFC3339 time, exclusive"
+// @Param        before query int    false "Cursor from next_before"
+// @Param        limit  query int    false "1..200, default 50"
+// @Success      200 {object} models.Response{data=models.SecurityEventsResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/security-events [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMySecurityEvents(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var filter models.AuditEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		handleResponse(c, h.log, "invalid query", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.Audit().ListForUser(ctx, userID.(string), filter)
+	if err != nil {
+		handleResponse(c, h.log, "failed to load security events", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "security events", http.StatusOK, resp)
+}
+
+// ListAuditEvents godoc
+// @Summary      Audit log (all users)
+// @Description  user_id actor yoki target bo‘yicha filtrlaydi
+// @Tags         admin
+// @Produce      json
+// @Param        user_id query string false "User ID (actor or target)"
+// @Param        type    query string false "Event type"
+// @Param        from    query string false "RFC3339 time, inclusive"
+// @Param        to      query string false "RFC3339 time, exclusive"
+// @Param        before  query int    false "Cursor from next_before"
+// @Param        limit   query int    false "1..200, default 50"
+// @Success      200 {object} models.Response{data=models.AuditEventsResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /admin/audit-events [get]
+// @Security     ApiKeyAuth
+func (h Handler) ListAuditEvents(c *gin.Context) {
+	var filter models.AuditEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		handleResponse(c, h.log, "invalid query", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.Audit().List(ctx, filter)
+	if err != nil {
+		handleResponse(c, h.log, "failed to load audit events", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "audit events", http.StatusOK, resp)
+}
+
+// audit - so‘rovdagi IP va user agent bilan hodisa yozadi; actorID login oldidan bo‘sh bo‘lishi mumkin.
+func (h Handler) audit(c *gin.Context, typ, actorID, targetUserID string, payload interface{}) {
+	h.services.Audit().Record(c.Request.Context(), service.AuditEntry{
+		Type:         typ,
+		ActorID:      actorID,
+		TargetUserID: targetUserID,
+		IP:           c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Payload:      payload,
+	})
+}
+
+// auditEmail - jurnalga xom email emas, normallashtirilgan emailning SHA-256'i yoziladi:
+// bir emailga qilingan urinishlarni bog‘lash mumkin, lekin append-only jadvalda PII qolmaydi.
+func auditEmail(email string) string {
+	return security.HashToken(strings.ToLower(strings.TrimSpace(email)))
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// RequestEmailChange godoc
+// @Summary      Request login email change
+// @Description  Yangi manzil bandligini tekshiradi va unga tasdiqlash kodini yuboradi
+// @Tags         profile
+// @Accept       json
+// @Produce      json
+// @Param        data body models.ChangeEmailRequest true "New email"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/email [post]
+// @Security     ApiKeyAuth
+func (h Handler) RequestEmailChange(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.services.EmailChange().Request(ctx, userID.(string), req.NewEmail); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailTaken):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		case errors.Is(err, service.ErrEmailUnchanged):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrOTPCooldown):
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+		default:
+			handleResponse(c, h.log, "failed to request email change", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	handleResponse(c, h.log, "confirmation code sent to the new email", http.StatusOK, nil)
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm login email change
+// @Description  Yangi manzilga kelgan kod bilan emailni almashtiradi; eski manzilga revert havolasi yuboriladi
+// @Tags         profile
+// @Accept       json
+// @Produce      json
+// @Param        data body models.ConfirmEmailChangeRequest true "Code"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/email/confirm [post]
+// @Security     ApiKeyAuth
+func (h Handler) ConfirmEmailChange(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req models.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.services.EmailChange().Confirm(ctx, userID.(string), req.Code); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailTaken):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		case errors.Is(err, service.ErrNoPendingEmail):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrOTPTooManyAttempts):
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+		case errors.Is(err, service.ErrOTPInvalid):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		default:
+			handleResponse(c, h.log, "failed to confirm email change", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.audit(c, models.AuditEmailChanged, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "email changed", http.StatusOK, nil)
+}
+
+// RevertEmailChange godoc
+// @Summary      Revert login email change
+// @Description  Eski manzilga yuborilgan havoladagi token orqali emailni qaytaradi
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.RevertEmailChangeRequest true "Revert token"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/email/revert [post]
+func (h Handler) RevertEmailChange(c *gin.Context) {
+	var req models.RevertEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.EmailChange().Revert(ctx, req.Token); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRevertToken):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		case errors.Is(err, service.ErrEmailTaken):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		default:
+			handleResponse(c, h.log, "failed to revert email change", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	handleResponse(c, h.log, "email change reverted", http.StatusOK, nil)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/service"
+)
+
+// RequestMyExport godoc
+// @Summary      Request a personal data export
+// @Description  GDPR: arxiv (ZIP, JSON fayllar) fon job'ida tayyorlanadi va yuklab olish havolasi emailga yuboriladi. Kuniga bitta
+// @Tags         user
+// @Produce      json
+// @Success      202 {object} models.Response{data=models.DataExport}
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/export [post]
+// @Security     ApiKeyAuth
+func (h Handler) RequestMyExport(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	export, err := h.services.Export().Request(ctx, userID.(string))
+	if err != nil {
+		if errors.Is(err, service.ErrExportTooSoon) {
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to request data export", http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(c, models.AuditDataExportRequested, userID.(string), userID.(string), gin.H{"export_id": export.ID})
+	handleResponse(c, h.log, "data export started", http.StatusAccepted, export)
+}
+
+// GetMyExport godoc
+// @Summary      Latest data export status
+// @Tags         user
+// @Produce      json
+// @Success      200 {object} models.Response{data=models.DataExport}
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/export [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMyExport(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	export, err := h.services.Export().Latest(ctx, userID.(string))
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to load data export", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "data export", http.StatusOK, export)
+}
+
+// DownloadExport godoc
+// @Summary      Download a data export archive
+// @Description  Emaildagi havola: token bir martalik emas, lekin muddati cheklangan (EXPORT_LINK_TTL)
+// @Tags         user
+// @Produce      application/zip
+// @Param        token path string true "Download token"
+// @Success      200 {file} file
+// @Failure      404 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /exports/{token} [get]
+func (h Handler) DownloadExport(c *gin.Context) {
+	rc, export, err := h.services.Export().Open(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrExportLinkInvalid) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to open data export", http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="speakpall-export-%s.zip"`, export.CreatedAt.UTC().Format("2006-01-02")))
+	c.Header("Cache-Control", "no-store")
+	if export.SizeBytes != nil {
+		c.Header("Content-Length", fmt.Sprint(*export.SizeBytes))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		h.log.Error("data export download interrupted", logger.Error(err), logger.String("export_id", export.ID))
+	}
+}
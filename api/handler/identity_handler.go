@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// LinkGoogleIdentity godoc
+// @Summary      Link Google account
+// @Description  Google OAuth code orqali joriy akkauntga google_id ni bog‘laydi
+// @Tags         identities
+// @Accept       json
+// @Produce      json
+// @Param        data body models.GoogleAuthRequest true "Google authorization code"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities/google [post]
+// @Security     ApiKeyAuth
+func (h Handler) LinkGoogleIdentity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	var req models.GoogleAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	googleUser, err := h.services.Google().ExchangeCodeForUser(ctx, req.Code)
+	if err != nil {
+		handleResponse(c, h.log, "Google authorization failed", http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if err := h.services.User().LinkGoogle(ctx, userID.(string), *googleUser); err != nil {
+		if errors.Is(err, service.ErrGoogleAlreadyLinked) {
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to link google account", http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(c, models.AuditIdentityLinked, userID.(string), userID.(string), gin.H{"provider": "google"})
+	handleResponse(c, h.log, "google account linked", http.StatusOK, nil)
+}
+
+// UnlinkGoogleIdentity godoc
+// @Summary      Unlink Google account
+// @Description  google_id bog‘lanishini uzadi (parol, boshqa provayder yoki passkey qolmasa rad etiladi)
+// @Tags         identities
+// @Produce      json
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities/google [delete]
+// @Security     ApiKeyAuth
+func (h Handler) UnlinkGoogleIdentity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.User().UnlinkGoogle(ctx, userID.(string)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoPasswordSet):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		case errors.Is(err, service.ErrGoogleNotLinked):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		default:
+			handleResponse(c, h.log, "failed to unlink google account", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.audit(c, models.AuditIdentityUnlinked, userID.(string), userID.(string), gin.H{"provider": "google"})
+	handleResponse(c, h.log, "google account unlinked", http.StatusOK, nil)
+}
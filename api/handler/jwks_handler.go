@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/pkg/jwt"
+)
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  speakpall tokenlarini tekshirish uchun public kalitlar (faol va rotation'dagi eski kalitlar, kid bo‘yicha)
+// @Tags         auth
+// @Produce      json
+// @Success      200 {object} jwks.Set
+// @Router       /.well-known/jwks.json [get]
+func (h Handler) JWKS(c *gin.Context) {
+	// boshqa servislar keshlay oladi; rotation'da yangi kid kelganda qayta yuklashadi
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwt.JWKS())
+}
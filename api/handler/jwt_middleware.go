@@ -7,9 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"speakpall/pkg/jwt"
+	"speakpall/pkg/logger"
 )
 
-
 func (h Handler) JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -18,7 +18,7 @@ func (h Handler) JWTMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		token := strings.TrimSpace(authHeader[7:])
+		token := bearerToken(c)
 		if token == "" {
 			handleResponse(c, h.log, "invalid bearer token", http.StatusUnauthorized, nil)
 			c.Abort()
@@ -40,14 +40,87 @@ func (h Handler) JWTMiddleware() gin.HandlerFunc {
 
 		userID, _ := claims["user_id"].(string)
 		role, _ := claims["role"].(string)
+		sessionID, _ := claims["sid"].(string)
 		if userID == "" {
 			handleResponse(c, h.log, "invalid token claims", http.StatusUnauthorized, nil)
 			c.Abort()
 			return
 		}
 
+		// logout qilingan access token (jti denylist); Redis ishlamasa so‘rov o‘tkazilmaydi (fail closed)
+		if jti, _ := claims["jti"].(string); jti != "" {
+			denied, err := h.services.Redis().IsAccessTokenDenied(c.Request.Context(), jti)
+			if err != nil {
+				h.log.Error("access token denylist check failed", logger.Error(err))
+				handleResponse(c, h.log, "token check unavailable, try again later", http.StatusServiceUnavailable, nil)
+				c.Abort()
+				return
+			}
+			if denied {
+				handleResponse(c, h.log, "token has been revoked", http.StatusUnauthorized, nil)
+				c.Abort()
+				return
+			}
+		}
+
+		// bekor qilingan sessiya access tokenlari bir necha soniyada o‘z kuchini yo‘qotadi; tekshiruv xatosida ham rad etiladi
+		if sessionID != "" {
+			revoked, err := h.services.Session().IsRevoked(c.Request.Context(), sessionID)
+			if err != nil {
+				h.log.Error("session revocation check failed", logger.Error(err))
+				handleResponse(c, h.log, "session check unavailable, try again later", http.StatusServiceUnavailable, nil)
+				c.Abort()
+				return
+			}
+			if revoked {
+				handleResponse(c, h.log, "session has been revoked", http.StatusUnauthorized, nil)
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user_id", userID)
 		c.Set("role", role)
+		c.Set("session_id", sessionID)
+
+		if err := h.services.User().TouchLastSeen(c.Request.Context(), userID); err != nil {
+			h.log.Error("last_seen update failed", logger.Error(err), logger.String("user_id", userID))
+		}
+
+		c.Next()
+	}
+}
+
+// bearerToken - "Authorization: Bearer <token>" headeridan tokenni oladi (bo‘lmasa "").
+func bearerToken(c *gin.Context) string {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(authHeader[7:])
+}
+
+// RequireVerifiedEmail - JWTMiddleware'dan keyin ishlatiladi; emaili tasdiqlanmagan userlarni 403 bilan qaytaradi.
+func (h Handler) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+			c.Abort()
+			return
+		}
+
+		verified, err := h.services.User().IsEmailVerified(c.Request.Context(), userID)
+		if err != nil {
+			handleResponse(c, h.log, "failed to check email verification", http.StatusInternalServerError, err.Error())
+			c.Abort()
+			return
+		}
+		if !verified {
+			handleResponse(c, h.log, "email is not verified", http.StatusForbidden, nil)
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
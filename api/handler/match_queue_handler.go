@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// EnqueueMatch godoc
+// @Summary      Join the match queue
+// @Description  Til va daraja berilmasa profil/match-prefs'dan olinadi. Juftlik topilgach attempt "matched" bo‘ladi va session_id GET /match/queue'da ko‘rinadi.
+// @Description  mode=tandem: faqat o‘zaro til almashinuvi (A native B ning tilida va aksincha); MATCH_TANDEM_FALLBACK'dan keyin bir tomonlama ham,
+// @Description  qaysi yo‘nalish bajarilgani attempt.tandem_direction'da
+// @Tags         match
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MatchQueueRequest false "Desired language and level"
+// @Success      202 {object} models.Response{data=models.MatchQueueStatus}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /match/queue [post]
+// @Security     ApiKeyAuth
+func (h Handler) EnqueueMatch(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.MatchQueueRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	status, err := h.services.MatchQueue().Enqueue(ctx, userID.(string), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMatchLanguageRequired),
+			errors.Is(err, service.ErrTandemNativeRequired):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrAccountDeleted):
+			handleResponse(c, h.log, err.Error(), http.StatusForbidden, nil)
+		case errors.Is(err, service.ErrAlreadyQueued):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		default:
+			handleResponse(c, h.log, "failed to join match queue", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	handleResponse(c, h.log, "queued", http.StatusAccepted, status)
+}
+
+// GetMatchQueue godoc
+// @Summary      Match queue status
+// @Description  Oxirgi attempt holati; navbatda bo‘lsa position (1 — birinchi), queue_size, kutish bo‘yicha yumshatilgan
+// @Description  joriy mezonlar (criteria) va shu til juftligi statistikasidan taxminiy kutish (estimated_wait)
+// @Tags         match
+// @Produce      json
+// @Success      200 {object} models.Response{data=models.MatchQueueStatus}
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /match/queue [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMatchQueue(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	status, err := h.services.MatchQueue().Status(ctx, userID.(string))
+	if err != nil {
+		handleResponse(c, h.log, "failed to load match queue status", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "match queue status", http.StatusOK, status)
+}
+
+// CancelMatch godoc
+// @Summary      Leave the match queue
+// @Tags         match
+// @Produce      json
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /match/queue [delete]
+// @Security     ApiKeyAuth
+func (h Handler) CancelMatch(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.MatchQueue().Cancel(ctx, userID.(string)); err != nil {
+		if errors.Is(err, service.ErrNotQueued) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to leave match queue", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "left match queue", http.StatusOK, nil)
+}
+
+// ExplainMatchScore godoc
+// @Summary      Explain match compatibility of two users
+// @Description  Joriy profil va match-prefs bo‘yicha signal-ma-signal baho: nima uchun juftlangan yoki juftlanmagan
+// @Tags         admin
+// @Produce      json
+// @Param        user_a query string true "User ID (uuid)"
+// @Param        user_b query string true "User ID (uuid)"
+// @Success      200 {object} models.Response{data=models.MatchScore}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /admin/match/score [get]
+// @Security     ApiKeyAuth
+func (h Handler) ExplainMatchScore(c *gin.Context) {
+	var q models.MatchScoreQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		handleResponse(c, h.log, "user_a and user_b must be valid user IDs", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	score, err := h.services.MatchQueue().Explain(ctx, q.UserA, q.UserB)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to score match", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "match score", http.StatusOK, score)
+}
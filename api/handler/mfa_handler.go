@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// MFAEnrollPending godoc
+// @Summary      Enroll 2FA during login
+// @Description  2FA majburiy rol (admin) uchun: login qaytargan mfa_token bilan TOTP secret yaratadi; so‘ng /auth/mfa/verify
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MFAEnrollPendingRequest true "MFA token"
+// @Success      200 {object} models.Response{data=models.MFAEnrollResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/mfa/enroll [post]
+func (h Handler) MFAEnrollPending(c *gin.Context) {
+	var req models.MFAEnrollPendingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.MFA().EnrollPending(ctx, req.MFAToken)
+	if err != nil {
+		h.mfaError(c, err, "failed to start two-factor enrollment")
+		return
+	}
+	handleResponse(c, h.log, "scan the secret with an authenticator app", http.StatusOK, resp)
+}
+
+// MFAVerify godoc
+// @Summary      Complete login with 2FA
+// @Description  mfa_token + TOTP yoki tiklash kodi; muvaffaqiyatli bo‘lsa access/refresh tokenlar.
+// @Description  mfa_token bir martalik; MFA_MAX_FAILURES ta xatodan keyin bekor bo‘ladi va 429 qaytadi
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MFAVerifyRequest true "MFA token and code"
+// @Success      200 {object} models.Response{data=models.MFALoginResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/mfa/verify [post]
+func (h Handler) MFAVerify(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	userID, codes, err := h.services.MFA().CompleteLogin(ctx, req.MFAToken, req.Code)
+	if err != nil {
+		h.mfaError(c, err, "failed to verify two-factor code")
+		return
+	}
+
+	u, err := h.services.User().GetByID(ctx, userID)
+	if err != nil {
+		handleResponse(c, h.log, "failed to load user", http.StatusInternalServerError, err.Error())
+		return
+	}
+	role := u.Role
+	if role == "" {
+		role = "user"
+	}
+
+	resp, err := h.issueTokens(c, userID, role)
+	if err != nil {
+		h.tokenError(c, err)
+		return
+	}
+	handleResponse(c, h.log, "login successful", http.StatusOK, models.MFALoginResponse{LoginResponse: resp, RecoveryCodes: codes})
+}
+
+// EnrollMyMFA godoc
+// @Summary      Start 2FA enrollment
+// @Description  Yangi TOTP secret va otpauth:// URI (QR uchun); /user/me/mfa/confirm bilan tasdiqlanmaguncha 2FA yoqilmaydi
+// @Tags         mfa
+// @Produce      json
+// @Success      200 {object} models.Response{data=models.MFAEnrollResponse}
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/mfa/enroll [post]
+// @Security     ApiKeyAuth
+func (h Handler) EnrollMyMFA(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.MFA().Enroll(ctx, userID.(string))
+	if err != nil {
+		h.mfaError(c, err, "failed to start two-factor enrollment")
+		return
+	}
+	handleResponse(c, h.log, "scan the secret with an authenticator app", http.StatusOK, resp)
+}
+
+// ConfirmMyMFA godoc
+// @Summary      Confirm 2FA enrollment
+// @Description  Ilovadagi birinchi kod bilan 2FA ni yoqadi; tiklash kodlari faqat shu javobda ko‘rsatiladi
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MFACodeRequest true "TOTP code"
+// @Success      200 {object} models.Response{data=models.MFARecoveryCodesResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/mfa/confirm [post]
+// @Security     ApiKeyAuth
+func (h Handler) ConfirmMyMFA(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.services.MFA().Confirm(ctx, userID.(string), req.Code)
+	if err != nil {
+		h.mfaError(c, err, "failed to enable two-factor authentication")
+		return
+	}
+	h.audit(c, models.AuditMFAEnabled, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "two-factor authentication enabled", http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateMyRecoveryCodes godoc
+// @Summary      Regenerate 2FA recovery codes
+// @Description  Amaldagi kod bilan eski tiklash kodlarini bekor qilib yangilarini beradi
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MFACodeRequest true "TOTP or recovery code"
+// @Success      200 {object} models.Response{data=models.MFARecoveryCodesResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/mfa/recovery-codes [post]
+// @Security     ApiKeyAuth
+func (h Handler) RegenerateMyRecoveryCodes(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.services.MFA().RegenerateRecoveryCodes(ctx, userID.(string), req.Code)
+	if err != nil {
+		h.mfaError(c, err, "failed to regenerate recovery codes")
+		return
+	}
+	h.audit(c, models.AuditMFARecoveryRegenerate, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "recovery codes regenerated", http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMyMFA godoc
+// @Summary      Disable 2FA
+// @Description  Amaldagi TOTP yoki tiklash kodi talab qilinadi; 2FA majburiy rollar uchun rad etiladi
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MFACodeRequest true "TOTP or recovery code"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/mfa [delete]
+// @Security     ApiKeyAuth
+func (h Handler) DisableMyMFA(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.MFA().Disable(ctx, userID.(string), req.Code); err != nil {
+		h.mfaError(c, err, "failed to disable two-factor authentication")
+		return
+	}
+	h.audit(c, models.AuditMFADisabled, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "two-factor authentication disabled", http.StatusOK, nil)
+}
+
+func (h Handler) mfaError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrMFATokenInvalid),
+		errors.Is(err, service.ErrMFAInvalidCode):
+		handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+	case errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFANotEnrolling):
+		handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+	case errors.Is(err, service.ErrMFARequired):
+		handleResponse(c, h.log, err.Error(), http.StatusForbidden, nil)
+	case errors.Is(err, service.ErrMFATooManyAttempts):
+		handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+	case errors.Is(err, pgx.ErrNoRows):
+		handleResponse(c, h.log, "user not found", http.StatusNotFound, nil)
+	default:
+		handleResponse(c, h.log, msg, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// OAuthStart godoc
+// @Summary      Start OAuth/OIDC login
+// @Description  Provayder (google, apple, facebook yoki sozlangan OIDC) login manzilini qaytaradi; PKCE verifier va state Redis'da saqlanadi
+// @Tags         auth
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Success      200 {object} models.Response{data=models.OAuthStartResponse}
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/{provider}/start [get]
+func (h Handler) OAuthStart(c *gin.Context) {
+	h.oauthStart(c, "")
+}
+
+// OAuthCallback godoc
+// @Summary      OAuth/OIDC callback
+// @Description  code va state ni tekshiradi; login bo‘lsa JWT tokenlar, bog‘lash bo‘lsa tasdiq qaytaradi (Apple form_post ham qabul qilinadi)
+// @Tags         auth
+// @Accept       json
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Param        data body models.OAuthCallbackRequest true "Authorization code and state"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/{provider}/callback [post]
+func (h Handler) OAuthCallback(c *gin.Context) {
+	h.oauthCallback(c, "")
+}
+
+// LinkIdentityCallback godoc
+// @Summary      Finish linking a provider
+// @Description  /user/me/identities/{provider}/start bilan boshlangan bog‘lashni yakunlaydi; state faqat uni boshlagan user bilan qabul qilinadi
+// @Tags         identities
+// @Accept       json
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Param        data body models.OAuthCallbackRequest true "Authorization code and state"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities/{provider}/callback [post]
+// @Security     ApiKeyAuth
+func (h Handler) LinkIdentityCallback(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	h.oauthCallback(c, userID.(string))
+}
+
+func (h Handler) oauthCallback(c *gin.Context, callerUserID string) {
+	var req models.OAuthCallbackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	res, err := h.services.OAuth().Callback(ctx, c.Param("provider"), req.Code, req.State, callerUserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOAuthProviderUnknown):
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+		case errors.Is(err, service.ErrOAuthLinkMismatch):
+			handleResponse(c, h.log, err.Error(), http.StatusForbidden, nil)
+		case errors.Is(err, service.ErrOAuthStateInvalid),
+			errors.Is(err, service.ErrOAuthEmailRequired),
+			errors.Is(err, service.ErrInvalidIDToken),
+			errors.Is(err, service.ErrOAuthExchange):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		case errors.Is(err, service.ErrIdentityAlreadyLinked),
+			errors.Is(err, service.ErrProviderAlreadyLinked),
+			errors.Is(err, service.ErrAccountLinkRequired):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		default:
+			handleResponse(c, h.log, "oauth login failed", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if res.Linked {
+		h.audit(c, models.AuditIdentityLinked, res.UserID, res.UserID, gin.H{"provider": c.Param("provider")})
+		handleResponse(c, h.log, "identity linked", http.StatusOK, nil)
+		return
+	}
+
+	u, err := h.services.User().GetByID(ctx, res.UserID)
+	if err != nil {
+		handleResponse(c, h.log, "failed to load user", http.StatusInternalServerError, err.Error())
+		return
+	}
+	role := u.Role
+	if role == "" {
+		role = "user"
+	}
+
+	h.finishLogin(c, res.UserID, role, "login via "+c.Param("provider"))
+}
+
+// StartLinkIdentity godoc
+// @Summary      Start linking a provider
+// @Description  Joriy akkauntga yangi provayderni bog‘lash uchun login manzilini qaytaradi; callback /user/me/identities/{provider}/callback
+// @Tags         identities
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Success      200 {object} models.Response{data=models.OAuthStartResponse}
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities/{provider}/start [post]
+// @Security     ApiKeyAuth
+func (h Handler) StartLinkIdentity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	h.oauthStart(c, userID.(string))
+}
+
+func (h Handler) oauthStart(c *gin.Context, linkUserID string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.OAuth().Start(ctx, c.Param("provider"), linkUserID)
+	if err != nil {
+		if errors.Is(err, service.ErrOAuthProviderUnknown) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to start oauth flow", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "oauth started", http.StatusOK, resp)
+}
+
+// GetMyIdentities godoc
+// @Summary      List linked providers
+// @Description  user_identities dagi bog‘langan tashqi akkauntlar
+// @Tags         identities
+// @Produce      json
+// @Success      200 {object} models.Response{data=[]models.UserIdentity}
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMyIdentities(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.services.OAuth().ListIdentities(ctx, userID.(string))
+	if err != nil {
+		handleResponse(c, h.log, "failed to load identities", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "identities list", http.StatusOK, list)
+}
+
+// UnlinkIdentity godoc
+// @Summary      Unlink a provider
+// @Description  Provayder bog‘lanishini uzadi (parol, boshqa provayder yoki passkey qolmasa rad etiladi)
+// @Tags         identities
+// @Produce      json
+// @Param        provider path string true "Provider name"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/identities/{provider} [delete]
+// @Security     ApiKeyAuth
+func (h Handler) UnlinkIdentity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.OAuth().Unlink(ctx, userID.(string), c.Param("provider")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoPasswordSet):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		case errors.Is(err, service.ErrIdentityNotLinked):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		default:
+			handleResponse(c, h.log, "failed to unlink identity", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.audit(c, models.AuditIdentityUnlinked, userID.(string), userID.(string), gin.H{"provider": c.Param("provider")})
+	handleResponse(c, h.log, "identity unlinked", http.StatusOK, nil)
+}
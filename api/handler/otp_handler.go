@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// RequestOTP godoc
+// @Summary      Request email login code
+// @Description  Emailga bir martalik login kodi yuboradi (email mavjud bo‘lmasa ham javob bir xil)
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.OTPRequest true "Email"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/otp/request [post]
+func (h Handler) RequestOTP(c *gin.Context) {
+	var req models.OTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.services.OTP().RequestLoginCode(ctx, req.Email); err != nil {
+		handleResponse(c, h.log, "failed to send code", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handleResponse(c, h.log, "if the email is registered, a code has been sent", http.StatusOK, nil)
+}
+
+// VerifyOTP godoc
+// @Summary      Login with email code
+// @Description  Email kodini tekshiradi va Login bilan bir xil access/refresh tokenlarni qaytaradi
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.OTPVerifyRequest true "Email and code"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/otp/verify [post]
+func (h Handler) VerifyOTP(c *gin.Context) {
+	var req models.OTPVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.services.OTP().VerifyLoginCode(ctx, req.Email, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOTPTooManyAttempts):
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+		case errors.Is(err, service.ErrOTPInvalid):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		default:
+			handleResponse(c, h.log, "failed to verify code", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.finishLogin(c, user.ID, user.Role, "login via email code")
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// BrowsePartners godoc
+// @Summary      Browse possible partners
+// @Description  discoverable=false, o‘chirilgan va bloklangan (har ikki yo‘nalishda) userlar chiqmaydi.
+// @Description  Berilmagan filtrlar match_preferences'dan olinadi (ignore_prefs=true — o‘chiradi); keyingi sahifa uchun cursor=next_cursor
+// @Tags         partners
+// @Produce      json
+// @Param        native_lang  query string   false "Partner native language"
+// @Param        target_lang  query string   false "Partner target language"
+// @Param        min_level    query int      false "1..6"
+// @Param        max_level    query int      false "1..6"
+// @Param        country      query []string false "ISO-2 code (repeatable)"
+// @Param        interests    query []int    false "Interest ID (repeatable, any of)"
+// @Param        online       query bool     false "Only users seen in the last 5 minutes"
+// @Param        ignore_prefs query bool     false "Do not apply my match preferences"
+// @Param        sort         query string   false "compatibility (default) | last_seen"
+// @Param        cursor       query string   false "Cursor from next_cursor"
+// @Param        limit        query int      false "1..100, default 20"
+// @Success      200 {object} models.Response{data=models.PartnersResponse}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      403 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /partners [get]
+// @Security     ApiKeyAuth
+func (h Handler) BrowsePartners(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var filter models.PartnerFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		handleResponse(c, h.log, "invalid query", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	resp, err := h.services.Partner().Browse(ctx, userID.(string), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPartnerFilter):
+			handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
+		case errors.Is(err, service.ErrAccountDeleted):
+			handleResponse(c, h.log, err.Error(), http.StatusForbidden, nil)
+		default:
+			handleResponse(c, h.log, "failed to load partners", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	handleResponse(c, h.log, "partners", http.StatusOK, resp)
+}
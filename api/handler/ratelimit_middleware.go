@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/pkg/logger"
+)
+
+// RateKey - so‘rovdan rate limit kalitini oladi (bo‘sh bo‘lsa tekshiruv o‘tkazib yuboriladi).
+type RateKey func(c *gin.Context) string
+
+func RateKeyIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// RateKeyUserID - JWTMiddleware'dan keyin ishlatiladi.
+func RateKeyUserID(c *gin.Context) string {
+	return c.GetString("user_id")
+}
+
+// RateKeyEmail - JSON body'dagi "email" maydoni; body keyingi handler uchun qayta tiklanadi.
+func RateKeyEmail(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(body.Email))
+}
+
+// RateLimit - config.RateLimits dagi qoida bo‘yicha sliding-window cheklov; oshsa 429 + Retry-After.
+// Redis ishlamasa so‘rov o‘tkaziladi (fail-open) va xato loglanadi.
+func (h Handler) RateLimit(rule string, key RateKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		res, err := h.services.RateLimit().Allow(ctx, rule, k)
+		if err != nil {
+			h.log.Error("rate limit check failed", logger.Error(err), logger.String("rule", rule))
+			c.Next()
+			return
+		}
+		if !res.Allowed {
+			tooManyRequests(c, h, res.RetryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+func tooManyRequests(c *gin.Context, h Handler, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	handleResponse(c, h.log, "too many requests", http.StatusTooManyRequests, nil)
+	c.Abort()
+}
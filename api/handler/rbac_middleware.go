@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+)
+
+// RequireRole - JWTMiddleware'dan keyin; tokendagi rol ro‘yxatda bo‘lmasa 403.
+func (h Handler) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		handleResponse(c, h.log, "forbidden", http.StatusForbidden, nil)
+		c.Abort()
+	}
+}
+
+// RequirePermission - tokendagi rol bo‘yicha (arzon, DB'ga murojaat qilmaydi).
+func (h Handler) RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.RoleHasPermission(c.GetString("role"), perm) {
+			handleResponse(c, h.log, "forbidden", http.StatusForbidden, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireFreshPermission - sensitive amallar uchun: rol DB'dan qayta o‘qiladi,
+// shuning uchun rolidan tushirilgan adminning eski tokeni bu yerda ishlamaydi.
+func (h Handler) RequireFreshPermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		u, err := h.services.User().GetByID(ctx, userID)
+		if err != nil {
+			handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+			c.Abort()
+			return
+		}
+		if !models.RoleHasPermission(u.Role, perm) {
+			handleResponse(c, h.log, "forbidden", http.StatusForbidden, nil)
+			c.Abort()
+			return
+		}
+
+		c.Set("role", u.Role)
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// GetMySessions godoc
+// @Summary      List my active sessions
+// @Description  Faol auth_sessions (qurilma, IP, yaratilgan va oxirgi ishlatilgan vaqt); joriy sessiya belgilanadi
+// @Tags         sessions
+// @Produce      json
+// @Success      200 {object} models.Response{data=[]models.SessionInfo}
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/sessions [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMySessions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.services.Session().List(ctx, userID.(string), c.GetString("session_id"))
+	if err != nil {
+		handleResponse(c, h.log, "failed to load sessions", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "sessions list", http.StatusOK, list)
+}
+
+// DeleteMySession godoc
+// @Summary      Revoke a session
+// @Description  Tanlangan sessiyani bekor qiladi (access tokenlari ham bir necha soniyada bekor bo‘ladi)
+// @Tags         sessions
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/sessions/{id} [delete]
+// @Security     ApiKeyAuth
+func (h Handler) DeleteMySession(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.Session().RevokeForUser(ctx, userID.(string), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+			return
+		}
+		handleResponse(c, h.log, "failed to revoke session", http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(c, models.AuditSessionRevoked, userID.(string), userID.(string), gin.H{"session_id": c.Param("id")})
+	handleResponse(c, h.log, "session revoked", http.StatusOK, nil)
+}
+
+// RevokeOtherSessions godoc
+// @Summary      Revoke all other sessions
+// @Description  Joriy sessiyadan tashqari barcha sessiyalarni bekor qiladi
+// @Tags         sessions
+// @Produce      json
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/sessions/revoke-others [post]
+// @Security     ApiKeyAuth
+func (h Handler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.Session().RevokeAllForUser(ctx, userID.(string), c.GetString("session_id")); err != nil {
+		handleResponse(c, h.log, "failed to revoke sessions", http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(c, models.AuditSessionsRevokedOthers, userID.(string), userID.(string), nil)
+	handleResponse(c, h.log, "other sessions revoked", http.StatusOK, nil)
+}
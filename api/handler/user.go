@@ -1,15 +1,20 @@
 package handler
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"speakpall/api/models"
 	"speakpall/pkg/jwt"
+	"speakpall/pkg/logger"
 	"speakpall/pkg/password"
 	"speakpall/pkg/security"
+	"speakpall/service"
 )
 
 // SignUp godoc
@@ -33,6 +38,9 @@ func (h Handler) SignUp(c *gin.Context) {
 	// Parolni service qatlamida hash qilamiz (double-hash bo‘lmasin)
 	userID, err := h.services.User().Create(c.Request.Context(), req)
 	if err != nil {
+		if h.passwordPolicyError(c, err) {
+			return
+		}
 		handleResponse(c, h.log, "failed to create user", http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -50,6 +58,7 @@ func (h Handler) SignUp(c *gin.Context) {
 // @Success      200 {object} models.LoginResponse
 // @Failure      400 {object} models.Response
 // @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
 // @Failure      500 {object} models.Response
 // @Router       /auth/login [post]
 func (h Handler) Login(c *gin.Context) {
@@ -59,34 +68,113 @@ func (h Handler) Login(c *gin.Context) {
 		return
 	}
 
+	// ketma-ket xato parollardan keyingi progressive lockout
+	if left, err := h.services.RateLimit().LoginLockedFor(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		h.log.Error("login lockout check failed", logger.Error(err))
+	} else if left > 0 {
+		tooManyRequests(c, h, left)
+		return
+	}
+
 	user, err := h.services.User().GetForLoginByEmail(c.Request.Context(), req.Email)
 	if err != nil {
-		handleResponse(c, h.log, "user not found", http.StatusUnauthorized, err.Error())
+		h.audit(c, models.AuditLoginFailed, "", "", gin.H{"method": "password", "email_sha256": auditEmail(req.Email), "reason": "unknown_email"})
+		if !h.loginFailed(c, req.Email) {
+			handleResponse(c, h.log, "user not found", http.StatusUnauthorized, err.Error())
+		}
 		return
 	}
 	if err := security.CompareHashAndPassword(user.PasswordHash, req.Password); err != nil {
-		handleResponse(c, h.log, "invalid credentials", http.StatusUnauthorized, "email or password is incorrect")
+		h.audit(c, models.AuditLoginFailed, "", user.ID, gin.H{"method": "password", "reason": "invalid_password"})
+		if !h.loginFailed(c, req.Email) {
+			handleResponse(c, h.log, "invalid credentials", http.StatusUnauthorized, "email or password is incorrect")
+		}
 		return
 	}
+	h.services.RateLimit().LoginSucceeded(c.Request.Context(), req.Email, c.ClientIP())
+
+	// eski bcrypt (yoki eski parametrli) hash — majburiy reset'siz yangi formatga o‘tkaziladi
+	if err := h.services.User().UpgradePasswordHash(c.Request.Context(), user.ID, user.PasswordHash, req.Password); err != nil {
+		h.log.Error("password rehash failed", logger.Error(err), logger.String("user_id", user.ID))
+	}
+
+	h.finishLogin(c, user.ID, user.Role, "login successful")
+}
+
+// passwordPolicyError - parol siyosati buzilgan bo‘lsa 400 va barcha buzilgan qoidalar ro‘yxati.
+func (h Handler) passwordPolicyError(c *gin.Context, err error) bool {
+	pe, ok := password.AsPolicyError(err)
+	if !ok {
+		return false
+	}
+	handleResponse(c, h.log, "password does not meet the policy", http.StatusBadRequest, pe.Violations)
+	return true
+}
 
-	at, err := jwt.GenerateAccessToken(user.ID, user.Role)
+// loginFailed - xatoni qayd qiladi; shu urinish lockout'ni boshlasa 429 + Retry-After yozib true qaytaradi.
+func (h Handler) loginFailed(c *gin.Context, email string) bool {
+	d, err := h.services.RateLimit().LoginFailed(c.Request.Context(), email, c.ClientIP())
 	if err != nil {
-		handleResponse(c, h.log, "failed to generate access token", http.StatusInternalServerError, err.Error())
+		h.log.Error("record login failure failed", logger.Error(err))
+		return false
+	}
+	if d > 0 {
+		tooManyRequests(c, h, d)
+		return true
+	}
+	return false
+}
+
+// finishLogin - birinchi faktor o‘tgach: 2FA yoqilgan (yoki rol uchun majburiy) bo‘lsa
+// tokenlar o‘rniga mfa_token qaytadi, aks holda darhol access/refresh juftligi.
+func (h Handler) finishLogin(c *gin.Context, userID, role, msg string) {
+	if err := h.services.Account().CanLogin(c.Request.Context(), userID); err != nil {
+		h.tokenError(c, err)
 		return
 	}
-	rt, _, err := jwt.GenerateRefreshToken(user.ID)
+	pending, err := h.services.MFA().BeginLogin(c.Request.Context(), userID, role)
 	if err != nil {
-		handleResponse(c, h.log, "failed to generate refresh token", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "failed to check two-factor status", http.StatusInternalServerError, err.Error())
+		return
+	}
+	if pending != nil {
+		handleResponse(c, h.log, "two-factor authentication required", http.StatusOK, pending)
 		return
 	}
 
-	resp := models.LoginResponse{
-		ID:           user.ID,
-		Role:         user.Role,
-		AccessToken:  at,
-		RefreshToken: rt,
+	resp, err := h.issueTokens(c, userID, role)
+	if err != nil {
+		h.tokenError(c, err)
+		return
 	}
-	handleResponse(c, h.log, "login successful", http.StatusOK, resp)
+	handleResponse(c, h.log, msg, http.StatusOK, resp)
+}
+
+// issueTokens - Login, OTP va Google oqimlari uchun bir xil access/refresh juftligini yaratadi
+// (har safar yangi auth_sessions qatori ochiladi). To‘liq autentifikatsiyadan keyin chaqirilgani uchun
+// grace muddatidagi o‘chirilgan akkaunt shu yerda tiklanadi.
+func (h Handler) issueTokens(c *gin.Context, userID, role string) (models.LoginResponse, error) {
+	restored, err := h.services.Account().Restore(c.Request.Context(), userID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if restored {
+		h.audit(c, models.AuditAccountRestored, userID, userID, nil)
+	}
+	resp, err := h.services.Session().Issue(c.Request.Context(), userID, role, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	h.audit(c, models.AuditLoginSucceeded, userID, userID, gin.H{"route": c.FullPath()})
+	return resp, nil
+}
+
+func (h Handler) tokenError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAccountDeleted) {
+		handleResponse(c, h.log, err.Error(), http.StatusForbidden, nil)
+		return
+	}
+	handleResponse(c, h.log, "failed to generate tokens", http.StatusInternalServerError, err.Error())
 }
 
 // RefreshToken godoc
@@ -108,41 +196,19 @@ func (h Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	claims, err := jwt.ExtractClaims(req.RefreshToken)
-	if err != nil {
-		handleResponse(c, h.log, "invalid refresh token", http.StatusUnauthorized, err.Error())
-		return
-	}
-
-	if t, _ := claims["typ"].(string); t != "refresh" {
-		handleResponse(c, h.log, "invalid token type", http.StatusUnauthorized, nil)
-		return
-	}
-
-	userID := fmt.Sprint(claims["user_id"])
-	role := fmt.Sprint(claims["role"])
-	if userID == "" {
-		handleResponse(c, h.log, "invalid claims in refresh token", http.StatusUnauthorized, nil)
-		return
-	}
-
-	at, err := jwt.GenerateAccessToken(userID, role)
-	if err != nil {
-		handleResponse(c, h.log, "failed to generate access token", http.StatusInternalServerError, err.Error())
-		return
-	}
-	rt, _, err := jwt.GenerateRefreshToken(userID)
+	resp, err := h.services.Session().Refresh(c.Request.Context(), req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		handleResponse(c, h.log, "failed to generate refresh token", http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenInvalid),
+			errors.Is(err, service.ErrSessionInvalid),
+			errors.Is(err, service.ErrRefreshTokenReused):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		default:
+			handleResponse(c, h.log, "failed to refresh tokens", http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	resp := models.LoginResponse{
-		ID:           userID,
-		Role:         role,
-		AccessToken:  at,
-		RefreshToken: rt,
-	}
 	handleResponse(c, h.log, "tokens refreshed", http.StatusOK, resp)
 }
 
@@ -173,10 +239,20 @@ func (h Handler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.services.User().ChangePassword(c.Request.Context(), userID.(string), req.OldPassword, req.NewPassword); err != nil {
+		if h.passwordPolicyError(c, err) {
+			return
+		}
 		handleResponse(c, h.log, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
+	// joriy qurilmadan tashqari barcha sessiyalar bekor qilinadi
+	if err := h.services.Session().RevokeAllForUser(c.Request.Context(), userID.(string), c.GetString("session_id")); err != nil {
+		handleResponse(c, h.log, "failed to revoke sessions", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.audit(c, models.AuditPasswordChanged, userID.(string), userID.(string), nil)
 	handleResponse(c, h.log, "password changed successfully", http.StatusOK, nil)
 }
 
@@ -190,6 +266,7 @@ func (h Handler) ChangePassword(c *gin.Context) {
 // @Success      200 {object} models.LoginResponse
 // @Failure      400 {object} models.Response
 // @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
 // @Failure      500 {object} models.Response
 // @Router       /auth/google [post]
 func (h Handler) GoogleAuth(c *gin.Context) {
@@ -205,47 +282,77 @@ func (h Handler) GoogleAuth(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.services.User().GoogleAuth(c.Request.Context(), googleUser.Email, googleUser.Name, googleUser.GoogleID)
-	if err != nil {
-		handleResponse(c, h.log, "failed to create/login user", http.StatusInternalServerError, err.Error())
+	h.googleLogin(c, googleUser)
+}
+
+// GoogleIDTokenAuth godoc
+// @Summary      Google ID token orqali login (mobil)
+// @Description  Android/iOS Google SDK bergan ID tokenni JWKS orqali tekshiradi va JWT tokenlar qaytaradi
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.GoogleIDTokenRequest true "Google ID token"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/google/id-token [post]
+func (h Handler) GoogleIDTokenAuth(c *gin.Context) {
+	var req models.GoogleIDTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
 		return
 	}
 
-	u, err := h.services.User().GetByID(c.Request.Context(), userID)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	googleUser, err := h.services.Google().VerifyIDToken(ctx, req.IDToken)
 	if err != nil {
-		handleResponse(c, h.log, "failed to load user", http.StatusInternalServerError, err.Error())
+		if errors.Is(err, service.ErrGoogleEmailNotVerified) {
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+			return
+		}
+		handleResponse(c, h.log, "Google login failed", http.StatusUnauthorized, err.Error())
 		return
 	}
-	role := u.Role
-	if role == "" {
-		role = "user"
-	}
 
-	at, err := jwt.GenerateAccessToken(userID, role)
+	h.googleLogin(c, googleUser)
+}
+
+// googleLogin: tasdiqlangan Google foydalanuvchisini topadi/yaratadi va token juftligini qaytaradi.
+func (h Handler) googleLogin(c *gin.Context, googleUser *models.GoogleUser) {
+	userID, err := h.services.User().GoogleAuth(c.Request.Context(), *googleUser)
 	if err != nil {
-		handleResponse(c, h.log, "failed to generate access token", http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, service.ErrGoogleEmailNotVerified):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		case errors.Is(err, service.ErrGoogleAlreadyLinked),
+			errors.Is(err, service.ErrAccountLinkRequired):
+			handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+		default:
+			handleResponse(c, h.log, "failed to create/login user", http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
-	rt, _, err := jwt.GenerateRefreshToken(userID)
+
+	u, err := h.services.User().GetByID(c.Request.Context(), userID)
 	if err != nil {
-		handleResponse(c, h.log, "failed to generate refresh token", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "failed to load user", http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	resp := models.LoginResponse{
-		ID:           userID,
-		Role:         role,
-		AccessToken:  at,
-		RefreshToken: rt,
+	role := u.Role
+	if role == "" {
+		role = "user"
 	}
-	handleResponse(c, h.log, "login via google", http.StatusOK, resp)
-}
-
 
+	h.finishLogin(c, userID, role, "login via google")
+}
 
 // Logout godoc
 // @Summary      Logout (chiqish)
-// @Description  JWT tokenlarni va sessionni bekor qiladi (minimal variant: cookie’larni tozalash)
+// @Description  Access tokenni (jti) Redis denylist'ga qo‘shadi, sessiyani (auth_sessions) bekor qiladi va cookie’larni tozalaydi
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -255,7 +362,41 @@ func (h Handler) GoogleAuth(c *gin.Context) {
 // @Router       /auth/logout [post]
 // @Security     ApiKeyAuth
 func (h Handler) Logout(c *gin.Context) {
-	// Minimal, qo‘shimcha helperlarsiz: faqat cookie tozalash
+	ctx := c.Request.Context()
+
+	// access token (Authorization header): jti muddati tugaguncha denylist'ga, sessiyasi bekor qilinadi
+	if token := bearerToken(c); token != "" {
+		if claims, err := jwt.ExtractClaims(token); err == nil && claims["typ"] == "access" {
+			jti, _ := claims["jti"].(string)
+			expStr, _ := claims["exp"].(string)
+			exp, _ := strconv.ParseInt(expStr, 10, 64)
+			if jti != "" {
+				if err := h.services.Redis().DenyAccessToken(ctx, jti, time.Until(time.Unix(exp, 0))); err != nil {
+					handleResponse(c, h.log, "failed to revoke access token", http.StatusInternalServerError, err.Error())
+					return
+				}
+			}
+			if sid, _ := claims["sid"].(string); sid != "" {
+				if err := h.services.Session().Revoke(ctx, sid); err != nil {
+					handleResponse(c, h.log, "failed to revoke session", http.StatusInternalServerError, err.Error())
+					return
+				}
+			}
+		}
+	}
+
+	// refresh_token ixtiyoriy: berilsa, uning sessiyasi (refresh token oilasi) bekor qilinadi
+	var req models.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err == nil {
+		claims, err := jwt.ExtractClaims(req.RefreshToken)
+		if sid, _ := claims["sid"].(string); err == nil && sid != "" {
+			if err := h.services.Session().Revoke(ctx, sid); err != nil {
+				handleResponse(c, h.log, "failed to revoke session", http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+
 	c.SetCookie("access_token", "", -1, "/", "", false, true)
 	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
 	handleResponse(c, h.log, "Logged out successfully", http.StatusOK, nil)
@@ -279,13 +420,15 @@ func (h Handler) RequestPasswordReset(c *gin.Context) {
 		return
 	}
 
-	token, err := h.services.User().CreatePasswordResetToken(c.Request.Context(), req.Email)
-	if err != nil {
+	if err := h.services.User().CreatePasswordResetToken(c.Request.Context(), req.Email); err != nil {
 		handleResponse(c, h.log, "failed to send reset link", http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	handleResponse(c, h.log, "password reset link sent", http.StatusOK, gin.H{"token": token})
+	h.audit(c, models.AuditPasswordResetRequest, "", "", gin.H{"email_sha256": auditEmail(req.Email)})
+
+	// email mavjud bo‘lsa ham, bo‘lmasa ham javob bir xil
+	handleResponse(c, h.log, "if the email is registered, a reset link has been sent", http.StatusOK, nil)
 }
 
 // ResetPassword godoc
@@ -311,21 +454,24 @@ func (h Handler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := password.ValidatePassword(req.NewPassword); err != nil {
-		handleResponse(c, h.log, "invalid password", http.StatusBadRequest, err.Error())
-		return
-	}
-
-	userID, err := h.services.User().ValidatePasswordResetToken(c.Request.Context(), req.Token)
+	userID, err := h.services.User().ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
 	if err != nil {
-		handleResponse(c, h.log, "invalid or expired token", http.StatusUnauthorized, err.Error())
+		switch {
+		case h.passwordPolicyError(c, err):
+		case errors.Is(err, service.ErrResetTokenInvalid):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		default:
+			handleResponse(c, h.log, "failed to reset password", http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	if err := h.services.User().ResetPassword(c.Request.Context(), userID, req.NewPassword); err != nil {
-		handleResponse(c, h.log, "failed to reset password", http.StatusInternalServerError, err.Error())
+	// parol tiklangach barcha sessiyalar bekor qilinadi
+	if err := h.services.Session().RevokeAllForUser(c.Request.Context(), userID, ""); err != nil {
+		handleResponse(c, h.log, "failed to revoke sessions", http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	h.audit(c, models.AuditPasswordReset, "", userID, nil)
 	handleResponse(c, h.log, "password reset successfully", http.StatusOK, gin.H{"message": "Password has been successfully reset."})
 }
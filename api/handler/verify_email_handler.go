@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// VerifyEmail godoc
+// @Summary      Verify email address
+// @Description  Emailga yuborilgan kod orqali users.email_verified ni true qiladi
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.VerifyEmailRequest true "Email and code"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/verify-email [post]
+func (h Handler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.User().VerifyEmail(ctx, req.Email, req.Code); err != nil {
+		switch {
+		case errors.Is(err, service.ErrOTPTooManyAttempts):
+			handleResponse(c, h.log, err.Error(), http.StatusTooManyRequests, nil)
+		case errors.Is(err, service.ErrOTPInvalid):
+			handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+		default:
+			handleResponse(c, h.log, "failed to verify email", http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	handleResponse(c, h.log, "email verified", http.StatusOK, nil)
+}
+
+// ResendVerificationEmail godoc
+// @Summary      Resend verification email
+// @Description  Tasdiqlash kodini qayta yuboradi (cooldown bilan); javob email holatidan qat'i nazar bir xil
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        data body models.ResendVerificationRequest true "Email"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/verify-email/resend [post]
+func (h Handler) ResendVerificationEmail(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.services.User().ResendVerificationEmail(ctx, req.Email); err != nil {
+		handleResponse(c, h.log, "failed to resend verification email", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	handleResponse(c, h.log, "if the email needs verification, a code has been sent", http.StatusOK, nil)
+}
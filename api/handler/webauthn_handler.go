@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"speakpall/api/models"
+	"speakpall/service"
+)
+
+// BeginPasskeyRegistration godoc
+// @Summary      Start passkey registration
+// @Description  navigator.credentials.create() uchun publicKey options; challenge Redis'da 5 daqiqa saqlanadi
+// @Tags         passkeys
+// @Produce      json
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/passkeys/register/begin [post]
+// @Security     ApiKeyAuth
+func (h Handler) BeginPasskeyRegistration(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	opts, err := h.services.WebAuthn().BeginRegistration(ctx, userID.(string))
+	if err != nil {
+		handleResponse(c, h.log, "failed to start passkey registration", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "passkey registration started", http.StatusOK, gin.H{"publicKey": opts})
+}
+
+// FinishPasskeyRegistration godoc
+// @Summary      Finish passkey registration
+// @Description  Authenticator javobini (attestation "none") tekshiradi va passkey'ni saqlaydi
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Param        data body models.PasskeyRegisterFinishRequest true "Credential from navigator.credentials.create()"
+// @Success      201 {object} models.Response{data=models.WebAuthnCredential}
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      409 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/passkeys/register/finish [post]
+// @Security     ApiKeyAuth
+func (h Handler) FinishPasskeyRegistration(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.PasskeyRegisterFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	cred, err := h.services.WebAuthn().FinishRegistration(ctx, userID.(string), req.Name, req.Credential)
+	if err != nil {
+		h.passkeyError(c, err, "failed to register passkey")
+		return
+	}
+	h.audit(c, models.AuditPasskeyAdded, userID.(string), userID.(string), gin.H{"passkey_id": cred.ID, "name": cred.Name})
+	handleResponse(c, h.log, "passkey registered", http.StatusCreated, cred)
+}
+
+// BeginPasskeyLogin godoc
+// @Summary      Start passkey login
+// @Description  navigator.credentials.get() uchun publicKey options; email bo‘lmasa discoverable (usernameless) login
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Param        data body models.PasskeyLoginBeginRequest false "Optional email"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/passkey/login/begin [post]
+func (h Handler) BeginPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginBeginRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	opts, err := h.services.WebAuthn().BeginLogin(ctx, req.Email)
+	if err != nil {
+		handleResponse(c, h.log, "failed to start passkey login", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "passkey login started", http.StatusOK, gin.H{"publicKey": opts})
+}
+
+// FinishPasskeyLogin godoc
+// @Summary      Finish passkey login
+// @Description  Assertion imzosi va hisoblagichni tekshiradi; Login bilan bir xil access/refresh juftligi qaytadi
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Param        data body models.PasskeyLoginFinishRequest true "Credential from navigator.credentials.get()"
+// @Success      200 {object} models.LoginResponse
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      429 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /auth/passkey/login/finish [post]
+func (h Handler) FinishPasskeyLogin(c *gin.Context) {
+	var req models.PasskeyLoginFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := h.services.WebAuthn().FinishLogin(ctx, req.Credential)
+	if err != nil {
+		h.passkeyError(c, err, "passkey login failed")
+		return
+	}
+
+	u, err := h.services.User().GetByID(ctx, res.UserID)
+	if err != nil {
+		handleResponse(c, h.log, "failed to load user", http.StatusInternalServerError, err.Error())
+		return
+	}
+	role := u.Role
+	if role == "" {
+		role = "user"
+	}
+
+	// UV (PIN/biometrika) bilan passkey o‘zi ikki faktor — TOTP qayta so‘ralmaydi
+	if !res.UserVerified {
+		h.finishLogin(c, res.UserID, role, "login via passkey")
+		return
+	}
+	resp, err := h.issueTokens(c, res.UserID, role)
+	if err != nil {
+		h.tokenError(c, err)
+		return
+	}
+	handleResponse(c, h.log, "login via passkey", http.StatusOK, resp)
+}
+
+// GetMyPasskeys godoc
+// @Summary      List my passkeys
+// @Tags         passkeys
+// @Produce      json
+// @Success      200 {object} models.Response{data=[]models.WebAuthnCredential}
+// @Failure      401 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/passkeys [get]
+// @Security     ApiKeyAuth
+func (h Handler) GetMyPasskeys(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.services.WebAuthn().List(ctx, userID.(string))
+	if err != nil {
+		handleResponse(c, h.log, "failed to load passkeys", http.StatusInternalServerError, err.Error())
+		return
+	}
+	handleResponse(c, h.log, "passkeys list", http.StatusOK, list)
+}
+
+// RenameMyPasskey godoc
+// @Summary      Rename a passkey
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Param        id   path string true "Passkey ID"
+// @Param        data body models.RenamePasskeyRequest true "New name"
+// @Success      200 {object} models.Response
+// @Failure      400 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/passkeys/{id} [patch]
+// @Security     ApiKeyAuth
+func (h Handler) RenameMyPasskey(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+	var req models.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleResponse(c, h.log, "invalid request", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.WebAuthn().Rename(ctx, userID.(string), c.Param("id"), req.Name); err != nil {
+		h.passkeyError(c, err, "failed to rename passkey")
+		return
+	}
+	handleResponse(c, h.log, "passkey renamed", http.StatusOK, nil)
+}
+
+// DeleteMyPasskey godoc
+// @Summary      Delete a passkey
+// @Tags         passkeys
+// @Produce      json
+// @Param        id path string true "Passkey ID"
+// @Success      200 {object} models.Response
+// @Failure      401 {object} models.Response
+// @Failure      404 {object} models.Response
+// @Failure      500 {object} models.Response
+// @Router       /user/me/passkeys/{id} [delete]
+// @Security     ApiKeyAuth
+func (h Handler) DeleteMyPasskey(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		handleResponse(c, h.log, "unauthorized", http.StatusUnauthorized, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.services.WebAuthn().Delete(ctx, userID.(string), c.Param("id")); err != nil {
+		h.passkeyError(c, err, "failed to delete passkey")
+		return
+	}
+	h.audit(c, models.AuditPasskeyRemoved, userID.(string), userID.(string), gin.H{"passkey_id": c.Param("id")})
+	handleResponse(c, h.log, "passkey deleted", http.StatusOK, nil)
+}
+
+func (h Handler) passkeyError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrPasskeyChallengeInvalid),
+		errors.Is(err, service.ErrPasskeyInvalid):
+		handleResponse(c, h.log, err.Error(), http.StatusUnauthorized, nil)
+	case errors.Is(err, service.ErrPasskeyAlreadyRegistered):
+		handleResponse(c, h.log, err.Error(), http.StatusConflict, nil)
+	case errors.Is(err, service.ErrPasskeyNotFound):
+		handleResponse(c, h.log, err.Error(), http.StatusNotFound, nil)
+	default:
+		handleResponse(c, h.log, msg, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// audit_events.type qiymatlari
+const (
+	AuditLoginSucceeded        = "login.succeeded"
+	AuditLoginFailed           = "login.failed"
+	AuditPasswordChanged       = "password.changed"
+	AuditPasswordResetRequest  = "password.reset_requested"
+	AuditPasswordReset         = "password.reset"
+	AuditRoleChanged           = "role.changed"
+	AuditIdentityLinked        = "identity.linked"
+	AuditIdentityUnlinked      = "identity.unlinked"
+	AuditMFAEnabled            = "mfa.enabled"
+	AuditMFADisabled           = "mfa.disabled"
+	AuditMFARecoveryRegenerate = "mfa.recovery_codes_regenerated"
+	AuditPasskeyAdded          = "passkey.added"
+	AuditPasskeyRemoved        = "passkey.removed"
+	AuditEmailChanged          = "email.changed"
+	AuditSessionRevoked        = "session.revoked"
+	AuditSessionsRevokedOthers = "sessions.revoked_others"
+	AuditAccountDeleted        = "account.deleted"
+	AuditAccountRestored       = "account.restored"
+	AuditAccountPurged         = "account.purged"
+	AuditDataExportRequested   = "data_export.requested"
+)
+
+// AuditEvent — audit_events qatori. ActorID — amalni bajargan (login oldidan bo‘sh),
+// TargetUserID — ta'sir qilingan akkaunt
+type AuditEvent struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"                     example:"login.succeeded"`
+	ActorID      *string         `json:"actor_id,omitempty"`
+	TargetUserID *string         `json:"target_user_id,omitempty"`
+	IPAddress    string          `json:"ip_address,omitempty"`
+	UserAgent    string          `json:"user_agent,omitempty"`
+	Payload      json.RawMessage `json:"payload,omitempty"        swaggertype:"object"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// AuditEventFilter — query parametrlari; Before — oldingi sahifaning next_before qiymati (id kursor)
+type AuditEventFilter struct {
+	UserID string     `form:"user_id" binding:"omitempty,uuid"`
+	Type   string     `form:"type"    binding:"omitempty,max=64"`
+	From   *time.Time `form:"from"    time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to"      time_format:"2006-01-02T15:04:05Z07:00"`
+	Before int64      `form:"before"  binding:"omitempty,min=1"`
+	Limit  int        `form:"limit"   binding:"omitempty,min=1,max=200"`
+	// TargetOnly - faqat target_user_id bo‘yicha (user o‘z jurnalini ko‘rganda)
+	TargetOnly bool `form:"-"`
+}
+
+type AuditEventsResponse struct {
+	Events     []AuditEvent `json:"events"`
+	NextBefore *int64       `json:"next_before,omitempty"`
+}
+
+// SecurityEvent — user o‘z jurnalida ko‘radigan ko‘rinish. Hodisani boshqa user (masalan, admin rolni o‘zgartirgan)
+// bajargan bo‘lsa, uning ID, IP va user agenti ko‘rsatilmaydi — faqat ByStaff belgisi
+type SecurityEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"                 example:"login.succeeded"`
+	ByStaff   bool            `json:"by_staff,omitempty"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"    swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type SecurityEventsResponse struct {
+	Events     []SecurityEvent `json:"events"`
+	NextBefore *int64          `json:"next_before,omitempty"`
+}
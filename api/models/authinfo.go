@@ -27,6 +27,6 @@ type RefreshTokenResponse struct {
 // Parolni almashtirish
 type ChangePasswordRequest struct {
 	OldPassword    string `json:"old_password"      binding:"required"`
-	NewPassword    string `json:"new_password"      binding:"required,min=6"`
+	NewPassword    string `json:"new_password"      binding:"required"`
 	RepeatPassword string `json:"repeat_password"   binding:"required,eqfield=NewPassword"`
 }
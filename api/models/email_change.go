@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// EmailChange — email_change_requests jadvalidagi qator.
+type EmailChange struct {
+	ID              string     `db:"id"`
+	UserID          string     `db:"user_id"`
+	OldEmail        string     `db:"old_email"`
+	NewEmail        string     `db:"new_email"`
+	RevertExpiresAt *time.Time `db:"revert_expires_at"`
+	ConfirmedAt     *time.Time `db:"confirmed_at"`
+	RevertedAt      *time.Time `db:"reverted_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+// POST /user/me/email
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+}
+
+// POST /user/me/email/confirm
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// POST /auth/email/revert
+type RevertEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
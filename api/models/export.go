@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DataExportQueued  = "queued"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+// DataExport — data_exports qatori (GDPR arxivi)
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Status      string     `json:"status"                 example:"queued"`
+	BlobKey     string     `json:"-"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Quyidagilar arxivdagi JSON fayllar elementlari
+
+type ExportBlock struct {
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportCallSession — sessions jadvali (suhbatlar); PartnerID — ikkinchi ishtirokchi
+type ExportCallSession struct {
+	ID        string     `json:"id"`
+	PartnerID string     `json:"partner_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Topic     *string    `json:"topic,omitempty"`
+	State     string     `json:"state"`
+}
+
+type ExportFeedback struct {
+	SessionID string    `json:"session_id"`
+	RaterID   string    `json:"rater_id"`
+	RateeID   string    `json:"ratee_id"`
+	Rating    int       `json:"rating"`
+	Comment   *string   `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportMessage struct {
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	Kind      string    `json:"kind"`
+	Body      *string   `json:"body,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportNotification struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	Title     *string         `json:"title,omitempty"`
+	Body      *string         `json:"body,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+}
+
+type ExportAuthSession struct {
+	ID         string     `json:"id"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
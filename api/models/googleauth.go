@@ -5,10 +5,17 @@ type GoogleAuthRequest struct {
 	Code string `json:"code" binding:"required" example:"4/0AX4XfW..."` // OAuth authorization code
 }
 
+// GoogleIDTokenRequest — mobil Google SDK bergan ID token (JWT)
+type GoogleIDTokenRequest struct {
+	IDToken string `json:"id_token" binding:"required" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6..."`
+}
+
 // GoogleUser — Google'dan keladigan foydalanuvchi ma'lumotlari
 type GoogleUser struct {
-	Email    string  `json:"email"     example:"user@example.com"`
-	Name     string  `json:"name"      example:"John Doe"`
-	GoogleID string  `json:"google_id" example:"123456789012345678901"`
-	Picture  *string `json:"picture,omitempty" example:"https://lh3.googleusercontent.com/a-/AOh14Gg..."` // optional
+	Email    string `json:"email"     example:"user@example.com"`
+	Name     string `json:"name"      example:"John Doe"`
+	GoogleID string `json:"google_id" example:"123456789012345678901"`
+	// EmailVerified — Google emailni tasdiqlaganmi (tasdiqlanmagan email bilan bog‘lanmaydi)
+	EmailVerified bool    `json:"email_verified"`
+	Picture       *string `json:"picture,omitempty" example:"https://lh3.googleusercontent.com/a-/AOh14Gg..."` // optional
 }
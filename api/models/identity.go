@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const ProviderGoogle = "google"
+
+// UserIdentity — user_identities qatori (tashqi provayder akkaunti)
+type UserIdentity struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Provider    string     `json:"provider"           example:"apple"`
+	Subject     string     `json:"-"`
+	Email       *string    `json:"email,omitempty"    example:"user@privaterelay.appleid.com"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ExternalIdentity — provayder (OIDC id_token yoki userinfo) qaytargan foydalanuvchi
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       *string
+}
+
+// OAuthStartResponse — frontend foydalanuvchini AuthURL ga yo‘naltiradi
+type OAuthStartResponse struct {
+	AuthURL string `json:"auth_url" example:"https://accounts.google.com/o/oauth2/v2/auth?..."`
+	State   string `json:"state"`
+}
+
+// OAuthCallbackRequest — JSON yoki form (Apple form_post) ko‘rinishida keladi
+type OAuthCallbackRequest struct {
+	Code  string `json:"code"  form:"code"  binding:"required"`
+	State string `json:"state" form:"state" binding:"required"`
+}
@@ -0,0 +1,157 @@
+package models
+
+import "time"
+
+// match_attempts.status qiymatlari
+const (
+	MatchStatusQueued    = "queued"
+	MatchStatusMatched   = "matched"
+	MatchStatusCanceled  = "canceled"
+	MatchStatusCompleted = "completed"
+	MatchStatusExpired   = "expired"
+
+	// MatchStatusIdle faqat API javobida: userda hech qanday attempt yo‘q
+	MatchStatusIdle = "idle"
+)
+
+// Navbat rejimlari: standard — bir xil tilni o‘rganayotganlar; tandem — til almashinuvi
+const (
+	MatchModeStandard = "standard"
+	MatchModeTandem   = "tandem"
+)
+
+// match_attempts.tandem_direction — attempt egasi nuqtai nazaridan qaysi yo‘nalish bajarildi
+const (
+	TandemMutual  = "mutual"
+	TandemTeaches = "teaches" // men sherik o‘rganayotgan tilda native
+	TandemLearns  = "learns"  // sherik men o‘rganayotgan tilda native
+)
+
+// MatchScore.Direction (tandem): juftlik ichida qaysi tomon sherigining maqsad tilida native
+const (
+	MatchDirectionATeaches = "a_teaches"
+	MatchDirectionBTeaches = "b_teaches"
+)
+
+// sessions.tandem_fit
+const (
+	TandemFitMutual = "mutual"
+	TandemFitOneWay = "one_way"
+)
+
+// MatchQueueRequest — POST /match/queue; bo‘sh maydonlar profil (target_lang, level) dan olinadi.
+// tandem rejimida profilda native_lang bo‘lishi shart
+type MatchQueueRequest struct {
+	Language string `json:"language" binding:"omitempty,min=2,max=16"          example:"en"`
+	Level    *int   `json:"level"    binding:"omitempty,min=1,max=6"           example:"3"`
+	Mode     string `json:"mode"     binding:"omitempty,oneof=standard tandem" example:"tandem"`
+}
+
+// MatchAttempt — match_attempts qatori
+type MatchAttempt struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"-"`
+	DesiredLanguage string     `json:"language"`
+	DesiredLevel    *int       `json:"level,omitempty"`
+	Status          string     `json:"status"`
+	Mode            string     `json:"mode"`
+	TandemDirection *string    `json:"tandem_direction,omitempty"`
+	MatchedWith     *string    `json:"matched_with,omitempty"`
+	SessionID       *string    `json:"session_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// MatchQueueStatus — GET /match/queue. Attempt bo‘lmasa status "idle";
+// Criteria va EstimatedWait faqat navbatda turganda
+type MatchQueueStatus struct {
+	Status        string         `json:"status"              example:"queued"`
+	Attempt       *MatchAttempt  `json:"attempt,omitempty"`
+	Position      *int           `json:"position,omitempty"` // 1 — navbat boshida
+	QueueSize     int            `json:"queue_size"`
+	Criteria      *MatchCriteria `json:"criteria,omitempty"`
+	EstimatedWait *MatchETA      `json:"estimated_wait,omitempty"`
+}
+
+// Yumshatish bosqichlari (MatchCriteria.Relaxed)
+const (
+	MatchRelaxLevel   = "level"
+	MatchRelaxCountry = "country"
+	MatchRelaxRating  = "rating"
+)
+
+// MatchCriteria — kutish vaqtiga qarab yumshatilgan joriy (effektiv) mezonlar.
+// Bloklar, til/rejim, gender_filter va yosh chegarasi hech qachon yumshatilmaydi
+type MatchCriteria struct {
+	Language     string   `json:"language"`
+	Mode         string   `json:"mode"`
+	MinLevel     *int     `json:"min_level,omitempty"`
+	MaxLevel     *int     `json:"max_level,omitempty"`
+	LevelGap     *int     `json:"level_gap,omitempty"` // oraliq berilmaganda ruxsat etilgan ± farq
+	GenderFilter string   `json:"gender_filter,omitempty"`
+	Countries    []string `json:"countries,omitempty"`
+	MinRating    *int     `json:"min_rating,omitempty"`
+	// Relaxed — qo‘llangan bosqichlar: level, country, rating
+	Relaxed     []string   `json:"relaxed"`
+	NextRelaxAt *time.Time `json:"next_relax_at,omitempty"`
+}
+
+// MatchETA — shu til juftligi bo‘yicha yaqindagi muvaffaqiyatli attemptlar kutish vaqti medianasidan
+type MatchETA struct {
+	Seconds    int    `json:"seconds"`
+	SampleSize int    `json:"sample_size"`
+	Basis      string `json:"basis" example:"language_pair"` // language_pair | language | none
+}
+
+// MatchCandidate — navbatga qo‘yilgan paytdagi user holati (Redis'da JSON); matcher DB'ga murojaat qilmaydi
+type MatchCandidate struct {
+	AttemptID  string   `json:"attempt_id"`
+	Mode       string   `json:"mode,omitempty"` // bo‘sh — standard
+	UserID     string   `json:"user_id"`
+	Language   string   `json:"language"`
+	Level      int      `json:"level,omitempty"` // 0 — noma'lum
+	NativeLang string   `json:"native_lang,omitempty"`
+	Gender     string   `json:"gender,omitempty"`
+	Country    string   `json:"country,omitempty"`
+	Rating     *float64 `json:"rating,omitempty"` // session_feedback o‘rtachasi
+	Age        *int     `json:"age,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+	Interests  []int    `json:"interests,omitempty"` // user_interests.interest_id
+
+	MinLevel     *int     `json:"min_level,omitempty"`
+	MaxLevel     *int     `json:"max_level,omitempty"`
+	LevelGap     int      `json:"-"` // yumshatilgan ± farq (0 — standart); har tick'da qayta hisoblanadi
+	GenderFilter string   `json:"gender_filter,omitempty"`
+	MinRating    *int     `json:"min_rating,omitempty"`
+	Countries    []string `json:"countries,omitempty"`
+	// Blocked — har ikki yo‘nalishdagi bloklar
+	Blocked []string `json:"blocked,omitempty"`
+
+	EnqueuedAt int64 `json:"enqueued_at"` // unix ms
+}
+
+// MatchSignal — bitta signal bo‘yicha baho; Score ikki yo‘nalish o‘rtachasi (0..1).
+// Passed=false bo‘lsa juftlik umuman olinmaydi (filtr)
+type MatchSignal struct {
+	Name   string   `json:"name"   example:"language"`
+	Weight float64  `json:"weight" example:"3"`
+	Score  float64  `json:"score"  example:"0.5"`
+	Passed bool     `json:"passed"`
+	Detail []string `json:"detail,omitempty"`
+}
+
+// MatchScoreQuery — /admin/match/score query parametrlari
+type MatchScoreQuery struct {
+	UserA string `form:"user_a" binding:"required,uuid"`
+	UserB string `form:"user_b" binding:"required,uuid"`
+}
+
+// MatchScore — ikki user juftligi bahosi va signallar bo‘yicha tafsilot
+type MatchScore struct {
+	Compatible bool          `json:"compatible"`
+	Reason     string        `json:"reason,omitempty"` // birinchi rad etgan sabab
+	Total      float64       `json:"total"`            // og‘irlikli o‘rtacha (0..1)
+	Signals    []MatchSignal `json:"signals"`
+	// Direction faqat tandem rejimida: mutual | a_teaches (a ning ona tili b ning maqsadi) | b_teaches
+	Direction string `json:"direction,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// UserMFA — user_mfa qatori (TOTP)
+type UserMFA struct {
+	UserID       string
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// MFAEnrollResponse — authenticator ilovaga qo‘shish uchun
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"      example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/SpeakPall:user@example.com?secret=JBSWY3DPEHPK3PXP&issuer=SpeakPall"`
+}
+
+// MFACodeRequest — TOTP kod (6 raqam) yoki tiklash kodi
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,min=6,max=20" example:"123456"`
+}
+
+// MFARecoveryCodesResponse — faqat bir marta ko‘rsatiladi
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAPendingResponse — Login ikkinchi bosqichni talab qiladi
+type MFAPendingResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	// EnrollmentRequired — rol uchun 2FA majburiy, lekin hali yoqilmagan: avval /auth/mfa/enroll
+	EnrollmentRequired bool `json:"enrollment_required,omitempty"`
+}
+
+// MFAEnrollPendingRequest — POST /auth/mfa/enroll
+type MFAEnrollPendingRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFAVerifyRequest — POST /auth/mfa/verify
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"      binding:"required,min=6,max=20" example:"123456"`
+}
+
+// MFALoginResponse — token juftligi; enrollment shu yerda tasdiqlansa tiklash kodlari ham
+type MFALoginResponse struct {
+	LoginResponse
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// auth_email_tokens.purpose qiymatlari
+const (
+	OTPPurposeLogin         = "login"
+	OTPPurposeVerify        = "verify"
+	OTPPurposeChangeEmail   = "change_email"
+	OTPPurposeDeleteAccount = "delete_account"
+)
+
+// AuthEmailToken — auth_email_tokens jadvalidagi qator (code faqat hash ko‘rinishida).
+type AuthEmailToken struct {
+	ID        string    `db:"id"`
+	Email     string    `db:"email"`
+	CodeHash  string    `db:"code"`
+	Purpose   string    `db:"purpose"`
+	Used      bool      `db:"used"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// POST /auth/otp/request
+type OTPRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// POST /auth/otp/verify
+type OTPVerifyRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code"  binding:"required,len=6,numeric"`
+}
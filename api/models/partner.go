@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// GET /partners saralash turlari
+const (
+	PartnerSortCompatibility = "compatibility"
+	PartnerSortLastSeen      = "last_seen"
+)
+
+// PartnerFilter — GET /partners query parametrlari. Berilmagan filtrlar chaqiruvchining
+// match_preferences'idan olinadi (ignore_prefs=true bo‘lsa olinmaydi)
+type PartnerFilter struct {
+	NativeLang  string   `form:"native_lang" binding:"omitempty,max=16"`
+	TargetLang  string   `form:"target_lang" binding:"omitempty,max=16"`
+	MinLevel    *int     `form:"min_level"   binding:"omitempty,min=1,max=6"`
+	MaxLevel    *int     `form:"max_level"   binding:"omitempty,min=1,max=6"`
+	Countries   []string `form:"country"`
+	Interests   []int    `form:"interests"`
+	OnlineOnly  bool     `form:"online"`
+	IgnorePrefs bool     `form:"ignore_prefs"`
+	Sort        string   `form:"sort"        binding:"omitempty,oneof=compatibility last_seen"`
+	Cursor      string   `form:"cursor"      binding:"omitempty,max=256"`
+	Limit       int      `form:"limit"       binding:"omitempty,min=1,max=100"`
+}
+
+// PartnerSearch — storage so‘rovi: filtrlar yakuniy (preferences qo‘shilgan) holatda
+type PartnerSearch struct {
+	ViewerID    string
+	NativeLang  string
+	TargetLang  string
+	AnyLang     string // native_lang yoki target_lang shu til (match_preferences.target_lang)
+	MinLevel    *int
+	MaxLevel    *int
+	Gender      string
+	Countries   []string
+	MinRating   *int
+	Interests   []int
+	OnlineSince *time.Time
+	AfterSeen   *time.Time // last_seen kursori: (AfterSeen, AfterID) dan keyingilar
+	AfterID     string
+	// AdultAge > 0 bo‘lsa yosh chegarasi qo‘llanadi: MinorsOnly — faqat yoshi ma'lum voyaga yetmaganlar,
+	// aks holda faqat voyaga yetganlar va yoshi noma'lumlar (matcher'dagi ageCompatible bilan bir xil)
+	AdultAge   int
+	MinorsOnly bool
+	Limit      int
+}
+
+// Partner — browse natijasi (ommaviy profil)
+type Partner struct {
+	ID          string     `json:"id"`
+	DisplayName string     `json:"name"`
+	AvatarURL   *string    `json:"avatar,omitempty"`
+	Age         *int       `json:"age,omitempty"`
+	Gender      *string    `json:"gender,omitempty"`
+	CountryCode *string    `json:"country_code,omitempty"`
+	NativeLang  *string    `json:"native_lang,omitempty"`
+	TargetLang  *string    `json:"target_lang,omitempty"`
+	Level       *int       `json:"level,omitempty"`
+	About       *string    `json:"about,omitempty"`
+	Timezone    *string    `json:"timezone,omitempty"`
+	Interests   []int      `json:"interests"`
+	Rating      *float64   `json:"rating,omitempty"`
+	LastSeen    *time.Time `json:"last_seen,omitempty"`
+	Online      bool       `json:"online"`
+	// Compatibility — MatchScorer bahosi (0..1)
+	Compatibility float64 `json:"compatibility"`
+}
+
+type PartnersResponse struct {
+	Partners   []Partner `json:"partners"`
+	NextCursor *string   `json:"next_cursor,omitempty"`
+}
@@ -2,19 +2,20 @@ package models
 
 // GET /user/me javobi uchun (asosiy profil)
 type Profile struct {
-	ID           string  `json:"id"`
-	Email        string  `json:"email"`
-	DisplayName  string  `json:"name"`
-	AvatarURL    *string `json:"avatar,omitempty"`
-	Age          *int    `json:"age,omitempty"`
-	Gender       *string `json:"gender,omitempty"` // "male"|"female"|nil
-	CountryCode  *string `json:"country_code,omitempty"`
-	NativeLang   *string `json:"native_lang,omitempty"`
-	TargetLang   *string `json:"target_lang,omitempty"`
-	Level        *int    `json:"level,omitempty"` // 1..6
-	About        *string `json:"about,omitempty"`
-	Timezone     *string `json:"timezone,omitempty"`
-	CreatedAt    string  `json:"created_at"`
+	ID            string  `json:"id"`
+	Email         string  `json:"email"`
+	DisplayName   string  `json:"name"`
+	AvatarURL     *string `json:"avatar,omitempty"`
+	Age           *int    `json:"age,omitempty"`
+	Gender        *string `json:"gender,omitempty"` // "male"|"female"|nil
+	CountryCode   *string `json:"country_code,omitempty"`
+	NativeLang    *string `json:"native_lang,omitempty"`
+	TargetLang    *string `json:"target_lang,omitempty"`
+	Level         *int    `json:"level,omitempty"` // 1..6
+	About         *string `json:"about,omitempty"`
+	Timezone      *string `json:"timezone,omitempty"`
+	EmailVerified bool    `json:"email_verified"`
+	CreatedAt     string  `json:"created_at"`
 }
 
 // PATCH /user/me (qisman yangilash)
@@ -0,0 +1,39 @@
+package models
+
+// Permission — RequirePermission middleware tekshiradigan huquq
+type Permission string
+
+const (
+	PermAdminAccess     Permission = "admin.access"       // /admin guruhiga kirish
+	PermUsersRead       Permission = "users.read"         // boshqa userlar profilini ko‘rish
+	PermUsersManageRole Permission = "users.manage_roles" // rol berish/olish (sensitive)
+	PermContentModerate Permission = "content.moderate"   // shikoyatlar, bloklash
+	PermAuditRead       Permission = "audit.read"         // xavfsizlik jurnali
+)
+
+// RolePermissions — rol -> huquqlar xaritasi
+var RolePermissions = map[string][]Permission{
+	RoleAdmin:     {PermAdminAccess, PermUsersRead, PermUsersManageRole, PermContentModerate, PermAuditRead},
+	RoleModerator: {PermAdminAccess, PermUsersRead, PermContentModerate},
+	RoleSupport:   {PermAdminAccess, PermUsersRead, PermAuditRead},
+	RoleUser:      {},
+}
+
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+func RoleHasPermission(role string, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateRoleRequest — PUT /admin/users/:id/role
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin moderator support user" example:"moderator"`
+}
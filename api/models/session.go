@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AuthSession — auth_sessions jadvalidagi qator (bitta refresh token oilasi).
+type AuthSession struct {
+	ID         string     `db:"id"`
+	UserID     string     `db:"user_id"`
+	RefreshJTI string     `db:"refresh_jti"`
+	UserAgent  string     `db:"user_agent"`
+	IPAddress  string     `db:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// GET /user/me/sessions elementi
+type SessionInfo struct {
+	ID         string     `json:"id"`
+	Device     string     `json:"device"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Current    bool       `json:"current"`
+}
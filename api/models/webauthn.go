@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebAuthnCredential — webauthn_credentials qatori (passkey)
+type WebAuthnCredential struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"-"`
+	CredentialID   []byte     `json:"-"`
+	PublicKey      []byte     `json:"-"`
+	SignCount      uint32     `json:"-"`
+	AAGUID         []byte     `json:"-"`
+	Transports     []string   `json:"transports"`
+	BackupEligible bool       `json:"backup_eligible"`
+	Name           string     `json:"name"                   example:"MacBook Touch ID"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// PasskeyRegisterFinishRequest — Credential: navigator.credentials.create() natijasi (toJSON)
+type PasskeyRegisterFinishRequest struct {
+	Name       string          `json:"name"       binding:"omitempty,max=64" example:"MacBook Touch ID"`
+	Credential json.RawMessage `json:"credential" binding:"required"         swaggertype:"object"`
+}
+
+// PasskeyLoginBeginRequest — email berilsa faqat shu userning passkey'lari taklif qilinadi
+type PasskeyLoginBeginRequest struct {
+	Email string `json:"email" binding:"omitempty,email" example:"user@example.com"`
+}
+
+// PasskeyLoginFinishRequest — Credential: navigator.credentials.get() natijasi (toJSON)
+type PasskeyLoginFinishRequest struct {
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+// RenamePasskeyRequest — PATCH /user/me/passkeys/{id}
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=64" example:"Work YubiKey"`
+}
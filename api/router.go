@@ -7,6 +7,7 @@ import (
 
 	_ "speakpall/api/docs"
 	"speakpall/api/handler"
+	"speakpall/api/models"
 	"speakpall/pkg/logger"
 	"speakpall/service"
 )
@@ -25,19 +26,39 @@ func New(services service.IServiceManager, log logger.ILogger) *gin.Engine {
 	r.Use(gin.Logger())
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/.well-known/jwks.json", h.JWKS)
+	r.GET("/exports/:token", h.RateLimit("export_download_ip", handler.RateKeyIP), h.DownloadExport)
 
 	// -------- AUTH --------
 	auth := r.Group("/auth")
 	{
 		auth.POST("/signup", h.SignUp)
-		auth.POST("/login", h.Login)
+		auth.POST("/login", h.RateLimit("login_ip", handler.RateKeyIP), h.RateLimit("login_email", handler.RateKeyEmail), h.Login)
 		auth.POST("/refresh-token", h.RefreshToken)
-		auth.POST("/change-password", h.ChangePassword)
+		auth.POST("/change-password", h.JWTMiddleware(), h.RateLimit("change_password_user", handler.RateKeyUserID), h.ChangePassword)
 		auth.POST("/google", h.GoogleAuth)
+		auth.POST("/google/id-token", h.GoogleIDTokenAuth)
 		auth.POST("/logout", h.Logout)
 
-		auth.POST("/request-password-reset", h.RequestPasswordReset)
-		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/request-password-reset", h.RateLimit("password_reset_ip", handler.RateKeyIP), h.RateLimit("password_reset_email", handler.RateKeyEmail), h.RequestPasswordReset)
+		auth.POST("/reset-password", h.RateLimit("password_reset_ip", handler.RateKeyIP), h.ResetPassword)
+
+		auth.POST("/otp/request", h.RateLimit("otp_request_ip", handler.RateKeyIP), h.RateLimit("otp_request_email", handler.RateKeyEmail), h.RequestOTP)
+		auth.POST("/otp/verify", h.RateLimit("otp_verify_ip", handler.RateKeyIP), h.RateLimit("otp_verify_email", handler.RateKeyEmail), h.VerifyOTP)
+
+		auth.POST("/verify-email", h.RateLimit("otp_verify_ip", handler.RateKeyIP), h.RateLimit("otp_verify_email", handler.RateKeyEmail), h.VerifyEmail)
+		auth.POST("/verify-email/resend", h.RateLimit("otp_request_ip", handler.RateKeyIP), h.RateLimit("otp_request_email", handler.RateKeyEmail), h.ResendVerificationEmail)
+
+		auth.POST("/email/revert", h.RevertEmailChange)
+
+		auth.POST("/mfa/enroll", h.RateLimit("mfa_verify_ip", handler.RateKeyIP), h.MFAEnrollPending)
+		auth.POST("/mfa/verify", h.RateLimit("mfa_verify_ip", handler.RateKeyIP), h.MFAVerify)
+
+		auth.POST("/passkey/login/begin", h.RateLimit("login_ip", handler.RateKeyIP), h.BeginPasskeyLogin)
+		auth.POST("/passkey/login/finish", h.RateLimit("login_ip", handler.RateKeyIP), h.FinishPasskeyLogin)
+
+		auth.GET("/:provider/start", h.OAuthStart)
+		auth.POST("/:provider/callback", h.OAuthCallback)
 	}
 
 	// -------- USER (JWT protected) --------
@@ -46,6 +67,36 @@ func New(services service.IServiceManager, log logger.ILogger) *gin.Engine {
 	{
 		user.GET("/me", h.GetMe)
 		user.PATCH("/me", h.PatchMe)
+		user.DELETE("/me", h.DeleteMe)
+		user.POST("/me/delete/code", h.RequestAccountDeletionCode)
+		user.POST("/me/export", h.RequestMyExport)
+		user.GET("/me/export", h.GetMyExport)
+		user.GET("/me/security-events", h.GetMySecurityEvents)
+
+		user.POST("/me/email", h.RateLimit("email_change_user", handler.RateKeyUserID), h.RequestEmailChange)
+		user.POST("/me/email/confirm", h.ConfirmEmailChange)
+
+		user.GET("/me/sessions", h.GetMySessions)
+		user.DELETE("/me/sessions/:id", h.DeleteMySession)
+		user.POST("/me/sessions/revoke-others", h.RevokeOtherSessions)
+
+		user.POST("/me/identities/google", h.LinkGoogleIdentity)
+		user.DELETE("/me/identities/google", h.UnlinkGoogleIdentity)
+		user.GET("/me/identities", h.GetMyIdentities)
+		user.POST("/me/identities/:provider/start", h.StartLinkIdentity)
+		user.POST("/me/identities/:provider/callback", h.LinkIdentityCallback)
+		user.DELETE("/me/identities/:provider", h.UnlinkIdentity)
+
+		user.POST("/me/mfa/enroll", h.EnrollMyMFA)
+		user.POST("/me/mfa/confirm", h.ConfirmMyMFA)
+		user.POST("/me/mfa/recovery-codes", h.RateLimit("mfa_manage_user", handler.RateKeyUserID), h.RegenerateMyRecoveryCodes)
+		user.DELETE("/me/mfa", h.RateLimit("mfa_manage_user", handler.RateKeyUserID), h.DisableMyMFA)
+
+		user.GET("/me/passkeys", h.GetMyPasskeys)
+		user.POST("/me/passkeys/register/begin", h.BeginPasskeyRegistration)
+		user.POST("/me/passkeys/register/finish", h.FinishPasskeyRegistration)
+		user.PATCH("/me/passkeys/:id", h.RenameMyPasskey)
+		user.DELETE("/me/passkeys/:id", h.DeleteMyPasskey)
 
 		user.GET("/me/interests", h.GetMyInterests)
 		user.PUT("/me/interests", h.PutMyInterests)
@@ -60,7 +111,27 @@ func New(services service.IServiceManager, log logger.ILogger) *gin.Engine {
 		user.DELETE("/friends/:id", h.DeleteFriend)
 		user.GET("/friends", h.GetFriends)
 
+	}
+
+	// -------- PARTNERS (JWT protected) --------
+	r.GET("/partners", h.JWTMiddleware(), h.BrowsePartners)
 
+	// -------- MATCH (JWT + tasdiqlangan email) --------
+	match := r.Group("/match")
+	match.Use(h.JWTMiddleware(), h.RequireVerifiedEmail())
+	{
+		match.POST("/queue", h.EnqueueMatch)
+		match.GET("/queue", h.GetMatchQueue)
+		match.DELETE("/queue", h.CancelMatch)
+	}
+
+	// -------- ADMIN (JWT + rol) --------
+	admin := r.Group("/admin")
+	admin.Use(h.JWTMiddleware(), h.RequirePermission(models.PermAdminAccess))
+	{
+		admin.PUT("/users/:id/role", h.RequireFreshPermission(models.PermUsersManageRole), h.UpdateUserRole)
+		admin.GET("/audit-events", h.RequireFreshPermission(models.PermAuditRead), h.ListAuditEvents)
+		admin.GET("/match/score", h.RequirePermission(models.PermUsersRead), h.ExplainMatchScore)
 	}
 
 	return r
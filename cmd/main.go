@@ -5,8 +5,12 @@ import (
 
 	"speakpall/api"
 	"speakpall/config"
+	"speakpall/pkg/blobstore"
+	"speakpall/pkg/jwt"
 	"speakpall/pkg/logger"
 	"speakpall/pkg/mailer"
+	"speakpall/pkg/password"
+	"speakpall/pkg/security"
 	"speakpall/service"
 	"speakpall/storage/postgres"
 	"speakpall/storage/redis"
@@ -15,6 +19,40 @@ import (
 func main() {
 	cfg := config.Load()
 	log := logger.New(cfg.ServiceName)
+
+	keys, err := jwt.LoadKeySet(cfg.JWTPrivateKeyFile, cfg.JWTPublicKeyFiles, cfg.JWTSecretKey)
+	if err != nil {
+		log.Error("error while loading jwt keys", logger.Error(err))
+		return
+	}
+	jwt.SetKeySet(keys)
+
+	hashParams := security.DefaultPasswordParams
+	hashParams.Algorithm = cfg.PasswordHashAlgo
+	hashParams.BcryptCost = cfg.PasswordBcryptCost
+	hashParams.Argon2Time = cfg.Argon2Time
+	hashParams.Argon2Memory = cfg.Argon2MemoryKiB
+	hashParams.Argon2Threads = cfg.Argon2Threads
+	if err := security.SetPasswordParams(hashParams); err != nil {
+		log.Error("invalid password hash config", logger.Error(err))
+		return
+	}
+
+	policy, err := password.NewPolicy(password.Config{
+		MinLength:        cfg.PasswordMinLength,
+		MaxLength:        cfg.PasswordMaxLength,
+		RequireUpper:     cfg.PasswordRequireUpper,
+		RequireLower:     cfg.PasswordRequireLower,
+		RequireDigit:     cfg.PasswordRequireDigit,
+		RequireSpecial:   cfg.PasswordRequireSpecial,
+		ForbidPersonal:   cfg.PasswordForbidPersonal,
+		BreachedListFile: cfg.PasswordBreachedFile,
+	})
+	if err != nil {
+		log.Error("error while loading password policy", logger.Error(err))
+		return
+	}
+
 	pgStore, err := postgres.New(context.Background(), cfg, log, nil)
 	if err != nil {
 		log.Error("error while connecting to db", logger.Error(err))
@@ -25,7 +63,16 @@ func main() {
 	mailService := mailer.New(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPSenderName)
 	redisStore := redis.New(cfg)
 
-	services := service.New(pgStore, log, mailService, redisStore, cfg.Google)
+	blobs, err := blobstore.NewLocal(cfg.ExportDir)
+	if err != nil {
+		log.Error("error while opening blob store", logger.Error(err))
+		return
+	}
+
+	services := service.New(pgStore, log, mailService, redisStore, blobs, policy, cfg)
+	go services.Account().RunPurgeJob(context.Background())
+	go services.Export().RunWorker(context.Background())
+	go services.MatchQueue().RunMatcher(context.Background())
 
 	server := api.New(services, log)
 	log.Info("Service is running on", logger.Int("port", 8081))
@@ -1,9 +1,9 @@
-
 package config
 
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +14,22 @@ type OAuthProviderConfig struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// ID token tekshiruvi: qo‘shimcha ruxsat etilgan aud (Android/iOS client ID'lar) va JWKS manzili
+	ClientIDs []string
+	JWKSURL   string
+
+	// OIDC: Issuer berilsa endpointlar discovery orqali olinadi; quyidagilar uni ustidan yozadi
+	Issuer      string
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	Scopes      []string
+}
+
+// RateLimitRule - window ichida ko‘pi bilan Limit ta so‘rov (env: "10/1m")
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
 }
 
 type Config struct {
@@ -25,6 +41,8 @@ type Config struct {
 
 	ServiceName string
 	LoggerLevel string
+	AppURL      string // emaildagi havolalar uchun frontend manzili
+	APIURL      string // API'ning tashqi manzili (emaildagi to‘g‘ridan-to‘g‘ri yuklab olish havolalari)
 
 	RedisHost      string
 	RedisPort      string
@@ -36,14 +54,78 @@ type Config struct {
 	SMTPUser       string
 	SMTPPass       string
 	SMTPSenderName string
-	JWTSecretKey   string // ✅ YANGI QO‘SHILDI
+	JWTSecretKey   string // ✅ YANGI QO‘SHILDI (endi faqat eski HS256 tokenlarni tekshirish uchun)
+
+	// JWT imzo kalitlari (PEM): faol private kalit va rotation uchun eski public kalitlar
+	JWTPrivateKeyFile string
+	JWTPublicKeyFiles []string
 
+	// parol hashi: "argon2id" yoki "bcrypt"; eski hashlar login paytida shu parametrlarga o‘tkaziladi
+	PasswordHashAlgo   string
+	PasswordBcryptCost int
+	Argon2Time         uint32
+	Argon2MemoryKiB    uint32
+	Argon2Threads      uint8
 
+	// parol siyosati: signup, change-password va reset uchun yagona
+	PasswordMinLength      int
+	PasswordMaxLength      int
+	PasswordRequireUpper   bool
+	PasswordRequireLower   bool
+	PasswordRequireDigit   bool
+	PasswordRequireSpecial bool
+	PasswordForbidPersonal bool
+	PasswordBreachedFile   string
 
+	// RATE_LIMIT_<NAME>=10/1m bilan route/kalit bo‘yicha qoidalar ustidan yoziladi
+	RateLimits map[string]RateLimitRule
+	// login: (email, IP) juftligi LoginMaxFailures ta xatodan keyin lockout, har safar ikki baravar (LoginLockoutMax gacha)
+	LoginMaxFailures   int
+	LoginFailureWindow time.Duration
+	LoginLockoutBase   time.Duration
+	LoginLockoutMax    time.Duration
+	// barcha IP'lardan bitta emailga LoginEmailMaxFailures ta xato — email LoginFailureWindow ga qulflanadi
+	LoginEmailMaxFailures int
 
+	// akkaunt o‘chirish: grace ichida login tiklaydi, keyin purge job anonimlashtiradi
+	AccountDeletionGrace time.Duration
+	AccountPurgeInterval time.Duration
 
-	Google OAuthProviderConfig
+	// GDPR eksport: arxivlar ExportDir'da (lokal blob store), havola ExportLinkTTL amal qiladi
+	ExportDir     string
+	ExportLinkTTL time.Duration
 
+	// matchmaking: navbatda MatchQueueTimeout'dan ko‘p turgan urinish expired bo‘ladi; matcher har MatchInterval'da yuradi
+	MatchQueueTimeout time.Duration
+	MatchInterval     time.Duration
+	// juftlik bahosi: MATCH_WEIGHT_<SIGNAL>=2 bilan signal og‘irligi; MatchMinScore'dan past juftliklar olinmaydi (0..1)
+	MatchWeights  map[string]float64
+	MatchMinScore float64
+	// tandem rejimi: ikkala user shuncha kutgandan keyin bir tomonlama til juftligi ham olinadi
+	MatchTandemFallback time.Duration
+	// mezonlarni yumshatish: shuncha kutgandan keyin daraja oralig‘i kengayadi (har interval ±1, ko‘pi bilan 2),
+	// mamlakat filtri olib tashlanadi, min_rating har interval 1 ga pasayadi; 0 — bosqich o‘chiq
+	MatchRelaxLevelAfter   time.Duration
+	MatchRelaxCountryAfter time.Duration
+	MatchRelaxRatingAfter  time.Duration
+
+	// 2FA: admin roli uchun majburiy; MFAIssuer authenticator ilovada ko‘rinadi
+	MFARequiredForAdmin bool
+	MFAIssuer           string
+	// mfa_pending token bilan MFAMaxFailures ta noto‘g‘ri koddan keyin token yoqiladi va user MFALockout'ga bloklanadi
+	MFAMaxFailures int
+	MFALockout     time.Duration
+
+	// WebAuthn (passkey): RP ID — domen (portsiz), origin'lar — brauzerdagi to‘liq manzillar
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+
+	Google   OAuthProviderConfig
+	Apple    OAuthProviderConfig
+	Facebook OAuthProviderConfig
+	// OIDC_PROVIDERS=keycloak,okta — har biri OIDC_<NAME>_* env blokidan o‘qiladi
+	OIDC map[string]OAuthProviderConfig
 }
 
 func Load() Config {
@@ -61,8 +143,27 @@ func Load() Config {
 
 	cfg.ServiceName = cast.ToString(getOrReturnDefault("SERVICE_NAME", "convertpdfgo"))
 	cfg.LoggerLevel = cast.ToString(getOrReturnDefault("LOGGER_LEVEL", "debug"))
+	cfg.AppURL = cast.ToString(getOrReturnDefault("APP_URL", "http://localhost:8080"))
+	cfg.APIURL = cast.ToString(getOrReturnDefault("API_URL", "http://localhost:8011"))
+
+	cfg.JWTSecretKey = cast.ToString(getOrReturnDefault("JWT_SECRET_KEY", ""))
+	cfg.JWTPrivateKeyFile = cast.ToString(getOrReturnDefault("JWT_PRIVATE_KEY_FILE", ""))
+	cfg.JWTPublicKeyFiles = splitList(cast.ToString(getOrReturnDefault("JWT_PUBLIC_KEY_FILES", "")))
 
-	cfg.JWTSecretKey = cast.ToString(getOrReturnDefault("JWT_SECRET_KEY", "default_secret_key"))
+	cfg.PasswordHashAlgo = cast.ToString(getOrReturnDefault("PASSWORD_HASH_ALGO", "argon2id"))
+	cfg.PasswordBcryptCost = cast.ToInt(getOrReturnDefault("PASSWORD_BCRYPT_COST", 10))
+	cfg.Argon2Time = cast.ToUint32(getOrReturnDefault("ARGON2_TIME", 3))
+	cfg.Argon2MemoryKiB = cast.ToUint32(getOrReturnDefault("ARGON2_MEMORY_KIB", 65536))
+	cfg.Argon2Threads = cast.ToUint8(getOrReturnDefault("ARGON2_THREADS", 2))
+
+	cfg.PasswordMinLength = cast.ToInt(getOrReturnDefault("PASSWORD_MIN_LENGTH", 8))
+	cfg.PasswordMaxLength = cast.ToInt(getOrReturnDefault("PASSWORD_MAX_LENGTH", 128))
+	cfg.PasswordRequireUpper = cast.ToBool(getOrReturnDefault("PASSWORD_REQUIRE_UPPER", true))
+	cfg.PasswordRequireLower = cast.ToBool(getOrReturnDefault("PASSWORD_REQUIRE_LOWER", true))
+	cfg.PasswordRequireDigit = cast.ToBool(getOrReturnDefault("PASSWORD_REQUIRE_DIGIT", true))
+	cfg.PasswordRequireSpecial = cast.ToBool(getOrReturnDefault("PASSWORD_REQUIRE_SPECIAL", true))
+	cfg.PasswordForbidPersonal = cast.ToBool(getOrReturnDefault("PASSWORD_FORBID_PERSONAL", true))
+	cfg.PasswordBreachedFile = cast.ToString(getOrReturnDefault("PASSWORD_BREACHED_LIST_FILE", ""))
 
 	cfg.RedisHost = cast.ToString(getOrReturnDefault("REDIS_HOST", "localhost"))
 	cfg.RedisPort = cast.ToString(getOrReturnDefault("REDIS_PORT", "6379"))
@@ -76,11 +177,52 @@ func Load() Config {
 	cfg.SMTPPass = cast.ToString(getOrReturnDefault("SMTP_PASS", "wwvn ehzs qsvs ojcf"))
 	cfg.SMTPSenderName = cast.ToString(getOrReturnDefault("SMTP_SENDER_NAME", "pdfninja"))
 
+	cfg.RateLimits = map[string]RateLimitRule{}
+	for name, def := range defaultRateLimits {
+		cfg.RateLimits[name] = parseRateLimit(cast.ToString(getOrReturnDefault("RATE_LIMIT_"+strings.ToUpper(name), def)), def)
+	}
+	cfg.LoginMaxFailures = cast.ToInt(getOrReturnDefault("LOGIN_MAX_FAILURES", 5))
+	cfg.LoginFailureWindow = cast.ToDuration(getOrReturnDefault("LOGIN_FAILURE_WINDOW", "15m"))
+	cfg.LoginLockoutBase = cast.ToDuration(getOrReturnDefault("LOGIN_LOCKOUT_BASE", "1m"))
+	cfg.LoginLockoutMax = cast.ToDuration(getOrReturnDefault("LOGIN_LOCKOUT_MAX", "1h"))
+	cfg.LoginEmailMaxFailures = cast.ToInt(getOrReturnDefault("LOGIN_EMAIL_MAX_FAILURES", 100))
+
+	cfg.AccountDeletionGrace = cast.ToDuration(getOrReturnDefault("ACCOUNT_DELETION_GRACE", "720h"))
+	cfg.AccountPurgeInterval = cast.ToDuration(getOrReturnDefault("ACCOUNT_PURGE_INTERVAL", "1h"))
+
+	cfg.ExportDir = cast.ToString(getOrReturnDefault("EXPORT_DIR", "./data/exports"))
+	cfg.ExportLinkTTL = cast.ToDuration(getOrReturnDefault("EXPORT_LINK_TTL", "72h"))
 
-	cfg.Google = OAuthProviderConfig{
-		ClientID:     cast.ToString(getOrReturnDefault("GOOGLE_CLIENT_ID", "")),
-		ClientSecret: cast.ToString(getOrReturnDefault("GOOGLE_CLIENT_SECRET", "")),
-		RedirectURL:  cast.ToString(getOrReturnDefault("GOOGLE_REDIRECT_URL", "")),
+	cfg.MatchQueueTimeout = cast.ToDuration(getOrReturnDefault("MATCH_QUEUE_TIMEOUT", "5m"))
+	cfg.MatchInterval = cast.ToDuration(getOrReturnDefault("MATCH_INTERVAL", "1s"))
+	cfg.MatchWeights = map[string]float64{}
+	for name, def := range defaultMatchWeights {
+		cfg.MatchWeights[name] = cast.ToFloat64(getOrReturnDefault("MATCH_WEIGHT_"+strings.ToUpper(name), def))
+	}
+	cfg.MatchMinScore = cast.ToFloat64(getOrReturnDefault("MATCH_MIN_SCORE", 0))
+	cfg.MatchTandemFallback = cast.ToDuration(getOrReturnDefault("MATCH_TANDEM_FALLBACK", "2m"))
+	cfg.MatchRelaxLevelAfter = cast.ToDuration(getOrReturnDefault("MATCH_RELAX_LEVEL_AFTER", "1m"))
+	cfg.MatchRelaxCountryAfter = cast.ToDuration(getOrReturnDefault("MATCH_RELAX_COUNTRY_AFTER", "2m"))
+	cfg.MatchRelaxRatingAfter = cast.ToDuration(getOrReturnDefault("MATCH_RELAX_RATING_AFTER", "3m"))
+
+	cfg.MFARequiredForAdmin = cast.ToBool(getOrReturnDefault("MFA_REQUIRED_FOR_ADMIN", false))
+	cfg.MFAIssuer = cast.ToString(getOrReturnDefault("MFA_ISSUER", "SpeakPall"))
+	cfg.MFAMaxFailures = cast.ToInt(getOrReturnDefault("MFA_MAX_FAILURES", 5))
+	cfg.MFALockout = cast.ToDuration(getOrReturnDefault("MFA_LOCKOUT", "15m"))
+
+	cfg.WebAuthnRPID = cast.ToString(getOrReturnDefault("WEBAUTHN_RP_ID", "localhost"))
+	cfg.WebAuthnRPName = cast.ToString(getOrReturnDefault("WEBAUTHN_RP_NAME", "SpeakPall"))
+	cfg.WebAuthnOrigins = splitList(cast.ToString(getOrReturnDefault("WEBAUTHN_ORIGINS", cfg.AppURL)))
+
+	cfg.Google = loadOAuthProvider("GOOGLE", "https://accounts.google.com")
+	cfg.Google.JWKSURL = cast.ToString(getOrReturnDefault("GOOGLE_JWKS_URL", "https://www.googleapis.com/oauth2/v3/certs"))
+	cfg.Apple = loadOAuthProvider("APPLE", "https://appleid.apple.com")
+	cfg.Facebook = loadOAuthProvider("FACEBOOK", "")
+
+	cfg.OIDC = map[string]OAuthProviderConfig{}
+	for _, name := range splitList(cast.ToString(getOrReturnDefault("OIDC_PROVIDERS", ""))) {
+		name = strings.ToLower(name)
+		cfg.OIDC[name] = loadOAuthProvider("OIDC_"+strings.ToUpper(name), "")
 	}
 
 	return cfg
@@ -93,4 +235,75 @@ func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
 	}
 
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// defaultRateLimits - rate limit qoidalari nomi -> "limit/window"
+var defaultRateLimits = map[string]string{
+	"login_ip":             "20/1m",
+	"login_email":          "10/10m",
+	"password_reset_ip":    "5/1m",
+	"password_reset_email": "3/1h",
+	"otp_request_ip":       "10/1m",
+	"otp_request_email":    "5/10m",
+	"otp_verify_ip":        "20/1m",
+	"otp_verify_email":     "10/10m",
+	"change_password_user": "5/10m",
+	"email_change_user":    "5/1h",
+	"mfa_verify_ip":        "10/1m",
+	"mfa_manage_user":      "5/10m",
+	"export_download_ip":   "20/1m",
+}
+
+// defaultMatchWeights - juftlik bahosidagi signal nomi -> og‘irlik (0 — ballga ta'sir qilmaydi)
+var defaultMatchWeights = map[string]float64{
+	"language":  3,
+	"level":     2,
+	"gender":    1,
+	"country":   1,
+	"rating":    1,
+	"interests": 2,
+	"timezone":  1,
+}
+
+// parseRateLimit - "10/1m" ko‘rinishidagi qiymat; noto‘g‘ri bo‘lsa standart qiymat olinadi.
+func parseRateLimit(value, fallback string) RateLimitRule {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) == 2 {
+		limit, err1 := cast.ToIntE(strings.TrimSpace(parts[0]))
+		window, err2 := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err1 == nil && err2 == nil && limit > 0 && window > 0 {
+			return RateLimitRule{Limit: limit, Window: window}
+		}
+	}
+	if value != fallback {
+		return parseRateLimit(fallback, fallback)
+	}
+	return RateLimitRule{}
+}
+
+// loadOAuthProvider - <PREFIX>_CLIENT_ID, <PREFIX>_ISSUER, ... env blokini o‘qiydi.
+func loadOAuthProvider(prefix, defaultIssuer string) OAuthProviderConfig {
+	return OAuthProviderConfig{
+		ClientID:     cast.ToString(getOrReturnDefault(prefix+"_CLIENT_ID", "")),
+		ClientSecret: cast.ToString(getOrReturnDefault(prefix+"_CLIENT_SECRET", "")),
+		RedirectURL:  cast.ToString(getOrReturnDefault(prefix+"_REDIRECT_URL", "")),
+		ClientIDs:    splitList(cast.ToString(getOrReturnDefault(prefix+"_CLIENT_IDS", ""))),
+		JWKSURL:      cast.ToString(getOrReturnDefault(prefix+"_JWKS_URL", "")),
+		Issuer:       cast.ToString(getOrReturnDefault(prefix+"_ISSUER", defaultIssuer)),
+		AuthURL:      cast.ToString(getOrReturnDefault(prefix+"_AUTH_URL", "")),
+		TokenURL:     cast.ToString(getOrReturnDefault(prefix+"_TOKEN_URL", "")),
+		UserInfoURL:  cast.ToString(getOrReturnDefault(prefix+"_USERINFO_URL", "")),
+		Scopes:       splitList(cast.ToString(getOrReturnDefault(prefix+"_SCOPES", ""))),
+	}
+}
+
+// splitList - vergul bilan ajratilgan env qiymatini ro‘yxatga aylantiradi.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -2,9 +2,40 @@ package config
 
 import "time"
 
-var SignKey = []byte("asd@#lskd2!aw32k34242WSASdsk32")
-
 const (
 	AccessExpireTime  = time.Minute * 20
 	RefreshExpireTime = time.Hour * 24
-)
\ No newline at end of file
+)
+
+// Email OTP
+const (
+	OTPCodeLength  = 6
+	OTPExpireTime  = time.Minute * 10
+	OTPMaxAttempts = 5
+	OTPResendDelay = time.Minute
+)
+
+// Email almashtirish: eski manzilga yuborilgan revert havolasi amal qilish muddati
+const EmailChangeRevertTime = time.Hour * 24 * 7
+
+// Parolni tiklash havolasi amal qilish muddati
+const PasswordResetExpireTime = time.Hour
+
+// OAuth/OIDC: state + PKCE verifier Redis'da shu muddat saqlanadi
+const OAuthStateExpireTime = time.Minute * 10
+
+// WebAuthn: registration/login challenge Redis'da shu muddat saqlanadi
+const WebAuthnChallengeExpireTime = time.Minute * 5
+
+// GDPR eksport: user uchun kuniga bitta arxiv; ishga tushib qolgan (crash) job shu muddatdan keyin qayta olinadi
+const (
+	DataExportCooldown   = time.Hour * 24
+	DataExportStaleAfter = time.Minute * 30
+)
+
+// Partner browse: shu muddat ichida so‘rov yuborgan user online hisoblanadi; last_seen ko‘pi bilan har
+// LastSeenTouchInterval'da yoziladi
+const (
+	OnlineWindow          = time.Minute * 5
+	LastSeenTouchInterval = time.Minute
+)
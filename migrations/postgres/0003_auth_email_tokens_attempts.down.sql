@@ -0,0 +1 @@
+ALTER TABLE auth_email_tokens DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,3 @@
+-- AUTH EMAIL TOKENS: noto'g'ri urinishlar soni
+ALTER TABLE auth_email_tokens
+  ADD COLUMN IF NOT EXISTS attempts int NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- EMAIL CHANGE REQUESTS
+CREATE TABLE IF NOT EXISTS email_change_requests (
+  id                 uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id            uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  old_email          citext NOT NULL,
+  new_email          citext NOT NULL,
+  revert_token_hash  text UNIQUE,
+  revert_expires_at  timestamptz,
+  confirmed_at       timestamptz,
+  reverted_at        timestamptz,
+  created_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS email_change_requests_user_time_idx
+  ON email_change_requests (user_id, created_at DESC);
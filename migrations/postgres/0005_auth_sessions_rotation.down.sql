@@ -0,0 +1,3 @@
+ALTER TABLE auth_sessions
+  DROP COLUMN IF EXISTS refresh_jti,
+  DROP COLUMN IF EXISTS last_used_at;
@@ -0,0 +1,4 @@
+-- AUTH SESSIONS: refresh token rotation (har bir qator = bitta refresh token oilasi)
+ALTER TABLE auth_sessions
+  ADD COLUMN IF NOT EXISTS refresh_jti  text,
+  ADD COLUMN IF NOT EXISTS last_used_at timestamptz;
@@ -0,0 +1,12 @@
+-- PASSWORD RESET TOKENS (faqat SHA-256 hash saqlanadi)
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+  id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash  text UNIQUE NOT NULL,
+  expires_at  timestamptz NOT NULL,
+  used_at     timestamptz,
+  created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_idx
+  ON password_reset_tokens (user_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS user_identities_user_idx;
+DROP TABLE IF EXISTS user_identities;
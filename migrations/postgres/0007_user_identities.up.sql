@@ -0,0 +1,19 @@
+-- USER IDENTITIES (bitta user bir nechta tashqi provayder: google, apple, facebook, oidc)
+CREATE TABLE IF NOT EXISTS user_identities (
+  id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id        uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider       text NOT NULL,
+  subject        text NOT NULL,
+  email          citext,
+  created_at     timestamptz NOT NULL DEFAULT now(),
+  last_login_at  timestamptz,
+  UNIQUE (provider, subject),
+  UNIQUE (user_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_idx ON user_identities (user_id);
+
+-- mavjud google_id bog‘lanishlari ko‘chiriladi
+INSERT INTO user_identities (user_id, provider, subject, email)
+SELECT id, 'google', google_id, email FROM users WHERE google_id IS NOT NULL
+ON CONFLICT DO NOTHING;
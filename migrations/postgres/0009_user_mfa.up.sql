@@ -0,0 +1,18 @@
+-- TOTP 2FA: confirmed_at NULL bo‘lsa ro‘yxatdan o‘tish (enrollment) hali tasdiqlanmagan
+CREATE TABLE IF NOT EXISTS user_mfa (
+  user_id         uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret          text NOT NULL,
+  confirmed_at    timestamptz,
+  last_used_step  bigint NOT NULL DEFAULT 0,
+  created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+-- bir martalik tiklash kodlari (faqat SHA-256 hash saqlanadi)
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+  id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash   text NOT NULL,
+  used_at     timestamptz,
+  created_at  timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (user_id, code_hash)
+);
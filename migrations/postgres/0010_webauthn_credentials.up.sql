@@ -0,0 +1,16 @@
+-- WebAuthn passkey'lar: credential_id authenticator beradi, public_key — COSE_Key baytlari
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+  id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id          uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  credential_id    bytea NOT NULL UNIQUE,
+  public_key       bytea NOT NULL,
+  sign_count       bigint NOT NULL DEFAULT 0,
+  aaguid           bytea,
+  transports       text[] NOT NULL DEFAULT '{}',
+  backup_eligible  boolean NOT NULL DEFAULT false,
+  name             text NOT NULL CHECK (char_length(name) BETWEEN 1 AND 64),
+  created_at       timestamptz NOT NULL DEFAULT now(),
+  last_used_at     timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_idx ON webauthn_credentials (user_id);
@@ -0,0 +1,7 @@
+DELETE FROM auth_email_tokens WHERE purpose = 'delete_account';
+ALTER TABLE auth_email_tokens DROP CONSTRAINT IF EXISTS auth_email_tokens_purpose_check;
+ALTER TABLE auth_email_tokens ADD CONSTRAINT auth_email_tokens_purpose_check
+  CHECK (purpose IN ('login','verify','change_email'));
+
+DROP INDEX IF EXISTS users_pending_purge_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS purged_at;
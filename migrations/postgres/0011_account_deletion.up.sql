@@ -0,0 +1,10 @@
+-- soft delete: deleted_at qo‘yiladi, grace muddatidan keyin purge job PII'ni o‘chiradi va purged_at yozadi
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS users_pending_purge_idx
+  ON users (deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+-- akkauntni o‘chirishni tasdiqlash kodi
+ALTER TABLE auth_email_tokens DROP CONSTRAINT IF EXISTS auth_email_tokens_purpose_check;
+ALTER TABLE auth_email_tokens ADD CONSTRAINT auth_email_tokens_purpose_check
+  CHECK (purpose IN ('login','verify','change_email','delete_account'));
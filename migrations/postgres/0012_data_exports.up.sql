@@ -0,0 +1,18 @@
+-- GDPR eksport: arxiv blob store'da (blob_key), yuklab olish havolasi — token_hash, expires_at gacha
+CREATE TABLE IF NOT EXISTS data_exports (
+  id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id       uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status        text NOT NULL DEFAULT 'queued' CHECK (status IN ('queued','running','ready','failed','expired')),
+  blob_key      text,
+  size_bytes    bigint,
+  token_hash    text UNIQUE,
+  error         text,
+  expires_at    timestamptz,
+  created_at    timestamptz NOT NULL DEFAULT now(),
+  started_at    timestamptz,
+  completed_at  timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS data_exports_user_created_idx ON data_exports (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS data_exports_queued_idx ON data_exports (created_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS data_exports_ready_idx ON data_exports (expires_at) WHERE status = 'ready';
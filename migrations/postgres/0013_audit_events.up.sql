@@ -0,0 +1,29 @@
+-- xavfsizlik jurnali: faqat INSERT (UPDATE/DELETE trigger bilan taqiqlangan).
+-- actor_id/target_user_id FK'siz: purge qilingan user yozuvlari ham saqlanib qoladi
+CREATE TABLE IF NOT EXISTS audit_events (
+  id              bigserial PRIMARY KEY,
+  type            text NOT NULL,
+  actor_id        uuid,
+  target_user_id  uuid,
+  ip_address      inet,
+  user_agent      text,
+  payload         jsonb NOT NULL DEFAULT '{}',
+  created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_user_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_events_type_idx ON audit_events (type, id DESC);
+CREATE INDEX IF NOT EXISTS audit_events_created_idx ON audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events;
+CREATE TRIGGER audit_events_no_update
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
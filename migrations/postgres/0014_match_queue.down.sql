@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS match_attempts_one_queued_idx;
+
+ALTER TABLE match_attempts
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,8 @@
+-- match navbati: jonli navbat Redis'da, har bir o‘tish shu jadvalda.
+-- bitta userda bir vaqtda faqat bitta queued attempt bo‘lishi mumkin
+ALTER TABLE match_attempts
+  ADD COLUMN IF NOT EXISTS session_id  uuid REFERENCES sessions(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS updated_at  timestamptz;
+
+CREATE UNIQUE INDEX IF NOT EXISTS match_attempts_one_queued_idx
+  ON match_attempts (user_id) WHERE status = 'queued';
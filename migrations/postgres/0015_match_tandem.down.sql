@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS sessions_mode_idx;
+
+ALTER TABLE sessions
+  DROP COLUMN IF EXISTS tandem_fit,
+  DROP COLUMN IF EXISTS mode;
+
+ALTER TABLE match_attempts
+  DROP COLUMN IF EXISTS tandem_direction,
+  DROP COLUMN IF EXISTS mode;
@@ -0,0 +1,13 @@
+-- tandem rejimi: A ning ona tili B ning maqsad tili va aksincha.
+-- tandem_direction attempt egasi nuqtai nazaridan: mutual | teaches (men sherikning tilida native) | learns (sherik mening tilimda native)
+ALTER TABLE match_attempts
+  ADD COLUMN IF NOT EXISTS mode             text NOT NULL DEFAULT 'standard' CHECK (mode IN ('standard','tandem')),
+  ADD COLUMN IF NOT EXISTS tandem_direction text CHECK (tandem_direction IN ('mutual','teaches','learns'));
+
+-- sessions: analitika uchun tandem va bir tomonlama sessiyalarni ajratish
+ALTER TABLE sessions
+  ADD COLUMN IF NOT EXISTS mode       text NOT NULL DEFAULT 'standard' CHECK (mode IN ('standard','tandem')),
+  ADD COLUMN IF NOT EXISTS tandem_fit text CHECK (tandem_fit IN ('mutual','one_way'));
+
+CREATE INDEX IF NOT EXISTS sessions_mode_idx
+  ON sessions (mode, tandem_fit, started_at DESC);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS match_attempts_wait_idx;
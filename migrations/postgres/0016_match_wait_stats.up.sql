@@ -0,0 +1,4 @@
+-- navbat ETA: til bo‘yicha yaqindagi matched attemptlar kutish vaqti
+CREATE INDEX IF NOT EXISTS match_attempts_wait_idx
+  ON match_attempts (desired_language, mode, created_at DESC)
+  WHERE status = 'matched';
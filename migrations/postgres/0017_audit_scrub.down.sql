@@ -0,0 +1,9 @@
+-- email hash'lari qaytarilmaydi
+DROP FUNCTION IF EXISTS audit_events_scrub_user(uuid);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,46 @@
+-- audit_events append-only qoladi, lekin purge qilingan user hodisalaridagi PII (ip, user agent, payload)
+-- faqat audit_events_scrub_user() orqali tozalanadi. Trigger UPDATE'ni faqat shu funksiya ichida
+-- va faqat maydonlarni bo‘shatish uchun o‘tkazadi; DELETE har doim taqiqlangan.
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+     AND current_setting('speakpall.audit_scrub', true) = 'on'
+     AND NEW.id = OLD.id
+     AND NEW.type = OLD.type
+     AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+     AND NEW.target_user_id IS NOT DISTINCT FROM OLD.target_user_id
+     AND NEW.created_at = OLD.created_at
+     AND NEW.ip_address IS NULL
+     AND NEW.user_agent IS NULL
+     AND NEW.payload = '{}'::jsonb THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION audit_events_scrub_user(uid uuid)
+RETURNS bigint AS $$
+DECLARE
+  n bigint;
+BEGIN
+  PERFORM set_config('speakpall.audit_scrub', 'on', true);
+  UPDATE audit_events
+     SET ip_address = NULL, user_agent = NULL, payload = '{}'::jsonb
+   WHERE (target_user_id = uid OR actor_id = uid)
+     AND (ip_address IS NOT NULL OR user_agent IS NOT NULL OR payload <> '{}'::jsonb);
+  GET DIAGNOSTICS n = ROW_COUNT;
+  PERFORM set_config('speakpall.audit_scrub', 'off', true);
+  RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+-- eski yozuvlardagi xom email (login.failed, password.reset_requested) normallashtirilgan emailning
+-- SHA-256'i bilan almashtiriladi (ilova ham shu formatda yozadi); bir martalik, shuning uchun trigger o‘chirib turiladi
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_no_update;
+UPDATE audit_events
+   SET payload = (payload - 'email')
+       || jsonb_build_object('email_sha256', encode(sha256(convert_to(lower(btrim(payload->>'email')), 'UTF8')), 'hex'))
+ WHERE payload ? 'email';
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_no_update;
@@ -0,0 +1,44 @@
+GRANT UPDATE, DELETE, TRUNCATE ON audit_events TO CURRENT_USER;
+
+GRANT speakpall_audit_scrubber TO CURRENT_USER;
+DROP FUNCTION IF EXISTS audit_events_scrub_user(uuid);
+REVOKE speakpall_audit_scrubber FROM CURRENT_USER;
+
+-- 0017 holati: GUC bilan boshqariladigan istisno
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+     AND current_setting('speakpall.audit_scrub', true) = 'on'
+     AND NEW.id = OLD.id
+     AND NEW.type = OLD.type
+     AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+     AND NEW.target_user_id IS NOT DISTINCT FROM OLD.target_user_id
+     AND NEW.created_at = OLD.created_at
+     AND NEW.ip_address IS NULL
+     AND NEW.user_agent IS NULL
+     AND NEW.payload = '{}'::jsonb THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION audit_events_scrub_user(uid uuid)
+RETURNS bigint AS $$
+DECLARE
+  n bigint;
+BEGIN
+  PERFORM set_config('speakpall.audit_scrub', 'on', true);
+  UPDATE audit_events
+     SET ip_address = NULL, user_agent = NULL, payload = '{}'::jsonb
+   WHERE (target_user_id = uid OR actor_id = uid)
+     AND (ip_address IS NOT NULL OR user_agent IS NOT NULL OR payload <> '{}'::jsonb);
+  GET DIAGNOSTICS n = ROW_COUNT;
+  PERFORM set_config('speakpall.audit_scrub', 'off', true);
+  RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+REVOKE ALL ON audit_events FROM speakpall_audit_scrubber;
+DROP ROLE IF EXISTS speakpall_audit_scrubber;
@@ -0,0 +1,60 @@
+-- 0017 dagi istisno GUC (speakpall.audit_scrub) bilan boshqarilardi — uni istalgan sessiya SET qila oladi.
+-- Endi jurnalni faqat speakpall_audit_scrubber roli tozalay oladi: audit_events_scrub_user() shu rol nomidan
+-- (SECURITY DEFINER) ishlaydi, trigger esa current_user'ni tekshiradi. Ilova roli UPDATE/DELETE huquqidan mahrum.
+-- Eslatma: ilova roli jadval egasi yoki superuser bo‘lmasligi kerak, aks holda u huquqlarni o‘ziga qaytara oladi.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'speakpall_audit_scrubber') THEN
+    CREATE ROLE speakpall_audit_scrubber NOLOGIN;
+  END IF;
+END;
+$$;
+
+GRANT SELECT, UPDATE ON audit_events TO speakpall_audit_scrubber;
+
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+     AND current_user = 'speakpall_audit_scrubber'
+     AND NEW.id = OLD.id
+     AND NEW.type = OLD.type
+     AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+     AND NEW.target_user_id IS NOT DISTINCT FROM OLD.target_user_id
+     AND NEW.created_at = OLD.created_at
+     AND NEW.ip_address IS NULL
+     AND NEW.user_agent IS NULL
+     AND NEW.payload = '{}'::jsonb THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION audit_events_scrub_user(uid uuid)
+RETURNS bigint
+SECURITY DEFINER
+SET search_path = pg_catalog, public
+AS $$
+DECLARE
+  n bigint;
+BEGIN
+  UPDATE public.audit_events
+     SET ip_address = NULL, user_agent = NULL, payload = '{}'::jsonb
+   WHERE (target_user_id = uid OR actor_id = uid)
+     AND (ip_address IS NOT NULL OR user_agent IS NOT NULL OR payload <> '{}'::jsonb);
+  GET DIAGNOSTICS n = ROW_COUNT;
+  RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+-- egasini almashtirish uchun migratsiya roli vaqtincha speakpall_audit_scrubber a'zosi bo‘ladi
+GRANT speakpall_audit_scrubber TO CURRENT_USER;
+ALTER FUNCTION audit_events_scrub_user(uuid) OWNER TO speakpall_audit_scrubber;
+REVOKE speakpall_audit_scrubber FROM CURRENT_USER;
+
+REVOKE ALL ON FUNCTION audit_events_scrub_user(uuid) FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION audit_events_scrub_user(uuid) TO CURRENT_USER;
+
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_events FROM PUBLIC;
+REVOKE UPDATE, DELETE, TRUNCATE ON audit_events FROM CURRENT_USER;
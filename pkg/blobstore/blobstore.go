@@ -0,0 +1,23 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store - katta fayllar (eksport arxivlari) uchun kalit -> bayt oqimi.
+// Kalitlar "/" bilan ajratilgan nisbiy yo‘l: "exports/<user_id>/<id>.zip".
+type Store interface {
+	// Put oqimni oxirigacha yozadi va yozilgan baytlar sonini qaytaradi; xato bo‘lsa yarim fayl qolmaydi
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open topilmasa ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete mavjud bo‘lmagan kalit uchun xato qaytarmaydi
+	Delete(ctx context.Context, key string) error
+}
@@ -1,14 +1,20 @@
 package generateotpcode
 
 import (
-	"math/rand"
-	"strconv"
+	"crypto/rand"
+	"math/big"
 )
 
-func GenerateOTPCode(length int) string {
-	code := ""
-	for i := 0; i < length; i++ {
-		code += strconv.Itoa(rand.Intn(10))
+// GenerateOTPCode crypto/rand asosida length xonali raqamli kod yaratadi.
+func GenerateOTPCode(length int) (string, error) {
+	max := big.NewInt(10)
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
 	}
-	return code
-}
\ No newline at end of file
+	return string(code), nil
+}
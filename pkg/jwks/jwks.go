@@ -0,0 +1,250 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found in jwks")
+
+// JWK - RFC 7517 kaliti (RSA, EC va OKP/Ed25519 public kalitlar).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Set - /.well-known/jwks.json formati.
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey - JWK'ni Go public kalitiga aylantiradi.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad n: %w", k.Kid, err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad e: %w", k.Kid, err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad x: %w", k.Kid, err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad y: %w", k.Kid, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: bad x", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwk %s: unsupported kty %q", k.Kid, k.Kty)
+	}
+}
+
+// FromPublicKey - Go public kalitini JWK'ga aylantiradi (RSA va Ed25519).
+func FromPublicKey(pub crypto.PublicKey) (JWK, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// Thumbprint - RFC 7638 JWK thumbprint (base64url SHA-256); kid sifatida ishlatiladi.
+func Thumbprint(k JWK) string {
+	var canonical string
+	switch k.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Crv, k.X, k.Y)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, k.Crv, k.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Cache - masofaviy JWKS'ni keshlaydi. Noma'lum kid kelsa (kalit rotation) qayta yuklaydi,
+// lekin minRefresh dan tez-tez emas.
+type Cache struct {
+	url        string
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+
+	// fetchMu bir vaqtda faqat bitta yuklashga ruxsat beradi; mu ostida HTTP so‘rov bajarilmaydi
+	fetchMu sync.Mutex
+	mu      sync.RWMutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func New(url string, ttl time.Duration) *Cache {
+	return &Cache{
+		url:        url,
+		ttl:        ttl,
+		minRefresh: 30 * time.Second,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		keys:       map[string]crypto.PublicKey{},
+	}
+}
+
+// SetMinRefresh - noma'lum kid sababli qayta yuklashlar orasidagi minimal oraliq (standart 30s).
+func (c *Cache) SetMinRefresh(d time.Duration) {
+	c.mu.Lock()
+	c.minRefresh = d
+	c.mu.Unlock()
+}
+
+// Key - kid bo‘yicha public kalitni qaytaradi.
+func (c *Cache) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	age := time.Since(c.fetched)
+	minRefresh := c.minRefresh
+	c.mu.RUnlock()
+
+	if ok && age < c.ttl {
+		return key, nil
+	}
+	// eskirgan kesh yoki noma'lum kid — qayta yuklash
+	if !ok && age < minRefresh {
+		return nil, ErrKeyNotFound
+	}
+	if err := c.refresh(ctx); err != nil {
+		if ok {
+			return key, nil // tarmoq xatosida eski kalit bilan ishlashda davom etamiz
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// refresh: kalitlar lock'siz yuklanadi va faqat map almashtirilayotganda yoziladi —
+// sekin JWKS so‘rovi keshdan o‘qiyotgan Key() chaqiruvlarini to‘xtatmaydi.
+func (c *Cache) refresh(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	// boshqa goroutine hozirgina yangilagan bo‘lishi mumkin
+	c.mu.RLock()
+	recent := time.Since(c.fetched) < c.minRefresh
+	c.mu.RUnlock()
+	if recent {
+		return nil
+	}
+
+	keys, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetched = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks fetch failed: status %d", resp.StatusCode)
+	}
+
+	var set Set
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks decode failed: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue // noma'lum turdagi kalitlar o‘tkazib yuboriladi
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
@@ -0,0 +1,221 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer - kalitlar to‘plamini almashtirish va so‘rovlarni sanash mumkin bo‘lgan soxta JWKS endpoint.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	set     Set
+	status  int
+	fetches atomic.Int32
+	hold    chan struct{} // berilsa, javob shu kanal yopilguncha kutadi
+	started chan struct{}
+}
+
+func newJWKSServer(t *testing.T, keys ...JWK) *jwksServer {
+	t.Helper()
+	s := &jwksServer{set: Set{Keys: keys}, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		set, status, hold, started := s.set, s.status, s.hold, s.started
+		s.mu.Unlock()
+		if started != nil {
+			close(started)
+		}
+		if hold != nil {
+			<-hold
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...JWK) {
+	s.mu.Lock()
+	s.set = Set{Keys: keys}
+	s.mu.Unlock()
+}
+
+func testJWK(t *testing.T, kid string) JWK {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := FromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Kid = kid
+	return k
+}
+
+func TestCacheRefreshesOnUnknownKid(t *testing.T) {
+	srv := newJWKSServer(t, testJWK(t, "k1"))
+	c := New(srv.URL, time.Hour)
+	c.SetMinRefresh(0)
+	ctx := context.Background()
+
+	if _, err := c.Key(ctx, "k1"); err != nil {
+		t.Fatalf("k1: %v", err)
+	}
+	if _, err := c.Key(ctx, "k1"); err != nil || srv.fetches.Load() != 1 {
+		t.Fatalf("cached k1: err=%v fetches=%d, want 1 fetch", err, srv.fetches.Load())
+	}
+
+	// kalit rotation: yangi kid kesh ichida yo‘q — qayta yuklanadi
+	srv.setKeys(testJWK(t, "k1"), testJWK(t, "k2"))
+	if _, err := c.Key(ctx, "k2"); err != nil {
+		t.Fatalf("k2 after rotation: %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Fatalf("fetches = %d, want 2", got)
+	}
+
+	if _, err := c.Key(ctx, "k3"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("k3: got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestCacheThrottlesUnknownKid(t *testing.T) {
+	srv := newJWKSServer(t, testJWK(t, "k1"))
+	c := New(srv.URL, time.Hour)
+	ctx := context.Background()
+
+	if _, err := c.Key(ctx, "k1"); err != nil {
+		t.Fatalf("k1: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.Key(ctx, "unknown"); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("unknown kid: got %v, want ErrKeyNotFound", err)
+		}
+	}
+	if got := srv.fetches.Load(); got != 1 {
+		t.Fatalf("fetches = %d, want 1 (minRefresh must throttle unknown kids)", got)
+	}
+}
+
+func TestCacheServesStaleKeyOnFetchError(t *testing.T) {
+	srv := newJWKSServer(t, testJWK(t, "k1"))
+	c := New(srv.URL, time.Hour)
+	c.SetMinRefresh(0)
+	ctx := context.Background()
+
+	if _, err := c.Key(ctx, "k1"); err != nil {
+		t.Fatalf("k1: %v", err)
+	}
+	c.ttl = 0
+	srv.mu.Lock()
+	srv.status = http.StatusInternalServerError
+	srv.mu.Unlock()
+
+	if _, err := c.Key(ctx, "k1"); err != nil {
+		t.Fatalf("stale k1 on fetch error: %v", err)
+	}
+	if _, err := c.Key(ctx, "k2"); err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("unknown kid on fetch error: got %v, want fetch error", err)
+	}
+}
+
+func TestCacheReadersNotBlockedByRefresh(t *testing.T) {
+	srv := newJWKSServer(t, testJWK(t, "k1"))
+	c := New(srv.URL, time.Hour)
+	c.SetMinRefresh(0)
+	ctx := context.Background()
+
+	if _, err := c.Key(ctx, "k1"); err != nil {
+		t.Fatalf("k1: %v", err)
+	}
+
+	hold, started := make(chan struct{}), make(chan struct{})
+	srv.mu.Lock()
+	srv.hold, srv.started = hold, started
+	srv.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Key(ctx, "k2")
+		done <- err
+	}()
+	<-started
+
+	// sekin yuklash davomida keshdagi kalit darhol qaytadi
+	got := make(chan error, 1)
+	go func() {
+		_, err := c.Key(ctx, "k1")
+		got <- err
+	}()
+	select {
+	case err := <-got:
+		if err != nil {
+			t.Fatalf("k1 during refresh: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cache hit blocked by an in-flight refresh")
+	}
+
+	close(hold)
+	if err := <-done; !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("k2: got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pub := range []interface{ Equal(crypto.PublicKey) bool }{&rsaKey.PublicKey, edPub} {
+		k, err := FromPublicKey(pub)
+		if err != nil {
+			t.Fatalf("%T: %v", pub, err)
+		}
+		back, err := k.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: %v", k.Kty, err)
+		}
+		if !pub.Equal(back) {
+			t.Fatalf("%s: key changed after round trip", k.Kty)
+		}
+	}
+}
+
+// RFC 7638, 3.1-bo‘lim namunasi
+func TestThumbprint(t *testing.T) {
+	k := JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn" +
+			"64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbI" +
+			"SD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	if got, want := Thumbprint(k), "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Fatalf("thumbprint %s, want %s", got, want)
+	}
+}
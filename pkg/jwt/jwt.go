@@ -3,10 +3,10 @@ package jwt
 import (
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 var (
@@ -17,58 +17,76 @@ var (
 const (
 	AccessTokenTTL  = 15 * time.Minute
 	RefreshTokenTTL = 7 * 24 * time.Hour
+	// MFAPendingTTL - parol to‘g‘ri, lekin 2FA kodi hali kiritilmagan
+	MFAPendingTTL = 5 * time.Minute
 )
 
-func getSecretKey() []byte {
-	secret := os.Getenv("JWT_SECRET_KEY")
-	if secret == "" {
-		panic("JWT_SECRET_KEY is not set in environment")
+// sign - faol kalit bilan imzolaydi, headerga kid qo‘yiladi.
+func sign(claims jwt.MapClaims) (string, error) {
+	ks, err := keySet()
+	if err != nil {
+		return "", err
 	}
-	return []byte(secret)
+	token := jwt.NewWithClaims(ks.method, claims)
+	token.Header["kid"] = ks.signKid
+	return token.SignedString(ks.signKey)
 }
 
-// GenerateAccessToken - faqat access token
-func GenerateAccessToken(userID, role string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+// GenerateAccessToken - faqat access token (sid - auth_sessions.id)
+func GenerateAccessToken(userID, role, sessionID string) (string, error) {
+	claims := jwt.MapClaims{}
 
 	claims["user_id"] = userID
 	claims["role"] = role
+	claims["sid"] = sessionID
+	claims["jti"] = uuid.NewString() // logout'da denylist uchun
 	claims["typ"] = "access"
 	claims["exp"] = time.Now().Add(AccessTokenTTL).Unix()
 	claims["iat"] = time.Now().Unix()
 
-	return token.SignedString(getSecretKey())
+	return sign(claims)
 }
 
-// GenerateRefreshToken - faqat refresh token
-func GenerateRefreshToken(userID string) (string, string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+// GenerateRefreshToken - faqat refresh token; jti rotation uchun auth_sessions.refresh_jti da saqlanadi
+func GenerateRefreshToken(userID, sessionID string) (string, string, error) {
+	claims := jwt.MapClaims{}
 
-	jti := fmt.Sprintf("%d", time.Now().UnixNano()) // unique ID
+	jti := uuid.NewString() // unique ID
 
 	claims["user_id"] = userID
+	claims["sid"] = sessionID
 	claims["typ"] = "refresh"
 	claims["jti"] = jti
 	claims["exp"] = time.Now().Add(RefreshTokenTTL).Unix()
 	claims["iat"] = time.Now().Unix()
 
-	signed, err := token.SignedString(getSecretKey())
+	signed, err := sign(claims)
 	if err != nil {
 		return "", "", err
 	}
 	return signed, jti, nil
 }
 
+// GenerateMFAPendingToken - faqat /auth/mfa/* uchun (JWTMiddleware typ=access talab qiladi)
+func GenerateMFAPendingToken(userID string) (string, error) {
+	claims := jwt.MapClaims{}
+
+	claims["user_id"] = userID
+	claims["typ"] = "mfa_pending"
+	claims["jti"] = uuid.NewString()
+	claims["exp"] = time.Now().Add(MFAPendingTTL).Unix()
+	claims["iat"] = time.Now().Unix()
+
+	return sign(claims)
+}
+
 // ParseToken - tokenni tekshiradi va claims qaytaradi
 func ParseToken(tokenString string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getSecretKey(), nil
-	})
+	ks, err := keySet()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.Parse(tokenString, ks.keyFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +121,12 @@ func ExtractClaims(tokenString string) (map[string]interface{}, error) {
 	if jti, ok := parsedClaims["jti"]; ok {
 		result["jti"] = stringify(jti)
 	}
+	if sid, ok := parsedClaims["sid"]; ok {
+		result["sid"] = stringify(sid)
+	}
+	if exp, ok := parsedClaims["exp"]; ok {
+		result["exp"] = stringify(exp)
+	}
 	return result, nil
 }
 
@@ -115,4 +139,4 @@ func stringify(v interface{}) string {
 	default:
 		return fmt.Sprintf("%v", val)
 	}
-}
\ No newline at end of file
+}
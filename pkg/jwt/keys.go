@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"speakpall/pkg/jwks"
+)
+
+var ErrNoSigningKey = errors.New("jwt signing key is not configured")
+
+// KeySet - bitta faol imzolovchi kalit va rotation uchun bir nechta tekshiruv kalitlari (kid bo‘yicha).
+// Kalit almashtirilganda eski public kalit JWT_PUBLIC_KEY_FILES da qoldiriladi —
+// u bilan imzolangan tokenlar muddati tugaguncha ishlayveradi.
+type KeySet struct {
+	signKey interface{}
+	signKid string
+	method  jwt.SigningMethod
+
+	verify map[string]crypto.PublicKey
+	// HS256 dan o‘tish davri: bo‘sh bo‘lmasa eski (kid'siz) HS256 tokenlar faqat tekshiriladi
+	legacySecret []byte
+}
+
+var current atomic.Pointer[KeySet]
+
+// SetKeySet - ishga tushishda (cmd/main.go) bir marta chaqiriladi.
+func SetKeySet(ks *KeySet) {
+	current.Store(ks)
+}
+
+func keySet() (*KeySet, error) {
+	ks := current.Load()
+	if ks == nil {
+		return nil, ErrNoSigningKey
+	}
+	return ks, nil
+}
+
+// LoadKeySet - PEM fayllardan o‘qiydi: privateKeyFile (RSA yoki Ed25519) imzolaydi,
+// publicKeyFiles (public yoki private PEM) faqat tekshiruv uchun.
+func LoadKeySet(privateKeyFile string, publicKeyFiles []string, legacySecret string) (*KeySet, error) {
+	if privateKeyFile == "" {
+		return nil, ErrNoSigningKey
+	}
+	key, err := readPEMKey(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a private key", privateKeyFile)
+	}
+
+	var verify []crypto.PublicKey
+	for _, f := range publicKeyFiles {
+		k, err := readPEMKey(f)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := k.(crypto.Signer); ok {
+			k = s.Public()
+		}
+		verify = append(verify, k)
+	}
+
+	var secret []byte
+	if legacySecret != "" {
+		secret = []byte(legacySecret)
+	}
+	return NewKeySet(signer, verify, secret)
+}
+
+func NewKeySet(signer crypto.Signer, verify []crypto.PublicKey, legacySecret []byte) (*KeySet, error) {
+	ks := &KeySet{
+		verify:       map[string]crypto.PublicKey{},
+		legacySecret: legacySecret,
+	}
+
+	switch k := signer.(type) {
+	case *rsa.PrivateKey:
+		ks.signKey, ks.method = k, jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		ks.signKey, ks.method = k, jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", signer)
+	}
+
+	kid, err := keyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	ks.signKid = kid
+	ks.verify[kid] = signer.Public()
+
+	for _, pub := range verify {
+		kid, err := keyID(pub)
+		if err != nil {
+			return nil, err
+		}
+		ks.verify[kid] = pub
+	}
+	return ks, nil
+}
+
+// JWKS - /.well-known/jwks.json uchun barcha tekshiruv kalitlari.
+func JWKS() jwks.Set {
+	set := jwks.Set{Keys: []jwks.JWK{}}
+	ks := current.Load()
+	if ks == nil {
+		return set
+	}
+	for kid, pub := range ks.verify {
+		k, err := jwks.FromPublicKey(pub)
+		if err != nil {
+			continue
+		}
+		k.Kid, k.Use = kid, "sig"
+		set.Keys = append(set.Keys, k)
+	}
+	return set
+}
+
+// keyFunc - kid bo‘yicha kalit tanlaydi; algoritm kalit turiga mos bo‘lishi shart.
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if ks.legacySecret == nil || t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return ks.legacySecret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+		if t.Method.Alg() != jwt.SigningMethodRS256.Alg() && t.Method.Alg() != jwt.SigningMethodEdDSA.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		kid, _ := t.Header["kid"].(string)
+		pub, ok := ks.verify[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return pub, nil
+	default:
+		return nil, errors.New("unexpected signing method")
+	}
+}
+
+func keyID(pub crypto.PublicKey) (string, error) {
+	k, err := jwks.FromPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return jwks.Thumbprint(k), nil
+}
+
+func readPEMKey(path string) (interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt key: %w", err)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+}
@@ -0,0 +1,245 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func edKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func useKeySet(t *testing.T, signer crypto.Signer, verify []crypto.PublicKey, legacy []byte) *KeySet {
+	t.Helper()
+	ks, err := NewKeySet(signer, verify, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := current.Load()
+	SetKeySet(ks)
+	t.Cleanup(func() { current.Store(prev) })
+	return ks
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "u1", "typ": "access", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, testClaims())
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSignAndVerify(t *testing.T) {
+	cases := []struct {
+		name   string
+		signer crypto.Signer
+		alg    string
+	}{
+		{"RS256", rsaKey(t), "RS256"},
+		{"EdDSA", edKey(t), "EdDSA"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ks := useKeySet(t, c.signer, nil, nil)
+
+			tok, err := GenerateAccessToken("u1", "admin", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, _, err := new(jwt.Parser).ParseUnverified(tok, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["alg"] != c.alg || parsed.Header["kid"] != ks.signKid {
+				t.Fatalf("header %v, want alg=%s kid=%s", parsed.Header, c.alg, ks.signKid)
+			}
+
+			claims, err := ExtractClaims(tok)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if claims["user_id"] != "u1" || claims["role"] != "admin" || claims["sid"] != "s1" || claims["typ"] != "access" {
+				t.Fatalf("unexpected claims %v", claims)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKey := rsaKey(t), edKey(t)
+
+	useKeySet(t, oldKey, nil, nil)
+	oldTok, err := GenerateAccessToken("u1", "user", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// yangi kalit imzolaydi, eski public kalit tekshiruv to‘plamida qoladi
+	ks := useKeySet(t, newKey, []crypto.PublicKey{oldKey.Public()}, nil)
+	if _, err := ParseToken(oldTok); err != nil {
+		t.Fatalf("token signed by retired key: %v", err)
+	}
+	newTok, err := GenerateAccessToken("u1", "user", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(newTok); err != nil {
+		t.Fatalf("token signed by active key: %v", err)
+	}
+	if got := len(JWKS().Keys); got != 2 {
+		t.Fatalf("jwks has %d keys, want 2", got)
+	}
+	for _, k := range JWKS().Keys {
+		if _, ok := ks.verify[k.Kid]; !ok || k.Use != "sig" {
+			t.Fatalf("unexpected jwk %+v", k)
+		}
+	}
+
+	// eski kalit to‘plamdan olib tashlangach uning tokenlari rad etiladi
+	useKeySet(t, newKey, nil, nil)
+	if _, err := ParseToken(oldTok); err == nil {
+		t.Fatal("token signed by removed key must be rejected")
+	}
+}
+
+func TestKeyFuncRejects(t *testing.T) {
+	signer, other := rsaKey(t), rsaKey(t)
+	ks := useKeySet(t, signer, nil, nil)
+	otherKid, err := keyID(other.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"unknown kid", signWith(t, jwt.SigningMethodRS256, otherKid, other)},
+		{"missing kid", signWith(t, jwt.SigningMethodRS256, "", signer)},
+		{"kid of another key", signWith(t, jwt.SigningMethodRS256, ks.signKid, other)},
+		{"alg not allowed", signWith(t, jwt.SigningMethodRS384, ks.signKid, signer)},
+		{"alg does not match kid key type", signWith(t, jwt.SigningMethodEdDSA, ks.signKid, edKey(t))},
+		{"HS256 with public key as secret", signWith(t, jwt.SigningMethodHS256, ks.signKid, pubDER)},
+		{"none", signWith(t, jwt.SigningMethodNone, ks.signKid, jwt.UnsafeAllowNoneSignatureType)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := ParseToken(c.token); err == nil {
+				t.Fatal("token must be rejected")
+			}
+		})
+	}
+}
+
+func TestLegacyHS256(t *testing.T) {
+	secret := []byte("legacy-secret")
+	legacy := signWith(t, jwt.SigningMethodHS256, "", secret)
+
+	useKeySet(t, edKey(t), nil, nil)
+	if _, err := ParseToken(legacy); err == nil {
+		t.Fatal("HS256 token must be rejected without a legacy secret")
+	}
+
+	useKeySet(t, edKey(t), nil, secret)
+	if _, err := ParseToken(legacy); err != nil {
+		t.Fatalf("legacy HS256 token: %v", err)
+	}
+	if _, err := ParseToken(signWith(t, jwt.SigningMethodHS256, "", []byte("other"))); err == nil {
+		t.Fatal("HS256 token with a wrong secret must be rejected")
+	}
+	if _, err := ParseToken(signWith(t, jwt.SigningMethodHS512, "", secret)); err == nil {
+		t.Fatal("HS512 must be rejected")
+	}
+}
+
+func TestNoKeySet(t *testing.T) {
+	prev := current.Load()
+	current.Store(nil)
+	t.Cleanup(func() { current.Store(prev) })
+
+	if _, err := GenerateAccessToken("u1", "user", "s1"); err != ErrNoSigningKey {
+		t.Fatalf("got %v, want ErrNoSigningKey", err)
+	}
+	if got := len(JWKS().Keys); got != 0 {
+		t.Fatalf("jwks has %d keys, want 0", got)
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, typ string, der []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	active, retired := edKey(t), rsaKey(t)
+	activeDER, err := x509.MarshalPKCS8PrivateKey(active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredDER, err := x509.MarshalPKIXPublicKey(retired.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := write("active.pem", "PRIVATE KEY", activeDER)
+	pub := write("retired.pem", "PUBLIC KEY", retiredDER)
+	rsaPriv := write("retired-private.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(retired))
+
+	ks, err := LoadKeySet(priv, []string{pub, rsaPriv}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.method != jwt.SigningMethodEdDSA || len(ks.verify) != 2 || ks.legacySecret != nil {
+		t.Fatalf("unexpected key set: method=%s verify=%d", ks.method.Alg(), len(ks.verify))
+	}
+
+	if _, err := LoadKeySet("", nil, ""); err != ErrNoSigningKey {
+		t.Fatalf("got %v, want ErrNoSigningKey", err)
+	}
+	if _, err := LoadKeySet(pub, nil, ""); err == nil {
+		t.Fatal("public key must not be accepted as the signing key")
+	}
+	if _, err := LoadKeySet(filepath.Join(dir, "missing.pem"), nil, ""); err == nil {
+		t.Fatal("missing key file must fail")
+	}
+}
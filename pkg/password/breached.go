@@ -0,0 +1,75 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// BreachedList - SHA-1 hashning birinchi 8 baytidan tuzilgan tartiblangan indeks.
+// Har parol uchun 8 bayt xotira; noto‘g‘ri moslik ehtimoli ~2^-64, qidiruv binary search.
+type BreachedList struct {
+	prefixes []uint64
+}
+
+// LoadBreachedList - qatorlar: oddiy parol yoki 40 belgili SHA-1 hex (ixtiyoriy ":count" bilan).
+// Bo‘sh qatorlar va "#" bilan boshlanganlar o‘tkazib yuboriladi.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	var prefixes []uint64
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if p, ok := hexPrefix(line); ok {
+			prefixes = append(prefixes, p)
+			continue
+		}
+		prefixes = append(prefixes, hashPrefix(line))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+
+	slices.Sort(prefixes)
+	return &BreachedList{prefixes: slices.Compact(prefixes)}, nil
+}
+
+func (b *BreachedList) Contains(password string) bool {
+	_, found := slices.BinarySearch(b.prefixes, hashPrefix(password))
+	return found
+}
+
+func (b *BreachedList) Len() int {
+	return len(b.prefixes)
+}
+
+func hashPrefix(password string) uint64 {
+	sum := sha1.Sum([]byte(password))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// hexPrefix - "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8[:count]" qatoridan prefiks.
+func hexPrefix(line string) (uint64, bool) {
+	h, _, _ := strings.Cut(line, ":")
+	if len(h) != 40 {
+		return 0, false
+	}
+	raw, err := hex.DecodeString(h)
+	if err != nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(raw[:8]), true
+}
@@ -0,0 +1,78 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeList(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := writeList(t,
+		"# comment",
+		"",
+		"password123",
+		"qwerty\r",
+		// SHA-1("P@ssw0rd") HIBP formatida, katta harflar bilan
+		"21BD12DC183F740EE76F27B78EB39C8AD972A757:9000",
+		// SHA-1("letmein"), count'siz va kichik harflar
+		"b7a875fc1ea228b9061041b7cec4bd3c52ab3ce3",
+		"password123", // takror
+	)
+	list, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %v", err)
+	}
+	if list.Len() != 4 {
+		t.Fatalf("got %d entries, want 4 (comments, blanks and duplicates skipped)", list.Len())
+	}
+
+	cases := []struct {
+		password string
+		want     bool
+	}{
+		{"password123", true},
+		{"qwerty", true},
+		{"P@ssw0rd", true},
+		{"letmein", true},
+		{"# comment", false},
+		{"Password123", false},
+		{"21BD12DC183F740EE76F27B78EB39C8AD972A757", false},
+	}
+	for _, c := range cases {
+		if got := list.Contains(c.password); got != c.want {
+			t.Errorf("Contains(%q) = %v, want %v", c.password, got, c.want)
+		}
+	}
+}
+
+func TestLoadBreachedListMissingFile(t *testing.T) {
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "nope.txt")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if _, err := NewPolicy(Config{BreachedListFile: filepath.Join(t.TempDir(), "nope.txt")}); err == nil {
+		t.Fatal("NewPolicy must fail when the list cannot be loaded")
+	}
+}
+
+func TestPolicyBreached(t *testing.T) {
+	p, err := NewPolicy(Config{BreachedListFile: writeList(t, "Summer2024!")})
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+	if got := violations(t, p.Validate("Summer2024!", UserInfo{})); len(got) != 1 || got[0] != RuleBreached {
+		t.Fatalf("got %v, want [breached]", got)
+	}
+	if err := p.Validate("Winter2024!", UserInfo{}); err != nil {
+		t.Fatalf("unexpected violation: %v", err)
+	}
+}
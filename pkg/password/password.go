@@ -2,29 +2,160 @@ package password
 
 import (
 	"errors"
-	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-func ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return errors.New("Password must be at least 8 characters long")
+// Rule - buzilgan qoida nomi (frontend shu kalit bo‘yicha xabar ko‘rsatadi).
+type Rule string
+
+const (
+	RuleMinLength     Rule = "min_length"
+	RuleMaxLength     Rule = "max_length"
+	RuleUppercase     Rule = "uppercase"
+	RuleLowercase     Rule = "lowercase"
+	RuleDigit         Rule = "digit"
+	RuleSpecial       Rule = "special"
+	RuleContainsEmail Rule = "contains_email"
+	RuleContainsName  Rule = "contains_name"
+	RuleBreached      Rule = "breached"
+)
+
+// personal ma'lumot qismlari shu uzunlikdan qisqa bo‘lsa tekshirilmaydi ("al", "jo" kabi)
+const minPersonalToken = 3
+
+type Violation struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError - barcha buzilgan qoidalar (birinchisi emas).
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return "password policy: " + strings.Join(msgs, "; ")
+}
+
+// AsPolicyError - handlerlar uchun qisqa yordamchi.
+func AsPolicyError(err error) (*PolicyError, bool) {
+	var pe *PolicyError
+	ok := errors.As(err, &pe)
+	return pe, ok
+}
+
+// Config - env'dan (config.Config) to‘ldiriladi.
+type Config struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	// ForbidPersonal - parol email (local part) yoki ism bo‘laklarini o‘z ichiga olmasin
+	ForbidPersonal bool
+	// BreachedListFile - har qatorda parol yoki SHA-1 hex (HIBP "HASH:count" formati ham)
+	BreachedListFile string
+}
+
+var DefaultConfig = Config{
+	MinLength:      8,
+	MaxLength:      128,
+	RequireUpper:   true,
+	RequireLower:   true,
+	RequireDigit:   true,
+	RequireSpecial: true,
+	ForbidPersonal: true,
+}
+
+// UserInfo - parolda bo‘lmasligi kerak bo‘lgan shaxsiy ma'lumotlar.
+type UserInfo struct {
+	Email       string
+	DisplayName string
+}
+
+type Policy struct {
+	cfg      Config
+	breached *BreachedList
+}
+
+func NewPolicy(cfg Config) (*Policy, error) {
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedListFile != "" {
+		list, err := LoadBreachedList(cfg.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
 	}
+	return p, nil
+}
 
-	if !regexp.MustCompile(`[A-Z]`).MatchString(password) {
-		return errors.New("Password must contain at least one uppercase letter")
+// Validate - buzilgan qoidalar bo‘lsa *PolicyError qaytaradi.
+func (p *Policy) Validate(password string, info UserInfo) error {
+	var v []Violation
+	add := func(r Rule, msg string) { v = append(v, Violation{Rule: r, Message: msg}) }
+
+	n := utf8.RuneCountInString(password)
+	if p.cfg.MinLength > 0 && n < p.cfg.MinLength {
+		add(RuleMinLength, "password is too short")
+	}
+	if p.cfg.MaxLength > 0 && n > p.cfg.MaxLength {
+		add(RuleMaxLength, "password is too long")
 	}
 
-	if !regexp.MustCompile(`[a-z]`).MatchString(password) {
-		return errors.New("Password must contain at least one lowercase letter")
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			special = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		add(RuleUppercase, "password must contain an uppercase letter")
+	}
+	if p.cfg.RequireLower && !lower {
+		add(RuleLowercase, "password must contain a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		add(RuleDigit, "password must contain a digit")
+	}
+	if p.cfg.RequireSpecial && !special {
+		add(RuleSpecial, "password must contain a special character")
 	}
 
-	if !regexp.MustCompile(`[0-9]`).MatchString(password) {
-		return errors.New("Password must contain at least one number")
+	if p.cfg.ForbidPersonal {
+		lp := strings.ToLower(password)
+		local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(info.Email)), "@")
+		if utf8.RuneCountInString(local) >= minPersonalToken && strings.Contains(lp, local) {
+			add(RuleContainsEmail, "password must not contain your email")
+		}
+		for _, part := range strings.Fields(strings.ToLower(info.DisplayName)) {
+			if utf8.RuneCountInString(part) >= minPersonalToken && strings.Contains(lp, part) {
+				add(RuleContainsName, "password must not contain your name")
+				break
+			}
+		}
 	}
 
-	if !regexp.MustCompile(`[!@#$%^&*(),.?":{}|<>]`).MatchString(password) {
-		return errors.New("Password must contain at least one special character")
+	if p.breached != nil && p.breached.Contains(password) {
+		add(RuleBreached, "password appears in a list of breached passwords")
 	}
 
+	if len(v) > 0 {
+		return &PolicyError{Violations: v}
+	}
 	return nil
-}
\ No newline at end of file
+}
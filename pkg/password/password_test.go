@@ -0,0 +1,72 @@
+package password
+
+import (
+	"reflect"
+	"testing"
+)
+
+func violations(t *testing.T, err error) []Rule {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	pe, ok := AsPolicyError(err)
+	if !ok {
+		t.Fatalf("expected *PolicyError, got %T: %v", err, err)
+	}
+	rules := make([]Rule, 0, len(pe.Violations))
+	for _, v := range pe.Violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPolicyValidate(t *testing.T) {
+	info := UserInfo{Email: "Alisher.Navoiy@example.com", DisplayName: "Alisher Navoiy"}
+	cases := []struct {
+		name     string
+		cfg      Config
+		password string
+		info     UserInfo
+		want     []Rule
+	}{
+		{name: "strong", cfg: DefaultConfig, password: "Tr1cky#Horse", info: info},
+		{name: "too short", cfg: DefaultConfig, password: "Ab1#", want: []Rule{RuleMinLength}},
+		{name: "too long", cfg: Config{MaxLength: 10}, password: "abcdefghijk", want: []Rule{RuleMaxLength}},
+		{name: "length counts runes", cfg: Config{MinLength: 4, MaxLength: 4}, password: "üöäß"},
+		{name: "no uppercase", cfg: DefaultConfig, password: "tr1cky#horse", want: []Rule{RuleUppercase}},
+		{name: "no lowercase", cfg: DefaultConfig, password: "TR1CKY#HORSE", want: []Rule{RuleLowercase}},
+		{name: "no digit", cfg: DefaultConfig, password: "Tricky#Horse", want: []Rule{RuleDigit}},
+		{name: "no special", cfg: DefaultConfig, password: "Tr1ckyHorse", want: []Rule{RuleSpecial}},
+		{name: "all violations reported", cfg: DefaultConfig, password: "abc",
+			want: []Rule{RuleMinLength, RuleUppercase, RuleDigit, RuleSpecial}},
+		{name: "contains email local part", cfg: DefaultConfig, password: "X1#alisher.navoiy", info: info,
+			want: []Rule{RuleContainsEmail, RuleContainsName}},
+		{name: "contains name", cfg: DefaultConfig, password: "Navoiy#2024", info: info, want: []Rule{RuleContainsName}},
+		{name: "short name parts ignored", cfg: DefaultConfig, password: "Jo#Secret99", info: UserInfo{Email: "jo@example.com", DisplayName: "Jo Li"}},
+		{name: "personal check disabled", cfg: Config{}, password: "navoiy", info: info},
+		{name: "empty config allows anything", cfg: Config{}, password: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := NewPolicy(c.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy: %v", err)
+			}
+			got := violations(t, p.Validate(c.password, c.info))
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPolicyErrorMessage(t *testing.T) {
+	p, _ := NewPolicy(Config{MinLength: 8, RequireDigit: true})
+	err := p.Validate("abc", UserInfo{})
+	want := "password policy: password is too short; password must contain a digit"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,193 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgoArgon2id = "argon2id"
+	AlgoBcrypt   = "bcrypt"
+)
+
+var (
+	ErrPasswordMismatch   = errors.New("password does not match")
+	ErrUnknownHashFormat  = errors.New("unknown password hash format")
+	ErrInvalidHashOptions = errors.New("invalid password hash parameters")
+)
+
+// PasswordParams - yangi hashlar uchun algoritm va parametrlar.
+// Argon2Memory KiB da; eski (boshqa algoritm yoki parametrli) hashlar NeedsRehash orqali yangilanadi.
+type PasswordParams struct {
+	Algorithm string
+
+	BcryptCost int
+
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+	Argon2KeyLen  uint32
+	Argon2SaltLen uint32
+}
+
+// DefaultPasswordParams - OWASP tavsiyasiga yaqin argon2id (64 MiB, 3 iteratsiya).
+var DefaultPasswordParams = PasswordParams{
+	Algorithm:     AlgoArgon2id,
+	BcryptCost:    bcrypt.DefaultCost,
+	Argon2Time:    3,
+	Argon2Memory:  64 * 1024,
+	Argon2Threads: 2,
+	Argon2KeyLen:  32,
+	Argon2SaltLen: 16,
+}
+
+var passwordParams atomic.Pointer[PasswordParams]
+
+// SetPasswordParams - ishga tushishda (cmd/main.go) config'dan chaqiriladi.
+func SetPasswordParams(p PasswordParams) error {
+	switch p.Algorithm {
+	case AlgoArgon2id:
+		if p.Argon2Time == 0 || p.Argon2Memory < 8*uint32(p.Argon2Threads) || p.Argon2Threads == 0 ||
+			p.Argon2KeyLen < 16 || p.Argon2SaltLen < 8 {
+			return ErrInvalidHashOptions
+		}
+	case AlgoBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return ErrInvalidHashOptions
+		}
+	default:
+		return fmt.Errorf("%w: algorithm %q", ErrInvalidHashOptions, p.Algorithm)
+	}
+	passwordParams.Store(&p)
+	return nil
+}
+
+func currentParams() PasswordParams {
+	if p := passwordParams.Load(); p != nil {
+		return *p
+	}
+	return DefaultPasswordParams
+}
+
+// HashPassword - joriy parametrlar bilan hash.
+// argon2id: PHC formati "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>"; bcrypt: "$2a$<cost>$...".
+func HashPassword(password string) (string, error) {
+	p := currentParams()
+	if p.Algorithm == AlgoBcrypt {
+		h, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(h), nil
+	}
+
+	salt := make([]byte, p.Argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, p.Argon2KeyLen)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgoArgon2id, argon2.Version, p.Argon2Memory, p.Argon2Time, p.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CompareHashAndPassword - bcrypt va argon2id hashlarni qabul qiladi; mos kelmasa ErrPasswordMismatch.
+func CompareHashAndPassword(hashedPassword, password string) error {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$"+AlgoArgon2id+"$"):
+		h, err := parseArgon2id(hashedPassword)
+		if err != nil {
+			return err
+		}
+		key := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		if subtle.ConstantTimeCompare(key, h.key) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+
+	case isBcrypt(hashedPassword):
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+	return ErrUnknownHashFormat
+}
+
+// NeedsRehash - hash joriy algoritm yoki parametrlardan farq qilsa true (login paytida yangilanadi).
+func NeedsRehash(hashedPassword string) bool {
+	p := currentParams()
+	switch {
+	case strings.HasPrefix(hashedPassword, "$"+AlgoArgon2id+"$"):
+		if p.Algorithm != AlgoArgon2id {
+			return true
+		}
+		h, err := parseArgon2id(hashedPassword)
+		if err != nil {
+			return true
+		}
+		return h.version != argon2.Version || h.time != p.Argon2Time || h.memory != p.Argon2Memory ||
+			h.threads != p.Argon2Threads || uint32(len(h.key)) != p.Argon2KeyLen || uint32(len(h.salt)) != p.Argon2SaltLen
+
+	case isBcrypt(hashedPassword):
+		if p.Algorithm != AlgoBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != p.BcryptCost
+	}
+	return true
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+type argon2idHash struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2id(encoded string) (*argon2idHash, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, ErrUnknownHashFormat
+	}
+	var h argon2idHash
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &h.version); err != nil {
+		return nil, ErrUnknownHashFormat
+	}
+	if h.version != argon2.Version {
+		return nil, ErrUnknownHashFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return nil, ErrUnknownHashFormat
+	}
+	// argon2.IDKey bunday parametrlarda panic qiladi
+	if h.time == 0 || h.threads == 0 || h.memory < 8*uint32(h.threads) {
+		return nil, ErrUnknownHashFormat
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(h.salt) == 0 {
+		return nil, ErrUnknownHashFormat
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, ErrUnknownHashFormat
+	}
+	return &h, nil
+}
@@ -0,0 +1,208 @@
+package security
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params - testlar tez ishlashi uchun kichik parametrlar.
+var testArgon2Params = PasswordParams{
+	Algorithm:     AlgoArgon2id,
+	BcryptCost:    bcrypt.MinCost,
+	Argon2Time:    1,
+	Argon2Memory:  1024,
+	Argon2Threads: 1,
+	Argon2KeyLen:  32,
+	Argon2SaltLen: 16,
+}
+
+func useParams(t *testing.T, p PasswordParams) {
+	t.Helper()
+	prev := passwordParams.Load()
+	if err := SetPasswordParams(p); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { passwordParams.Store(prev) })
+}
+
+func mustHash(t *testing.T, password string) string {
+	t.Helper()
+	h, err := HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// encodeArgon2id - HashPassword'dan mustaqil ravishda PHC satrini yig‘adi.
+func encodeArgon2id(password, salt string, time, memory uint32, threads uint8) string {
+	key := argon2.IDKey([]byte(password), []byte(salt), time, memory, threads, 32)
+	return fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$%s$%s", memory, time, threads,
+		base64.RawStdEncoding.EncodeToString([]byte(salt)), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestCompareHashAndPassword(t *testing.T) {
+	useParams(t, testArgon2Params)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("s3cret-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		hash string
+	}{
+		{"argon2id from HashPassword", mustHash(t, "s3cret-pass")},
+		{"argon2id encoded elsewhere", encodeArgon2id("s3cret-pass", "somesaltsomesalt", 2, 2048, 2)},
+		{"bcrypt $2a$", string(bcryptHash)},
+		{"bcrypt $2b$", "$2b$" + strings.TrimPrefix(string(bcryptHash), "$2a$")},
+		{"bcrypt $2y$", "$2y$" + strings.TrimPrefix(string(bcryptHash), "$2a$")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := CompareHashAndPassword(c.hash, "s3cret-pass"); err != nil {
+				t.Fatalf("correct password: %v", err)
+			}
+			if err := CompareHashAndPassword(c.hash, "s3cret-pasS"); !errors.Is(err, ErrPasswordMismatch) {
+				t.Fatalf("wrong password: got %v, want ErrPasswordMismatch", err)
+			}
+		})
+	}
+}
+
+func TestHashPasswordFormat(t *testing.T) {
+	useParams(t, testArgon2Params)
+	a, b := mustHash(t, "pw"), mustHash(t, "pw")
+	if a == b {
+		t.Fatal("hashes of the same password must use different salts")
+	}
+	if !strings.HasPrefix(a, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected argon2id hash %s", a)
+	}
+
+	useParams(t, PasswordParams{Algorithm: AlgoBcrypt, BcryptCost: bcrypt.MinCost})
+	h := mustHash(t, "pw")
+	if cost, err := bcrypt.Cost([]byte(h)); err != nil || cost != bcrypt.MinCost {
+		t.Fatalf("bcrypt hash %s: cost %d err %v", h, cost, err)
+	}
+	if err := CompareHashAndPassword(h, "pw"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareMalformedHash(t *testing.T) {
+	valid := encodeArgon2id("pw", "somesaltsomesalt", 1, 1024, 1)
+	parts := strings.Split(valid, "$")
+
+	cases := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"plain text", "pw"},
+		{"unknown algorithm", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"},
+		{"prefix only", "$argon2id$"},
+		{"truncated before key", strings.Join(parts[:5], "$")},
+		{"extra segment", valid + "$x"},
+		{"bad version", strings.Replace(valid, "v=19", "v=16", 1)},
+		{"missing version", strings.Replace(valid, "v=19", "19", 1)},
+		{"bad params", strings.Replace(valid, "m=1024,t=1,p=1", "m=x,t=1,p=1", 1)},
+		{"zero time", strings.Replace(valid, "t=1", "t=0", 1)},
+		{"zero threads", strings.Replace(valid, "p=1", "p=0", 1)},
+		{"memory below threads", strings.Replace(valid, "m=1024,t=1,p=1", "m=1,t=1,p=1", 1)},
+		{"bad salt encoding", strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "!!", parts[5]}, "$")},
+		{"empty salt", strings.Join([]string{parts[0], parts[1], parts[2], parts[3], "", parts[5]}, "$")},
+		{"empty key", strings.Join([]string{parts[0], parts[1], parts[2], parts[3], parts[4], ""}, "$")},
+		{"truncated bcrypt", "$2a$04$abc"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CompareHashAndPassword(c.hash, "pw")
+			if err == nil || errors.Is(err, ErrPasswordMismatch) {
+				t.Fatalf("got %v, want a format error", err)
+			}
+			if !NeedsRehash(c.hash) {
+				t.Fatal("malformed hash must need a rehash")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useParams(t, testArgon2Params)
+	current := mustHash(t, "pw")
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := func(mod func(*PasswordParams)) PasswordParams {
+		p := testArgon2Params
+		mod(&p)
+		return p
+	}
+	cases := []struct {
+		name   string
+		params PasswordParams
+		hash   string
+		want   bool
+	}{
+		{"same argon2id params", testArgon2Params, current, false},
+		{"memory raised", changed(func(p *PasswordParams) { p.Argon2Memory = 2048 }), current, true},
+		{"time raised", changed(func(p *PasswordParams) { p.Argon2Time = 2 }), current, true},
+		{"threads changed", changed(func(p *PasswordParams) { p.Argon2Threads = 2 }), current, true},
+		{"key length changed", changed(func(p *PasswordParams) { p.Argon2KeyLen = 64 }), current, true},
+		{"salt length changed", changed(func(p *PasswordParams) { p.Argon2SaltLen = 32 }), current, true},
+		{"bcrypt under argon2id", testArgon2Params, string(bcryptHash), true},
+		{"argon2id under bcrypt", PasswordParams{Algorithm: AlgoBcrypt, BcryptCost: bcrypt.MinCost}, current, true},
+		{"same bcrypt cost", PasswordParams{Algorithm: AlgoBcrypt, BcryptCost: bcrypt.MinCost}, string(bcryptHash), false},
+		{"bcrypt cost raised", PasswordParams{Algorithm: AlgoBcrypt, BcryptCost: bcrypt.MinCost + 1}, string(bcryptHash), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			useParams(t, c.params)
+			if got := NeedsRehash(c.hash); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSetPasswordParams(t *testing.T) {
+	prev := passwordParams.Load()
+	t.Cleanup(func() { passwordParams.Store(prev) })
+
+	cases := []struct {
+		name string
+		mod  func(*PasswordParams)
+		ok   bool
+	}{
+		{"valid argon2id", func(*PasswordParams) {}, true},
+		{"valid bcrypt", func(p *PasswordParams) { p.Algorithm = AlgoBcrypt }, true},
+		{"unknown algorithm", func(p *PasswordParams) { p.Algorithm = "scrypt" }, false},
+		{"zero time", func(p *PasswordParams) { p.Argon2Time = 0 }, false},
+		{"zero threads", func(p *PasswordParams) { p.Argon2Threads = 0 }, false},
+		{"memory too low", func(p *PasswordParams) { p.Argon2Memory = 4 }, false},
+		{"short key", func(p *PasswordParams) { p.Argon2KeyLen = 8 }, false},
+		{"short salt", func(p *PasswordParams) { p.Argon2SaltLen = 4 }, false},
+		{"bcrypt cost too low", func(p *PasswordParams) { p.Algorithm, p.BcryptCost = AlgoBcrypt, 1 }, false},
+		{"bcrypt cost too high", func(p *PasswordParams) { p.Algorithm, p.BcryptCost = AlgoBcrypt, 40 }, false},
+	}
+	for _, c := range cases {
+		p := testArgon2Params
+		c.mod(&p)
+		err := SetPasswordParams(p)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: got %v, want ok=%v", c.name, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidHashOptions) {
+			t.Errorf("%s: got %v, want ErrInvalidHashOptions", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 B ilovasi: SHA1 kaliti "12345678901234567890" (ASCII) base32'da
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC 8 xonali kodlarni beradi; 6 xonali kod ularning oxirgi 6 raqami
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, c := range cases {
+		step := Step(time.Unix(c.unix, 0))
+		got, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("T=%d: %v", c.unix, err)
+		}
+		if want := c.want[len(c.want)-Digits:]; got != want {
+			t.Errorf("T=%d: got %s, want %s", c.unix, got, want)
+		}
+		if s, ok := Validate(rfcSecret, got, time.Unix(c.unix, 0)); !ok || s != step {
+			t.Errorf("T=%d: Validate = (%d, %v), want (%d, true)", c.unix, s, ok, step)
+		}
+	}
+}
+
+func TestValidateSkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	cur := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	cases := []struct {
+		name     string
+		step     int64
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", cur, true, cur},
+		{"previous step", cur - 1, true, cur - 1},
+		{"next step", cur + 1, true, cur + 1},
+		{"two steps behind", cur - 2, false, 0},
+		{"two steps ahead", cur + 2, false, 0},
+	}
+	for _, c := range cases {
+		step, ok := Validate(rfcSecret, code(c.step), now)
+		if ok != c.wantOK || step != c.wantStep {
+			t.Errorf("%s: got (%d, %v), want (%d, %v)", c.name, step, ok, c.wantStep, c.wantOK)
+		}
+	}
+}
+
+// Validate mos qadamni qaytaradi: chaqiruvchi (MFA servisi) shu qadamni bir marta qabul qiladi.
+func TestValidateStepForReplayCheck(t *testing.T) {
+	start := time.Unix(1234567890, 0).Truncate(Period * time.Second)
+	code, err := Code(rfcSecret, Step(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := Validate(rfcSecret, code, start)
+	if !ok {
+		t.Fatal("code must be valid in its own step")
+	}
+	// shu kod keyingi qadamda ham (skew) qabul qilinadi, lekin o‘sha qadam raqami bilan — takroriy ishlatish aniqlanadi
+	again, ok := Validate(rfcSecret, code, start.Add(Period*time.Second))
+	if !ok || again != first {
+		t.Fatalf("reused code: got (%d, %v), want (%d, true)", again, ok, first)
+	}
+	// yangi qadam kodi boshqa qadam raqamini beradi
+	next, _ := Code(rfcSecret, Step(start)+1)
+	if s, ok := Validate(rfcSecret, next, start.Add(Period*time.Second)); !ok || s == first {
+		t.Fatalf("next step code: got (%d, %v), want a step other than %d", s, ok, first)
+	}
+}
+
+func TestValidateRejectsMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	good, _ := Code(rfcSecret, Step(now))
+
+	cases := []struct {
+		name   string
+		secret string
+		code   string
+		wantOK bool
+	}{
+		{"surrounding spaces", rfcSecret, " " + good + " ", true},
+		{"lowercase secret", strings.ToLower(rfcSecret), good, true},
+		{"empty", rfcSecret, "", false},
+		{"too short", rfcSecret, good[:5], false},
+		{"too long", rfcSecret, good + "0", false},
+		{"eight digit code", rfcSecret, "94287082", false},
+		{"wrong code", rfcSecret, "000000", false},
+		{"bad secret", "not base32!", good, false},
+	}
+	for _, c := range cases {
+		if _, ok := Validate(c.secret, c.code, now); ok != c.wantOK {
+			t.Errorf("%s: got %v, want %v", c.name, ok, c.wantOK)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Fatal("secrets must be random")
+	}
+	raw, err := b32.DecodeString(a)
+	if err != nil || len(raw) != 20 {
+		t.Fatalf("secret %q: %d bytes, err %v; want 20 bytes", a, len(raw), err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Speak Pall", "user@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Speak Pall:user@example.com" {
+		t.Fatalf("unexpected uri %s", u)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "Speak Pall" || q.Get("algorithm") != "SHA1" ||
+		q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Fatalf("unexpected query %v", q)
+	}
+}
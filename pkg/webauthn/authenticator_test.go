@@ -0,0 +1,396 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// Test uchun minimal CBOR encoder (faqat aniq uzunlikdagi elementlar).
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		b := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		return b
+	default:
+		b := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		return b
+	}
+}
+
+func cborInt(v int64) []byte {
+	if v < 0 {
+		return cborHead(1, uint64(-1-v))
+	}
+	return cborHead(0, uint64(v))
+}
+
+func cborBytes(b []byte) []byte { return append(cborHead(2, uint64(len(b))), b...) }
+func cborText(s string) []byte  { return append(cborHead(3, uint64(len(s))), s...) }
+
+// cborMap - kalit/qiymat juftlari allaqachon kodlangan holda, berilgan tartibda.
+func cborMap(kv ...[]byte) []byte {
+	out := cborHead(5, uint64(len(kv)/2))
+	for _, p := range kv {
+		out = append(out, p...)
+	}
+	return out
+}
+
+const (
+	testRPID   = "speakpall.test"
+	testOrigin = "https://speakpall.test"
+)
+
+func testConfig() Config {
+	return Config{RPID: testRPID, RPName: "SpeakPall", Origins: []string{testOrigin + "/"}}
+}
+
+// softAuthenticator - dasturiy authenticator: haqiqiy kalit bilan attestation/assertion yasaydi.
+type softAuthenticator struct {
+	t      *testing.T
+	alg    int64
+	ec     *ecdsa.PrivateKey
+	ed     ed25519.PrivateKey
+	credID []byte
+	count  uint32
+
+	// ceremony parametrlari (noto‘g‘ri qiymatlar testlari uchun o‘zgartiriladi)
+	rpID        string
+	origin      string
+	crossOrigin bool
+	flags       byte
+}
+
+func newSoftAuthenticator(t *testing.T, alg int64) *softAuthenticator {
+	t.Helper()
+	a := &softAuthenticator{t: t, alg: alg, credID: make([]byte, 16), rpID: testRPID, origin: testOrigin, flags: flagUP | flagUV}
+	if _, err := rand.Read(a.credID); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	switch alg {
+	case AlgES256:
+		a.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, a.ed, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unsupported test alg %d", alg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	if a.alg == AlgES256 {
+		x, y := make([]byte, 32), make([]byte, 32)
+		a.ec.X.FillBytes(x)
+		a.ec.Y.FillBytes(y)
+		return cborMap(
+			cborInt(coseKty), cborInt(coseKtyEC2),
+			cborInt(coseAlg), cborInt(AlgES256),
+			cborInt(-1), cborInt(coseCrvP256),
+			cborInt(-2), cborBytes(x),
+			cborInt(-3), cborBytes(y),
+		)
+	}
+	return cborMap(
+		cborInt(coseKty), cborInt(coseKtyOKP),
+		cborInt(coseAlg), cborInt(AlgEdDSA),
+		cborInt(-1), cborInt(coseCrvEd25519),
+		cborInt(-2), cborBytes(a.ed.Public().(ed25519.PublicKey)),
+	)
+}
+
+func (a *softAuthenticator) clientData(typ string, challenge []byte) []byte {
+	raw, err := json.Marshal(clientData{
+		Type:        typ,
+		Challenge:   base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:      a.origin,
+		CrossOrigin: a.crossOrigin,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return raw
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rp := sha256.Sum256([]byte(a.rpID))
+	out := append([]byte{}, rp[:]...)
+	out = append(out, flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(out[33:37], a.count)
+	return append(out, attested...)
+}
+
+func (a *softAuthenticator) sign(data []byte) []byte {
+	if a.alg == AlgES256 {
+		sum := sha256.Sum256(data)
+		sig, err := ecdsa.SignASN1(rand.Reader, a.ec, sum[:])
+		if err != nil {
+			a.t.Fatal(err)
+		}
+		return sig
+	}
+	return ed25519.Sign(a.ed, data)
+}
+
+// register - navigator.credentials.create() natijasi (JSON orqali, servisdagi kabi).
+func (a *softAuthenticator) register(challenge []byte) AttestationResponse {
+	attested := make([]byte, 16) // aaguid
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credID)))
+	attested = append(attested, a.credID...)
+	attested = append(attested, a.coseKey()...)
+
+	var resp AttestationResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.credID)
+	resp.RawID = a.credID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = a.clientData(typeCreate, challenge)
+	resp.Response.AttestationObject = cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(a.authData(a.flags|flagAT, attested)),
+	)
+	return roundTrip(a.t, resp)
+}
+
+// assert - navigator.credentials.get() natijasi; har chaqiriqda hisoblagich oshadi.
+func (a *softAuthenticator) assert(challenge []byte) AssertionResponse {
+	a.count++
+	cd := a.clientData(typeGet, challenge)
+	ad := a.authData(a.flags, nil)
+	cdHash := sha256.Sum256(cd)
+
+	var resp AssertionResponse
+	resp.ID = base64.RawURLEncoding.EncodeToString(a.credID)
+	resp.RawID = a.credID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = cd
+	resp.Response.AuthenticatorData = ad
+	resp.Response.Signature = a.sign(append(append([]byte{}, ad...), cdHash[:]...))
+	return roundTrip(a.t, resp)
+}
+
+func roundTrip[T any](t *testing.T, v T) T {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out T
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func mustChallenge(t *testing.T) []byte {
+	t.Helper()
+	ch, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+var testAlgs = []struct {
+	name string
+	alg  int64
+}{
+	{"ES256", AlgES256},
+	{"EdDSA", AlgEdDSA},
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	cfg := testConfig()
+	for _, tc := range testAlgs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newSoftAuthenticator(t, tc.alg)
+
+			regChallenge := mustChallenge(t)
+			cred, err := cfg.VerifyRegistration(regChallenge, a.register(regChallenge))
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+			if string(cred.ID) != string(a.credID) || cred.SignCount != 0 || len(cred.AAGUID) != 16 {
+				t.Fatalf("unexpected credential %+v", cred)
+			}
+			if _, alg, err := ParsePublicKey(cred.PublicKey); err != nil || alg != tc.alg {
+				t.Fatalf("stored public key: alg=%d err=%v", alg, err)
+			}
+
+			stored := cred.SignCount
+			for i := 1; i <= 2; i++ {
+				ch := mustChallenge(t)
+				resp := a.assert(ch)
+				// servis saqlangan sessiyani shu challenge bo‘yicha topadi
+				got, err := ClientDataChallenge(resp.Response.ClientDataJSON)
+				if err != nil || string(got) != string(ch) {
+					t.Fatalf("ClientDataChallenge: %v", err)
+				}
+				res, err := cfg.VerifyAssertion(ch, resp, cred.PublicKey, stored)
+				if err != nil {
+					t.Fatalf("assertion %d: %v", i, err)
+				}
+				if res.SignCount != uint32(i) || !res.UserVerified {
+					t.Fatalf("assertion %d: got %+v", i, res)
+				}
+				stored = res.SignCount
+			}
+		})
+	}
+}
+
+func TestAssertionBadSignature(t *testing.T) {
+	cfg := testConfig()
+	for _, tc := range testAlgs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newSoftAuthenticator(t, tc.alg)
+			ch := mustChallenge(t)
+			cred, err := cfg.VerifyRegistration(ch, a.register(ch))
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+
+			cases := []struct {
+				name   string
+				mutate func(*AssertionResponse)
+			}{
+				{"flipped signature byte", func(r *AssertionResponse) { r.Response.Signature[len(r.Response.Signature)-1] ^= 0xff }},
+				{"tampered authenticator data", func(r *AssertionResponse) { r.Response.AuthenticatorData[36] ^= 0x01 }},
+				{"signed by another key", func(r *AssertionResponse) {
+					other := newSoftAuthenticator(t, tc.alg)
+					other.credID = a.credID
+					*r = other.assert(ch)
+				}},
+			}
+			for _, c := range cases {
+				resp := a.assert(ch)
+				c.mutate(&resp)
+				if _, err := cfg.VerifyAssertion(ch, resp, cred.PublicKey, 0); err != ErrBadSignature {
+					t.Errorf("%s: got %v, want %v", c.name, err, ErrBadSignature)
+				}
+			}
+		})
+	}
+}
+
+func TestCeremonyMismatch(t *testing.T) {
+	cfg := testConfig()
+	cases := []struct {
+		name    string
+		setup   func(*softAuthenticator)
+		wantErr error
+	}{
+		{"other origin", func(a *softAuthenticator) { a.origin = "https://evil.test" }, ErrOriginMismatch},
+		{"http origin", func(a *softAuthenticator) { a.origin = "http://speakpall.test" }, ErrOriginMismatch},
+		{"cross origin iframe", func(a *softAuthenticator) { a.crossOrigin = true }, ErrOriginMismatch},
+		{"other rp id", func(a *softAuthenticator) { a.rpID = "evil.test" }, ErrRPIDMismatch},
+		{"parent domain rp id", func(a *softAuthenticator) { a.rpID = "test" }, ErrRPIDMismatch},
+		{"user not present", func(a *softAuthenticator) { a.flags = 0 }, ErrUserNotPresent},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			good := newSoftAuthenticator(t, AlgES256)
+			ch := mustChallenge(t)
+			cred, err := cfg.VerifyRegistration(ch, good.register(ch))
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+
+			bad := newSoftAuthenticator(t, AlgES256)
+			c.setup(bad)
+			if _, err := cfg.VerifyRegistration(ch, bad.register(ch)); err != c.wantErr {
+				t.Errorf("registration: got %v, want %v", err, c.wantErr)
+			}
+
+			// xuddi shu kalit, lekin noto‘g‘ri ceremony parametrlari bilan
+			c.setup(good)
+			if _, err := cfg.VerifyAssertion(ch, good.assert(ch), cred.PublicKey, 0); err != c.wantErr {
+				t.Errorf("assertion: got %v, want %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestChallengeAndTypeMismatch(t *testing.T) {
+	cfg := testConfig()
+	a := newSoftAuthenticator(t, AlgEdDSA)
+	ch := mustChallenge(t)
+	cred, err := cfg.VerifyRegistration(ch, a.register(ch))
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+
+	if _, err := cfg.VerifyRegistration(mustChallenge(t), a.register(ch)); err != ErrChallengeMismatch {
+		t.Errorf("registration with other challenge: got %v", err)
+	}
+	if _, err := cfg.VerifyAssertion(mustChallenge(t), a.assert(ch), cred.PublicKey, 0); err != ErrChallengeMismatch {
+		t.Errorf("assertion with other challenge: got %v", err)
+	}
+
+	// create javobi login sifatida qabul qilinmaydi
+	reg := a.register(ch)
+	var resp AssertionResponse
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = reg.Response.ClientDataJSON
+	resp.Response.AuthenticatorData = a.authData(flagUP, nil)
+	if _, err := cfg.VerifyAssertion(ch, resp, cred.PublicKey, 0); err != ErrInvalidResponse {
+		t.Errorf("create client data on login: got %v", err)
+	}
+}
+
+func TestSignCountRegression(t *testing.T) {
+	cfg := testConfig()
+	cases := []struct {
+		name    string
+		stored  uint32
+		next    uint32 // authenticator yuboradigan hisoblagich
+		wantErr error
+	}{
+		{"increasing", 5, 6, nil},
+		{"jump forward", 5, 100, nil},
+		{"same value", 5, 5, ErrSignCount},
+		{"went backwards", 5, 3, ErrSignCount},
+		{"reset to zero", 5, 0, ErrSignCount},
+		{"counterless authenticator", 0, 0, nil},
+		{"first counted use", 0, 1, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a := newSoftAuthenticator(t, AlgES256)
+			ch := mustChallenge(t)
+			cred, err := cfg.VerifyRegistration(ch, a.register(ch))
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+
+			a.count = c.next - 1 // assert() oshiradi
+			res, err := cfg.VerifyAssertion(ch, a.assert(ch), cred.PublicKey, c.stored)
+			if err != c.wantErr {
+				t.Fatalf("got %v, want %v", err, c.wantErr)
+			}
+			if err == nil && res.SignCount != c.next {
+				t.Fatalf("sign count %d, want %d", res.SignCount, c.next)
+			}
+		})
+	}
+}
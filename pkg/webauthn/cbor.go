@@ -0,0 +1,147 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Minimal CBOR (RFC 8949) dekoderi — faqat attestationObject va COSE kalitlari uchun yetarli qism:
+// butun sonlar, bayt/matn qatorlari, massiv, map, teglar va oddiy qiymatlar. Noaniq uzunlik qo‘llanmaydi.
+
+var errCBOR = errors.New("webauthn: malformed cbor")
+
+const cborMaxDepth = 16
+
+// decodeCBOR birinchi elementni o‘qiydi va qolgan baytlarni qaytaradi.
+// Butun sonlar int64, map kalitlari int64 yoki string bo‘ladi.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+func decodeItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errCBOR
+	}
+	if len(data) == 0 {
+		return nil, nil, errCBOR
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	if major == 7 {
+		return decodeSimple(info, data)
+	}
+
+	n, data, err := readArg(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return int64(n), data, nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return -1 - int64(n), data, nil
+	case 2, 3:
+		if uint64(len(data)) < n {
+			return nil, nil, errCBOR
+		}
+		b := make([]byte, n)
+		copy(b, data[:n])
+		if major == 3 {
+			return string(b), data[n:], nil
+		}
+		return b, data[n:], nil
+	case 4:
+		if n > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		arr := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var v interface{}
+			if v, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, data, nil
+	case 5:
+		if n > uint64(len(data)) {
+			return nil, nil, errCBOR
+		}
+		m := make(map[interface{}]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var k, v interface{}
+			if k, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported map key %T", errCBOR, k)
+			}
+			if v, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[k] = v
+		}
+		return m, data, nil
+	case 6:
+		// teg ma'nosi kerak emas — ichidagi qiymat qaytariladi
+		return decodeItem(data, depth+1)
+	}
+	return nil, nil, errCBOR
+}
+
+// readArg - qo‘shimcha ma'lumotdan uzunlik/qiymatni o‘qiydi (31 — noaniq uzunlik, qo‘llanmaydi).
+func readArg(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errCBOR
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errCBOR
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errCBOR
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errCBOR
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	return 0, nil, errCBOR
+}
+
+func decodeSimple(info byte, data []byte) (interface{}, []byte, error) {
+	switch info {
+	case 20:
+		return false, data, nil
+	case 21:
+		return true, data, nil
+	case 22, 23:
+		return nil, data, nil
+	case 25, 26, 27:
+		// float qiymatlar WebAuthn'da ishlatilmaydi — o‘tkazib yuboriladi
+		_, rest, err := readArg(info, data)
+		return nil, rest, err
+	}
+	return nil, nil, errCBOR
+}
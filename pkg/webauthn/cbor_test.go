@@ -0,0 +1,106 @@
+package webauthn
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []byte
+		want interface{}
+	}{
+		{"small uint", []byte{0x17}, int64(23)},
+		{"uint8", []byte{0x18, 0xff}, int64(255)},
+		{"uint16", []byte{0x19, 0x01, 0x00}, int64(256)},
+		{"uint32", []byte{0x1a, 0x00, 0x01, 0x00, 0x00}, int64(65536)},
+		{"negative", []byte{0x26}, int64(-7)},
+		{"negative uint16", []byte{0x39, 0x01, 0x00}, int64(-257)},
+		{"bytes", []byte{0x43, 1, 2, 3}, []byte{1, 2, 3}},
+		{"text", []byte{0x64, 'n', 'o', 'n', 'e'}, "none"},
+		{"array", []byte{0x82, 0x01, 0x20}, []interface{}{int64(1), int64(-1)}},
+		{"map", cborMap(cborInt(1), cborInt(2), cborText("a"), cborBytes([]byte{9})),
+			map[interface{}]interface{}{int64(1): int64(2), "a": []byte{9}}},
+		{"empty map", []byte{0xa0}, map[interface{}]interface{}{}},
+		{"tag is unwrapped", []byte{0xc1, 0x05}, int64(5)},
+		{"true", []byte{0xf5}, true},
+		{"false", []byte{0xf4}, false},
+		{"null", []byte{0xf6}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(c.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rest) != 0 {
+				t.Fatalf("%d trailing bytes", len(rest))
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORReturnsRest(t *testing.T) {
+	_, rest, err := decodeCBOR([]byte{0x01, 0xaa, 0xbb})
+	if err != nil || !reflect.DeepEqual(rest, []byte{0xaa, 0xbb}) {
+		t.Fatalf("rest=%x err=%v", rest, err)
+	}
+}
+
+func TestDecodeCBORMalformed(t *testing.T) {
+	deep := make([]byte, cborMaxDepth+2)
+	for i := range deep {
+		deep[i] = 0x81 // [[[...
+	}
+	cases := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"truncated uint16", []byte{0x19, 0x01}},
+		{"truncated bytes", []byte{0x45, 1, 2}},
+		{"indefinite length", []byte{0x5f, 0x41, 0x00, 0xff}},
+		{"array longer than input", []byte{0x83, 0x01}},
+		{"map with array key", []byte{0xa1, 0x80, 0x01}},
+		{"reserved simple", []byte{0xfc}},
+		{"too deep", deep},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, _, err := decodeCBOR(c.in); !errors.Is(err, errCBOR) {
+				t.Fatalf("got %v, want errCBOR", err)
+			}
+		})
+	}
+}
+
+func TestParsePublicKeyRejects(t *testing.T) {
+	cases := []struct {
+		name string
+		key  []byte
+	}{
+		{"not a map", cborInt(1)},
+		{"unknown alg", cborMap(cborInt(coseKty), cborInt(coseKtyEC2), cborInt(coseAlg), cborInt(-35))},
+		{"EC2 point off curve", cborMap(
+			cborInt(coseKty), cborInt(coseKtyEC2), cborInt(coseAlg), cborInt(AlgES256),
+			cborInt(-1), cborInt(coseCrvP256), cborInt(-2), cborBytes(make([]byte, 32)), cborInt(-3), cborBytes(make([]byte, 32)))},
+		{"Ed25519 short key", cborMap(
+			cborInt(coseKty), cborInt(coseKtyOKP), cborInt(coseAlg), cborInt(AlgEdDSA),
+			cborInt(-1), cborInt(coseCrvEd25519), cborInt(-2), cborBytes(make([]byte, 31)))},
+		{"RSA short modulus", cborMap(
+			cborInt(coseKty), cborInt(coseKtyRSA), cborInt(coseAlg), cborInt(AlgRS256),
+			cborInt(-1), cborBytes(make([]byte, 128)), cborInt(-2), cborBytes([]byte{1, 0, 1}))},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, _, err := ParsePublicKey(c.key); !errors.Is(err, ErrUnsupportedKey) {
+				t.Fatalf("got %v, want ErrUnsupportedKey", err)
+			}
+		})
+	}
+}
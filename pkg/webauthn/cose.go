@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algoritm identifikatorlari (RFC 9053, IANA COSE Algorithms)
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgs - pubKeyCredParams tartibi (afzalroq birinchi).
+var SupportedAlgs = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	coseKty = 1
+	coseAlg = 3
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+var ErrUnsupportedKey = errors.New("webauthn: unsupported public key")
+
+// ParsePublicKey - COSE_Key baytlaridan Go kalitini va algoritmni oladi.
+func ParsePublicKey(coseKey []byte) (crypto.PublicKey, int64, error) {
+	v, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, ErrUnsupportedKey
+	}
+
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, ErrUnsupportedKey
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, ErrUnsupportedKey
+		}
+		return pub, alg, nil
+
+	case kty == coseKtyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), alg, nil
+
+	case kty == coseKtyRSA && alg == AlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, ErrUnsupportedKey
+		}
+		exp := int(new(big.Int).SetBytes(e).Int64())
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, alg, nil
+	}
+	return nil, 0, fmt.Errorf("%w: kty=%d alg=%d", ErrUnsupportedKey, kty, alg)
+}
+
+// verifySignature - alg bo‘yicha imzoni tekshiradi.
+func verifySignature(pub crypto.PublicKey, alg int64, data, sig []byte) bool {
+	switch alg {
+	case AlgES256:
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		sum := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(k, sum[:], sig)
+	case AlgEdDSA:
+		k, ok := pub.(ed25519.PublicKey)
+		return ok && ed25519.Verify(k, data, sig)
+	case AlgRS256:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		sum := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig) == nil
+	}
+	return false
+}
@@ -0,0 +1,401 @@
+// Package webauthn - passkey (WebAuthn Level 2) ro‘yxatdan o‘tkazish va login ceremony'larini tekshirish.
+// Attestation "none": authenticator attestatsiyasi so‘ralmaydi va attStmt tekshirilmaydi —
+// kalitga birinchi ro‘yxatdan o‘tishdagi sessiya (JWT) orqali ishoniladi.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidResponse   = errors.New("webauthn: invalid authenticator response")
+	ErrChallengeMismatch = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch    = errors.New("webauthn: origin not allowed")
+	ErrRPIDMismatch      = errors.New("webauthn: rp id hash mismatch")
+	ErrUserNotPresent    = errors.New("webauthn: user presence flag not set")
+	ErrBadSignature      = errors.New("webauthn: signature verification failed")
+	// ErrSignCount - hisoblagich oshmadi: kalit nusxalangan bo‘lishi mumkin
+	ErrSignCount = errors.New("webauthn: signature counter did not increase")
+)
+
+const (
+	flagUP = 0x01
+	flagUV = 0x04
+	flagBE = 0x08
+	flagAT = 0x40
+	flagED = 0x80
+
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+
+	challengeSize = 32
+)
+
+// Config - relying party sozlamalari.
+type Config struct {
+	RPID    string   // masalan "speakpall.uz" (origin domeni yoki uning ota domeni)
+	RPName  string   // authenticator oynasida ko‘rinadi
+	Origins []string // ruxsat etilgan origin'lar, masalan "https://speakpall.uz"
+	Timeout time.Duration
+}
+
+// URLEncodedBytes - JSON'da base64url (paddingsiz) ko‘rinishida, WebAuthn toJSON() formatiga mos.
+type URLEncodedBytes []byte
+
+func (b URLEncodedBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncodedBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          URLEncodedBytes `json:"id"`
+	Name        string          `json:"name"`
+	DisplayName string          `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string          `json:"type"`
+	ID         URLEncodedBytes `json:"id"`
+	Transports []string        `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions - navigator.credentials.create({publicKey}) uchun.
+type CreationOptions struct {
+	Challenge              URLEncodedBytes        `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions - navigator.credentials.get({publicKey}) uchun.
+// AllowCredentials bo‘sh bo‘lsa discoverable (usernameless) login.
+type RequestOptions struct {
+	Challenge        URLEncodedBytes        `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse - create() natijasi (PublicKeyCredential.toJSON()).
+type AttestationResponse struct {
+	ID       string          `json:"id"`
+	RawID    URLEncodedBytes `json:"rawId"`
+	Type     string          `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+		AttestationObject URLEncodedBytes `json:"attestationObject"`
+		Transports        []string        `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse - get() natijasi (PublicKeyCredential.toJSON()).
+type AssertionResponse struct {
+	ID       string          `json:"id"`
+	RawID    URLEncodedBytes `json:"rawId"`
+	Type     string          `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBytes `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBytes `json:"authenticatorData"`
+		Signature         URLEncodedBytes `json:"signature"`
+		UserHandle        URLEncodedBytes `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential - muvaffaqiyatli ro‘yxatdan o‘tishdan keyin saqlanadigan ma'lumot.
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	BackupEligible bool
+}
+
+type AssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+
+	aaguid    []byte
+	credID    []byte
+	publicKey []byte
+}
+
+func NewChallenge() ([]byte, error) {
+	b := make([]byte, challengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (cfg Config) NewCreationOptions(challenge, userHandle []byte, name, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	params := make([]CredentialParameter, 0, len(SupportedAlgs))
+	for _, alg := range SupportedAlgs {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return CreationOptions{
+		Challenge:          challenge,
+		RP:                 RelyingParty{ID: cfg.RPID, Name: cfg.RPName},
+		User:               UserEntity{ID: userHandle, Name: name, DisplayName: displayName},
+		PubKeyCredParams:   params,
+		Timeout:            cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+func (cfg Config) NewRequestOptions(challenge []byte, allow []CredentialDescriptor) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          cfg.Timeout.Milliseconds(),
+		RPID:             cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: "preferred",
+	}
+}
+
+// ClientDataChallenge - clientDataJSON ichidagi challenge (saqlangan sessiyani topish uchun).
+func ClientDataChallenge(clientDataJSON []byte) ([]byte, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return nil, ErrInvalidResponse
+	}
+	ch, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || len(ch) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	return ch, nil
+}
+
+// VerifyRegistration - WebAuthn §7.1 (attestation "none").
+func (cfg Config) VerifyRegistration(challenge []byte, resp AttestationResponse) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if err := cfg.verifyClientData(resp.Response.ClientDataJSON, typeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	v, _, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	att, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	rawAuthData, ok := att["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.verifyAuthData(ad); err != nil {
+		return nil, err
+	}
+	if ad.flags&flagAT == 0 || len(ad.credID) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	if len(resp.RawID) > 0 && !bytes.Equal(resp.RawID, ad.credID) {
+		return nil, ErrInvalidResponse
+	}
+	if _, _, err := ParsePublicKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             ad.credID,
+		PublicKey:      ad.publicKey,
+		SignCount:      ad.signCount,
+		AAGUID:         ad.aaguid,
+		Transports:     resp.Response.Transports,
+		BackupEligible: ad.flags&flagBE != 0,
+	}, nil
+}
+
+// VerifyAssertion - WebAuthn §7.2. storedCount — bazadagi oxirgi hisoblagich.
+func (cfg Config) VerifyAssertion(challenge []byte, resp AssertionResponse, publicKey []byte, storedCount uint32) (AssertionResult, error) {
+	if resp.Type != "public-key" {
+		return AssertionResult{}, ErrInvalidResponse
+	}
+	if err := cfg.verifyClientData(resp.Response.ClientDataJSON, typeGet, challenge); err != nil {
+		return AssertionResult{}, err
+	}
+
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	if err := cfg.verifyAuthData(ad); err != nil {
+		return AssertionResult{}, err
+	}
+
+	pub, alg, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	cdHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte{}, resp.Response.AuthenticatorData...), cdHash[:]...)
+	if !verifySignature(pub, alg, signed, resp.Response.Signature) {
+		return AssertionResult{}, ErrBadSignature
+	}
+
+	// hisoblagichsiz authenticator'lar (sinxronlanadigan passkey'lar) doim 0 yuboradi
+	if (ad.signCount != 0 || storedCount != 0) && ad.signCount <= storedCount {
+		return AssertionResult{}, ErrSignCount
+	}
+
+	return AssertionResult{SignCount: ad.signCount, UserVerified: ad.flags&flagUV != 0}, nil
+}
+
+func (cfg Config) verifyClientData(raw []byte, typ string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ErrInvalidResponse
+	}
+	if cd.Type != typ {
+		return ErrInvalidResponse
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return ErrChallengeMismatch
+	}
+	if cd.CrossOrigin || !cfg.originAllowed(cd.Origin) {
+		return ErrOriginMismatch
+	}
+	return nil
+}
+
+func (cfg Config) verifyAuthData(ad *authenticatorData) error {
+	want := sha256.Sum256([]byte(cfg.RPID))
+	if subtle.ConstantTimeCompare(ad.rpIDHash, want[:]) != 1 {
+		return ErrRPIDMismatch
+	}
+	if ad.flags&flagUP == 0 {
+		return ErrUserNotPresent
+	}
+	return nil
+}
+
+func (cfg Config) originAllowed(origin string) bool {
+	for _, o := range cfg.Origins {
+		if strings.TrimRight(o, "/") == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAuthenticatorData - rpIdHash(32) | flags(1) | signCount(4) | [attestedCredentialData] | [extensions]
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidResponse
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+
+	if ad.flags&flagAT != 0 {
+		// aaguid(16) | credIdLen(2) | credId | credentialPublicKey (CBOR)
+		if len(rest) < 18 {
+			return nil, ErrInvalidResponse
+		}
+		ad.aaguid = rest[:16]
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if n == 0 || n > 1023 || len(rest) < n {
+			return nil, ErrInvalidResponse
+		}
+		ad.credID = rest[:n]
+		rest = rest[n:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		ad.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+
+	if ad.flags&flagED != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+	return ad, nil
+}
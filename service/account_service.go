@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/security"
+	"speakpall/storage"
+)
+
+var (
+	ErrAccountDeleted        = errors.New("account has been deleted")
+	ErrDeleteConfirmRequired = errors.New("password or confirmation code is required")
+	ErrDeleteConfirmInvalid  = errors.New("password is incorrect")
+	ErrAccountAlreadyDeleted = errors.New("account is already scheduled for deletion")
+)
+
+const accountPurgeBatch = 100
+
+type AccountService interface {
+	// RequestDeletionCode parolsiz (Google/OTP) akkauntlar uchun tasdiqlash kodi yuboradi
+	RequestDeletionCode(ctx context.Context, userID string) error
+	// Delete parol yoki yangi OTP bilan tasdiqlanadi: deleted_at + barcha sessiyalar bekor
+	Delete(ctx context.Context, userID, password, code string) error
+
+	// CanLogin grace muddati o‘tgan o‘chirilgan akkaunt uchun ErrAccountDeleted
+	CanLogin(ctx context.Context, userID string) error
+	// Restore grace ichida qayta login qilinganda akkauntni tiklaydi
+	Restore(ctx context.Context, userID string) (bool, error)
+
+	PurgeExpired(ctx context.Context) (int, error)
+	// RunPurgeJob ctx bekor qilinguncha har interval'da PurgeExpired
+	RunPurgeJob(ctx context.Context)
+}
+
+type accountService struct {
+	stg      storage.IUserStorage
+	otp      OTPService
+	sessions SessionService
+	audit    AuditService
+	log      logger.ILogger
+
+	grace         time.Duration
+	purgeInterval time.Duration
+}
+
+func NewAccountService(stg storage.IStorage, log logger.ILogger, otp OTPService, sessions SessionService, audit AuditService, cfg config.Config) AccountService {
+	return &accountService{
+		stg:           stg.User(),
+		otp:           otp,
+		sessions:      sessions,
+		audit:         audit,
+		log:           log,
+		grace:         cfg.AccountDeletionGrace,
+		purgeInterval: cfg.AccountPurgeInterval,
+	}
+}
+
+func (s *accountService) RequestDeletionCode(ctx context.Context, userID string) error {
+	s.log.Info("AccountService.RequestDeletionCode", logger.String("user_id", userID))
+	u, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.otp.SendCode(ctx, u.Email, models.OTPPurposeDeleteAccount)
+}
+
+func (s *accountService) Delete(ctx context.Context, userID, password, code string) error {
+	s.log.Info("AccountService.Delete", logger.String("user_id", userID))
+	u, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.DeletedAt != nil {
+		return ErrAccountAlreadyDeleted
+	}
+
+	switch {
+	case password != "":
+		if u.PasswordHash == "" || security.CompareHashAndPassword(u.PasswordHash, password) != nil {
+			return ErrDeleteConfirmInvalid
+		}
+	case code != "":
+		if err := s.otp.VerifyCode(ctx, u.Email, models.OTPPurposeDeleteAccount, code); err != nil {
+			return err
+		}
+	default:
+		return ErrDeleteConfirmRequired
+	}
+
+	if err := s.stg.SoftDelete(ctx, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAccountAlreadyDeleted
+		}
+		return err
+	}
+	return s.sessions.RevokeAllForUser(ctx, userID, "")
+}
+
+func (s *accountService) CanLogin(ctx context.Context, userID string) error {
+	u, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.DeletedAt != nil && time.Since(*u.DeletedAt) > s.grace {
+		return ErrAccountDeleted
+	}
+	return nil
+}
+
+func (s *accountService) Restore(ctx context.Context, userID string) (bool, error) {
+	if err := s.CanLogin(ctx, userID); err != nil {
+		return false, err
+	}
+	restored, err := s.stg.Restore(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if restored {
+		s.log.Info("account restored after deletion", logger.String("user_id", userID))
+	}
+	return restored, nil
+}
+
+func (s *accountService) PurgeExpired(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		ids, err := s.stg.ListPurgeable(ctx, time.Now().Add(-s.grace), accountPurgeBatch)
+		if err != nil {
+			return total, err
+		}
+		purged := 0
+		for _, id := range ids {
+			if err := s.stg.Anonymize(ctx, id); err != nil {
+				// bittasi xato bo‘lsa ham qolganlari davom etadi; keyingi ishga tushishda qayta urinadi
+				s.log.Error("account purge failed", logger.Error(err), logger.String("user_id", id))
+				continue
+			}
+			purged++
+			s.audit.Record(ctx, AuditEntry{Type: models.AuditAccountPurged, TargetUserID: id})
+		}
+		total += purged
+		if len(ids) < accountPurgeBatch || purged == 0 || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+func (s *accountService) RunPurgeJob(ctx context.Context) {
+	if s.purgeInterval <= 0 {
+		return
+	}
+	t := time.NewTicker(s.purgeInterval)
+	defer t.Stop()
+
+	for {
+		n, err := s.PurgeExpired(ctx)
+		if err != nil {
+			s.log.Error("account purge job failed", logger.Error(err))
+		} else if n > 0 {
+			s.log.Info("account purge job", logger.Int("purged", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
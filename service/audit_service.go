@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+const (
+	auditDefaultLimit = 50
+	auditWriteTimeout = 3 * time.Second
+)
+
+// AuditEntry - Record argumenti; Payload JSON'ga aylantiriladi (map yoki struct).
+type AuditEntry struct {
+	Type         string
+	ActorID      string
+	TargetUserID string
+	IP           string
+	UserAgent    string
+	Payload      interface{}
+}
+
+type AuditService interface {
+	// Record xatoni qaytarmaydi: jurnal yozilmasa ham asosiy amal to‘xtamaydi (faqat log)
+	Record(ctx context.Context, e AuditEntry)
+	// ListForUser faqat userga tegishli (target) hodisalar; boshqa actor'ning ID, IP va user agenti yashiriladi
+	ListForUser(ctx context.Context, userID string, filter models.AuditEventFilter) (models.SecurityEventsResponse, error)
+	List(ctx context.Context, filter models.AuditEventFilter) (models.AuditEventsResponse, error)
+}
+
+type auditService struct {
+	stg storage.IAuditStorage
+	log logger.ILogger
+}
+
+func NewAuditService(stg storage.IStorage, log logger.ILogger) AuditService {
+	return &auditService{stg: stg.Audit(), log: log}
+}
+
+func (s *auditService) Record(ctx context.Context, e AuditEntry) {
+	ev := models.AuditEvent{
+		Type:         e.Type,
+		ActorID:      optionalString(e.ActorID),
+		TargetUserID: optionalString(e.TargetUserID),
+		IPAddress:    e.IP,
+		UserAgent:    e.UserAgent,
+	}
+	if e.Payload != nil {
+		raw, err := json.Marshal(e.Payload)
+		if err != nil {
+			s.log.Error("audit payload marshal failed", logger.Error(err), logger.String("type", e.Type))
+		} else {
+			ev.Payload = raw
+		}
+	}
+
+	// so‘rov bekor qilinsa ham (client uzildi) hodisa yozilishi kerak
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+	if err := s.stg.Insert(ctx, ev); err != nil {
+		s.log.Error("audit event not recorded", logger.Error(err), logger.String("type", e.Type),
+			logger.String("target_user_id", e.TargetUserID))
+	}
+}
+
+func (s *auditService) ListForUser(ctx context.Context, userID string, filter models.AuditEventFilter) (models.SecurityEventsResponse, error) {
+	filter.UserID = userID
+	filter.TargetOnly = true
+	list, err := s.List(ctx, filter)
+	if err != nil {
+		return models.SecurityEventsResponse{}, err
+	}
+
+	resp := models.SecurityEventsResponse{Events: make([]models.SecurityEvent, 0, len(list.Events)), NextBefore: list.NextBefore}
+	for _, ev := range list.Events {
+		resp.Events = append(resp.Events, securityEvent(ev, userID))
+	}
+	return resp, nil
+}
+
+// securityEvent - actor userning o‘zi yoki noma'lum (login oldidan) bo‘lsa IP/user agent ko‘rsatiladi:
+// begona urinishlar qayerdan bo‘lganini user ko‘rishi kerak. Admin kabi boshqa user bajargan amalda esa yo‘q.
+func securityEvent(ev models.AuditEvent, userID string) models.SecurityEvent {
+	out := models.SecurityEvent{
+		ID:        ev.ID,
+		Type:      ev.Type,
+		Payload:   ev.Payload,
+		CreatedAt: ev.CreatedAt,
+	}
+	if ev.ActorID != nil && *ev.ActorID != userID {
+		out.ByStaff = true
+		return out
+	}
+	out.IPAddress, out.UserAgent = ev.IPAddress, ev.UserAgent
+	return out
+}
+
+func (s *auditService) List(ctx context.Context, filter models.AuditEventFilter) (models.AuditEventsResponse, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = auditDefaultLimit
+	}
+	events, err := s.stg.List(ctx, filter)
+	if err != nil {
+		return models.AuditEventsResponse{}, err
+	}
+
+	resp := models.AuditEventsResponse{Events: events}
+	if len(events) == filter.Limit {
+		next := events[len(events)-1].ID
+		resp.NextBefore = &next
+	}
+	return resp, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"speakpall/api/models"
+)
+
+func TestSecurityEventProjection(t *testing.T) {
+	self, admin := "user-1", "admin-1"
+	cases := []struct {
+		name      string
+		actor     *string
+		wantStaff bool
+		wantIP    string
+	}{
+		{"own action", &self, false, "10.0.0.1"},
+		{"anonymous attempt", nil, false, "10.0.0.1"},
+		{"staff action", &admin, true, ""},
+	}
+	for _, c := range cases {
+		ev := models.AuditEvent{
+			ID: 7, Type: models.AuditRoleChanged, ActorID: c.actor, TargetUserID: &self,
+			IPAddress: "10.0.0.1", UserAgent: "curl/8", Payload: []byte(`{"role":"moderator"}`),
+		}
+		got := securityEvent(ev, self)
+		if got.ByStaff != c.wantStaff || got.IPAddress != c.wantIP || (got.UserAgent != "") != (c.wantIP != "") {
+			t.Errorf("%s: got %+v", c.name, got)
+		}
+		if got.ID != ev.ID || got.Type != ev.Type || string(got.Payload) != string(ev.Payload) {
+			t.Errorf("%s: event fields not copied: %+v", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/mailer"
+	"speakpall/pkg/security"
+	"speakpall/storage"
+)
+
+var (
+	ErrEmailTaken         = errors.New("email is already in use")
+	ErrEmailUnchanged     = errors.New("new email is the same as the current one")
+	ErrNoPendingEmail     = errors.New("no pending email change")
+	ErrInvalidRevertToken = errors.New("invalid or expired revert link")
+)
+
+type EmailChangeService interface {
+	Request(ctx context.Context, userID, newEmail string) error
+	Confirm(ctx context.Context, userID, code string) error
+	Revert(ctx context.Context, token string) error
+}
+
+type emailChangeService struct {
+	stg        storage.IEmailChangeStorage
+	userStg    storage.IUserStorage
+	otp        OTPService
+	log        logger.ILogger
+	mailerCore *mailer.Mailer
+	appURL     string
+}
+
+func NewEmailChangeService(stg storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer, appURL string) EmailChangeService {
+	return &emailChangeService{
+		stg:        stg.EmailChange(),
+		userStg:    stg.User(),
+		otp:        NewOTPService(stg, log, mailerCore),
+		log:        log,
+		mailerCore: mailerCore,
+		appURL:     strings.TrimRight(appURL, "/"),
+	}
+}
+
+// Request: yangi manzil bandligini oldindan tekshiradi va unga tasdiqlash kodini yuboradi.
+func (s *emailChangeService) Request(ctx context.Context, userID, newEmail string) error {
+	s.log.Info("EmailChangeService.Request", logger.String("user_id", userID))
+	newEmail = normalizeEmail(newEmail)
+
+	u, err := s.userStg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Email, newEmail) {
+		return ErrEmailUnchanged
+	}
+
+	// users.email citext — katta-kichik harf farqi hisobga olinmaydi
+	if existing, err := s.userStg.GetLoginByEmail(ctx, newEmail); err == nil && existing.ID != "" {
+		return ErrEmailTaken
+	}
+
+	// avval kod yuboriladi: cooldown yoki pochta xatosida kutilayotgan so‘rov qolib ketmaydi
+	if err := s.otp.SendCode(ctx, newEmail, models.OTPPurposeChangeEmail); err != nil {
+		return err
+	}
+	_, err = s.stg.Create(ctx, userID, u.Email, newEmail)
+	return err
+}
+
+// Confirm: kodni tekshiradi, emailni almashtiradi va ikkala manzilga xabar yuboradi.
+func (s *emailChangeService) Confirm(ctx context.Context, userID, code string) error {
+	s.log.Info("EmailChangeService.Confirm", logger.String("user_id", userID))
+
+	req, err := s.stg.GetPending(ctx, userID, time.Now().Add(-config.OTPExpireTime))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoPendingEmail
+		}
+		return err
+	}
+
+	if err := s.otp.VerifyCode(ctx, req.NewEmail, models.OTPPurposeChangeEmail, code); err != nil {
+		return err
+	}
+
+	token, err := security.GenerateToken(32)
+	if err != nil {
+		return errors.New("failed to generate token")
+	}
+	if err := s.stg.Confirm(ctx, req.ID, security.HashToken(token), time.Now().Add(config.EmailChangeRevertTime)); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	if err := s.mailerCore.Send(req.OldEmail, "Your email address was changed", s.revertMail(req.NewEmail, token)); err != nil {
+		s.log.Error("email change notice to old address failed", logger.Error(err), logger.String("user_id", userID))
+	}
+	if err := s.mailerCore.Send(req.NewEmail, "Your email address was changed", emailChangedMail(req.NewEmail)); err != nil {
+		s.log.Error("email change notice to new address failed", logger.Error(err), logger.String("user_id", userID))
+	}
+	return nil
+}
+
+// Revert: eski manzilga yuborilgan havola orqali emailni qaytaradi.
+func (s *emailChangeService) Revert(ctx context.Context, token string) error {
+	req, err := s.stg.GetByRevertToken(ctx, security.HashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidRevertToken
+		}
+		return err
+	}
+	s.log.Info("EmailChangeService.Revert", logger.String("user_id", req.UserID))
+
+	if err := s.stg.Revert(ctx, req.ID); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *emailChangeService) revertMail(newEmail, token string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en"><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Email changed</title></head>
+<body style="font-family:Arial,sans-serif;background:#f4f4f4;margin:0;padding:24px">
+  <div style="max-width:600px;margin:0 auto;background:#fff;padding:24px;border-radius:8px">
+    <h2 style="margin:0 0 12px">Your email address was changed</h2>
+    <p style="margin:0 0 16px">The login email of your account was changed to <b>%s</b>.</p>
+    <p style="margin:0 0 16px">If this wasn’t you, restore your old address within %d days:</p>
+    <p><a href="%s/revert-email?token=%s"
+          style="display:inline-block;background:#dc3545;color:#fff;text-decoration:none;padding:12px 20px;border-radius:4px">Revert email change</a></p>
+  </div>
+</body></html>`, newEmail, int(config.EmailChangeRevertTime.Hours()/24), s.appURL, token)
+}
+
+func emailChangedMail(newEmail string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en"><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Email changed</title></head>
+<body style="font-family:Arial,sans-serif;background:#f4f4f4;margin:0;padding:24px">
+  <div style="max-width:600px;margin:0 auto;background:#fff;padding:24px;border-radius:8px">
+    <h2 style="margin:0 0 12px">Email address confirmed</h2>
+    <p style="margin:0 0 16px"><b>%s</b> is now the login email of your account.</p>
+  </div>
+</body></html>`, newEmail)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/blobstore"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/mailer"
+	"speakpall/pkg/security"
+	"speakpall/storage"
+)
+
+var (
+	ErrExportTooSoon     = errors.New("only one data export per day is allowed")
+	ErrExportNotFound    = errors.New("no data export requested yet")
+	ErrExportLinkInvalid = errors.New("invalid or expired download link")
+)
+
+const (
+	exportPollInterval = time.Minute
+	exportCleanupBatch = 100
+	// bitta arxiv uchun yuqori chegara (katta tarixli userlar ham sig‘ishi kerak)
+	exportBuildTimeout = 10 * time.Minute
+)
+
+type ExportService interface {
+	// Request navbatga qo‘yadi; arxiv fon job'ida tayyorlanadi va havola emailga yuboriladi
+	Request(ctx context.Context, userID string) (*models.DataExport, error)
+	Latest(ctx context.Context, userID string) (*models.DataExport, error)
+	// Open havoladagi token bo‘yicha arxivni ochadi (chaqiruvchi yopadi)
+	Open(ctx context.Context, token string) (io.ReadCloser, *models.DataExport, error)
+
+	// RunWorker ctx bekor qilinguncha navbatni qayta ishlaydi va muddati o‘tgan arxivlarni o‘chiradi
+	RunWorker(ctx context.Context)
+}
+
+type exportService struct {
+	stg        storage.IStorage
+	blobs      blobstore.Store
+	log        logger.ILogger
+	mailerCore *mailer.Mailer
+	apiURL     string
+	linkTTL    time.Duration
+
+	// wake - Request'dan keyin worker poll intervalini kutmasdan ishga tushadi
+	wake chan struct{}
+}
+
+func NewExportService(stg storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer, blobs blobstore.Store, cfg config.Config) ExportService {
+	return &exportService{
+		stg:        stg,
+		blobs:      blobs,
+		log:        log,
+		mailerCore: mailerCore,
+		apiURL:     strings.TrimRight(cfg.APIURL, "/"),
+		linkTTL:    cfg.ExportLinkTTL,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (s *exportService) Request(ctx context.Context, userID string) (*models.DataExport, error) {
+	s.log.Info("ExportService.Request", logger.String("user_id", userID))
+	if _, err := s.stg.Export().Create(ctx, userID, time.Now().Add(-config.DataExportCooldown)); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return nil, ErrExportTooSoon
+		}
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return s.stg.Export().GetLatestByUser(ctx, userID)
+}
+
+func (s *exportService) Latest(ctx context.Context, userID string) (*models.DataExport, error) {
+	e, err := s.stg.Export().GetLatestByUser(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrExportNotFound
+	}
+	return e, err
+}
+
+func (s *exportService) Open(ctx context.Context, token string) (io.ReadCloser, *models.DataExport, error) {
+	if token == "" {
+		return nil, nil, ErrExportLinkInvalid
+	}
+	e, err := s.stg.Export().GetByToken(ctx, security.HashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrExportLinkInvalid
+		}
+		return nil, nil, err
+	}
+	rc, err := s.blobs.Open(ctx, e.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, ErrExportLinkInvalid
+		}
+		return nil, nil, err
+	}
+	return rc, e, nil
+}
+
+func (s *exportService) RunWorker(ctx context.Context) {
+	t := time.NewTicker(exportPollInterval)
+	defer t.Stop()
+
+	for {
+		s.processQueue(ctx)
+		s.cleanupExpired(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *exportService) processQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		e, err := s.stg.Export().ClaimNext(ctx, time.Now().Add(-config.DataExportStaleAfter))
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				s.log.Error("export claim failed", logger.Error(err))
+			}
+			return
+		}
+		if err := s.process(ctx, e); err != nil {
+			s.log.Error("data export failed", logger.Error(err), logger.String("export_id", e.ID), logger.String("user_id", e.UserID))
+			if err := s.stg.Export().MarkFailed(ctx, e.ID, err.Error()); err != nil {
+				s.log.Error("export mark failed", logger.Error(err), logger.String("export_id", e.ID))
+			}
+		}
+	}
+}
+
+func (s *exportService) process(ctx context.Context, e *models.DataExport) error {
+	ctx, cancel := context.WithTimeout(ctx, exportBuildTimeout)
+	defer cancel()
+
+	files, err := s.collect(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.zip", e.UserID, e.ID)
+	pr, pw := io.Pipe()
+	go func() { pw.CloseWithError(writeExportZip(pw, files)) }()
+	size, err := s.blobs.Put(ctx, key, pr)
+	_ = pr.Close()
+	if err != nil {
+		return fmt.Errorf("store archive: %w", err)
+	}
+
+	token, err := security.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.linkTTL)
+	if err := s.stg.Export().MarkReady(ctx, e.ID, key, size, security.HashToken(token), expiresAt); err != nil {
+		_ = s.blobs.Delete(ctx, key)
+		return err
+	}
+
+	u, err := s.stg.User().GetUserByID(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.sendReadyEmail(u.Email, token, expiresAt); err != nil {
+		// arxiv tayyor: status GET /user/me/export orqali ko‘rinadi, faqat log qilamiz
+		s.log.Error("send export email failed", logger.Error(err), logger.String("user_id", e.UserID))
+	}
+	s.log.Info("data export ready", logger.String("export_id", e.ID), logger.Int("size", int(size)))
+	return nil
+}
+
+type exportFile struct {
+	name string
+	data interface{}
+}
+
+// collect - barcha ma'lumot arxiv yozilishidan oldin o‘qiladi, shunda DB xatosi yarim arxiv qoldirmaydi.
+func (s *exportService) collect(ctx context.Context, userID string) ([]exportFile, error) {
+	var files []exportFile
+	add := func(name string, data interface{}, err error) error {
+		if err != nil {
+			return fmt.Errorf("collect %s: %w", name, err)
+		}
+		files = append(files, exportFile{name: name, data: data})
+		return nil
+	}
+
+	profile, err := s.stg.Profile().GetProfile(ctx, userID)
+	if err := add("profile.json", profile, err); err != nil {
+		return nil, err
+	}
+	settings, err := s.stg.Settings().GetUserSettings(ctx, userID)
+	if err := add("settings.json", settings, err); err != nil {
+		return nil, err
+	}
+	prefs, err := s.stg.Matchs().GetMatchPrefs(ctx, userID)
+	if err := add("match_preferences.json", prefs, err); err != nil {
+		return nil, err
+	}
+	interests, err := s.stg.Interest().GetUserInterests(ctx, userID)
+	if interests == nil {
+		interests = []int{}
+	}
+	if err := add("interests.json", models.InterestsResponse{InterestIDs: interests}, err); err != nil {
+		return nil, err
+	}
+	friends, err := s.stg.Friend().ListFriends(ctx, userID)
+	if friends == nil {
+		friends = []string{}
+	}
+	if err := add("friends.json", friends, err); err != nil {
+		return nil, err
+	}
+
+	exp := s.stg.Export()
+	blocks, err := exp.ListBlocks(ctx, userID)
+	if err := add("blocks.json", blocks, err); err != nil {
+		return nil, err
+	}
+	sessions, err := exp.ListCallSessions(ctx, userID)
+	if err := add("sessions.json", sessions, err); err != nil {
+		return nil, err
+	}
+	logins, err := exp.ListAuthSessions(ctx, userID)
+	if err := add("login_sessions.json", logins, err); err != nil {
+		return nil, err
+	}
+	given, err := exp.ListFeedbackGiven(ctx, userID)
+	if err := add("feedback_given.json", given, err); err != nil {
+		return nil, err
+	}
+	received, err := exp.ListFeedbackReceived(ctx, userID)
+	if err := add("feedback_received.json", received, err); err != nil {
+		return nil, err
+	}
+	messages, err := exp.ListMessages(ctx, userID)
+	if err := add("messages.json", messages, err); err != nil {
+		return nil, err
+	}
+	notifications, err := exp.ListNotifications(ctx, userID)
+	if err := add("notifications.json", notifications, err); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func writeExportZip(w io.Writer, files []exportFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (s *exportService) cleanupExpired(ctx context.Context) {
+	list, err := s.stg.Export().ListExpired(ctx, time.Now(), exportCleanupBatch)
+	if err != nil {
+		s.log.Error("export cleanup failed", logger.Error(err))
+		return
+	}
+	for _, e := range list {
+		if err := s.blobs.Delete(ctx, e.BlobKey); err != nil {
+			s.log.Error("export blob delete failed", logger.Error(err), logger.String("export_id", e.ID))
+			continue
+		}
+		if err := s.stg.Export().MarkExpired(ctx, e.ID); err != nil {
+			s.log.Error("export mark expired failed", logger.Error(err), logger.String("export_id", e.ID))
+		}
+	}
+}
+
+func (s *exportService) sendReadyEmail(to, token string, expiresAt time.Time) error {
+	subject := "Your SpeakPall data export is ready"
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en"><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>Data Export</title></head>
+<body style="font-family:Arial,sans-serif;background:#f4f4f4;margin:0;padding:24px">
+  <div style="max-width:600px;margin:0 auto;background:#fff;padding:24px;border-radius:8px">
+    <h2 style="margin:0 0 12px">Your data export is ready</h2>
+    <p style="margin:0 0 16px">Download a ZIP archive with a copy of your personal data:</p>
+    <p><a href="%s/exports/%s"
+          style="display:inline-block;background:#007bff;color:#fff;text-decoration:none;padding:12px 20px;border-radius:4px">Download</a></p>
+    <p style="color:#888;margin:16px 0 0">The link expires on %s. If you didn’t request this, please change your password.</p>
+  </div>
+</body></html>`, s.apiURL, token, expiresAt.UTC().Format("2006-01-02 15:04 MST"))
+	return s.mailerCore.Send(to, subject, body)
+}
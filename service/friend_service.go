@@ -33,7 +33,7 @@ func (s *friendService) AddFriend(ctx context.Context, userID, friendID string)
 		return fmt.Errorf("cannot add yourself")
 	}
 	// 1) tekshirish: friendID user sifatida mavjudmi?
-	if _, err := s.userStg.GetUserByID(ctx, friendID); err != nil {
+	if u, err := s.userStg.GetUserByID(ctx, friendID); err != nil || u.DeletedAt != nil {
 		return fmt.Errorf("user not found")
 	}
 	// 2) qo'shish
@@ -2,27 +2,37 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	gojwt "github.com/golang-jwt/jwt/v4"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	oauth2v2 "google.golang.org/api/oauth2/v2"
 
 	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/jwks"
 )
 
-type GoogleOAuthConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURL  string
-}
+var ErrInvalidIDToken = errors.New("invalid google id token")
+
+// Google ID token emitentlari
+var googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+// GoogleOAuthConfig - umumiy provayder konfiguratsiyasining Google bloki.
+type GoogleOAuthConfig = config.OAuthProviderConfig
 
 type GoogleService interface {
 	ExchangeCodeForUser(ctx context.Context, code string) (*models.GoogleUser, error)
+	VerifyIDToken(ctx context.Context, idToken string) (*models.GoogleUser, error)
 }
 
 type googleService struct {
 	oauthConfig *oauth2.Config
+	audiences   []string
+	keys        *jwks.Cache
 }
 
 func NewGoogleService(config GoogleOAuthConfig) GoogleService {
@@ -37,6 +47,8 @@ func NewGoogleService(config GoogleOAuthConfig) GoogleService {
 			},
 			Endpoint: google.Endpoint,
 		},
+		audiences: append([]string{config.ClientID}, config.ClientIDs...),
+		keys:      jwks.New(config.JWKSURL, time.Hour),
 	}
 }
 
@@ -56,12 +68,99 @@ func (g *googleService) ExchangeCodeForUser(ctx context.Context, code string) (*
 		return nil, fmt.Errorf("userinfo fetch failed: %w", err)
 	}
 
-
+	var picture *string
+	if ui.Picture != "" {
+		picture = &ui.Picture
+	}
 
 	return &models.GoogleUser{
-		Email:    ui.Email,
-		Name:     ui.Name,
-		GoogleID: ui.Id,
-		Picture:  &ui.Picture, // models.GoogleUser da omitempty bor
+		Email:         ui.Email,
+		Name:          ui.Name,
+		GoogleID:      ui.Id,
+		EmailVerified: ui.VerifiedEmail != nil && *ui.VerifiedEmail,
+		Picture:       picture, // models.GoogleUser da omitempty bor
 	}, nil
 }
+
+// VerifyIDToken - mobil (Android/iOS) Google SDK bergan ID tokenni tekshiradi:
+// imzo (JWKS), aud, iss, exp va email_verified.
+func (g *googleService) VerifyIDToken(ctx context.Context, idToken string) (*models.GoogleUser, error) {
+	token, err := gojwt.Parse(idToken, func(t *gojwt.Token) (interface{}, error) {
+		if t.Method.Alg() != gojwt.SigningMethodRS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		kid, _ := t.Header["kid"].(string)
+		return g.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	claims, ok := token.Claims.(gojwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	}
+	if !verifyAnyIssuer(claims, googleIssuers) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	}
+	if !verifyAnyAudience(claims, g.audiences) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	if sub == "" || email == "" {
+		return nil, fmt.Errorf("%w: missing sub or email", ErrInvalidIDToken)
+	}
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = email
+	}
+
+	gu := &models.GoogleUser{
+		Email:         email,
+		Name:          name,
+		GoogleID:      sub,
+		EmailVerified: claimBool(claims["email_verified"]),
+	}
+	if pic, _ := claims["picture"].(string); pic != "" {
+		gu.Picture = &pic
+	}
+	if !gu.EmailVerified {
+		return nil, ErrGoogleEmailNotVerified
+	}
+	return gu, nil
+}
+
+func verifyAnyIssuer(claims gojwt.MapClaims, issuers []string) bool {
+	for _, iss := range issuers {
+		if claims.VerifyIssuer(iss, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyAnyAudience(claims gojwt.MapClaims, audiences []string) bool {
+	for _, aud := range audiences {
+		if aud != "" && claims.VerifyAudience(aud, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimBool - Google email_verified ni ba'zan "true" satr ko‘rinishida yuboradi.
+func claimBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
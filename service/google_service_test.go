@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+
+	"speakpall/config"
+	"speakpall/pkg/jwks"
+)
+
+const testGoogleClientID = "web-client.apps.googleusercontent.com"
+
+// googleKeys - httptest JWKS endpoint; kalitlarni rotation'ga o‘xshatib qo‘shish mumkin.
+type googleKeys struct {
+	mu      sync.Mutex
+	priv    map[string]*rsa.PrivateKey
+	fetches int
+}
+
+func (k *googleKeys) add(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.mu.Lock()
+	k.priv[kid] = key
+	k.mu.Unlock()
+}
+
+func (k *googleKeys) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.fetches++
+	set := jwks.Set{Keys: []jwks.JWK{}}
+	for kid, key := range k.priv {
+		j, _ := jwks.FromPublicKey(&key.PublicKey)
+		j.Kid = kid
+		set.Keys = append(set.Keys, j)
+	}
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func (k *googleKeys) sign(t *testing.T, kid string, signer *rsa.PrivateKey, claims gojwt.MapClaims) string {
+	t.Helper()
+	if signer == nil {
+		k.mu.Lock()
+		signer = k.priv[kid]
+		k.mu.Unlock()
+	}
+	tok := gojwt.NewWithClaims(gojwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func googleClaims(mod func(gojwt.MapClaims)) gojwt.MapClaims {
+	c := gojwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            testGoogleClientID,
+		"sub":            "1234567890",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "Test User",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	if mod != nil {
+		mod(c)
+	}
+	return c
+}
+
+func newTestGoogleService(t *testing.T) (*googleService, *googleKeys) {
+	t.Helper()
+	keys := &googleKeys{priv: map[string]*rsa.PrivateKey{}}
+	keys.add(t, "k1")
+	srv := httptest.NewServer(keys)
+	t.Cleanup(srv.Close)
+
+	g := NewGoogleService(config.OAuthProviderConfig{
+		ClientID:  testGoogleClientID,
+		ClientIDs: []string{"android-client.apps.googleusercontent.com"},
+		JWKSURL:   srv.URL,
+	}).(*googleService)
+	return g, keys
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	foreign, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		kid     string
+		signer  *rsa.PrivateKey
+		claims  gojwt.MapClaims
+		wantErr error
+	}{
+		{name: "valid", kid: "k1", claims: googleClaims(nil)},
+		{name: "valid issuer without scheme", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["iss"] = "accounts.google.com" })},
+		{name: "additional client id", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["aud"] = "android-client.apps.googleusercontent.com" })},
+		{name: "email_verified as string", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["email_verified"] = "true" })},
+		{name: "wrong aud", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["aud"] = "someone-else" }), wantErr: ErrInvalidIDToken},
+		{name: "wrong iss", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["iss"] = "https://evil.example.com" }), wantErr: ErrInvalidIDToken},
+		{name: "expired", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }), wantErr: ErrInvalidIDToken},
+		{name: "missing exp", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { delete(c, "exp") }), wantErr: ErrInvalidIDToken},
+		{name: "email not verified", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { c["email_verified"] = false }), wantErr: ErrGoogleEmailNotVerified},
+		{name: "missing sub", kid: "k1", claims: googleClaims(func(c gojwt.MapClaims) { delete(c, "sub") }), wantErr: ErrInvalidIDToken},
+		{name: "signed by unknown key", kid: "k1", signer: foreign, claims: googleClaims(nil), wantErr: ErrInvalidIDToken},
+		{name: "unknown kid", kid: "k9", signer: foreign, claims: googleClaims(nil), wantErr: ErrInvalidIDToken},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g, keys := newTestGoogleService(t)
+			gu, err := g.VerifyIDToken(context.Background(), keys.sign(t, c.kid, c.signer, c.claims))
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("got %v, want %v", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gu.GoogleID != "1234567890" || gu.Email != "user@example.com" || gu.Name != "Test User" || !gu.EmailVerified {
+				t.Fatalf("unexpected user %+v", gu)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenUnsignedAlgRejected(t *testing.T) {
+	g, _ := newTestGoogleService(t)
+	tok := gojwt.NewWithClaims(gojwt.SigningMethodHS256, googleClaims(nil))
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.VerifyIDToken(context.Background(), s); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("got %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestVerifyIDTokenRefreshesOnUnknownKid(t *testing.T) {
+	g, keys := newTestGoogleService(t)
+	g.keys.SetMinRefresh(0)
+	ctx := context.Background()
+
+	if _, err := g.VerifyIDToken(ctx, keys.sign(t, "k1", nil, googleClaims(nil))); err != nil {
+		t.Fatalf("k1: %v", err)
+	}
+
+	// Google kalitni almashtirdi: yangi kid keshda yo‘q — JWKS qayta yuklanadi
+	keys.add(t, "k2")
+	if _, err := g.VerifyIDToken(ctx, keys.sign(t, "k2", nil, googleClaims(nil))); err != nil {
+		t.Fatalf("k2 after rotation: %v", err)
+	}
+	if _, err := g.VerifyIDToken(ctx, keys.sign(t, "k1", nil, googleClaims(nil))); err != nil {
+		t.Fatalf("k1 after rotation: %v", err)
+	}
+
+	keys.mu.Lock()
+	fetches := keys.fetches
+	keys.mu.Unlock()
+	if fetches != 2 {
+		t.Fatalf("jwks fetches = %d, want 2", fetches)
+	}
+}
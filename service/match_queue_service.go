@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+var (
+	ErrAlreadyQueued         = errors.New("already in match queue")
+	ErrNotQueued             = errors.New("not in match queue")
+	ErrMatchLanguageRequired = errors.New("language is required (set target language in profile or request)")
+	ErrTandemNativeRequired  = errors.New("tandem mode requires a native language different from the target language")
+)
+
+const (
+	matchQueueKey       = "match:queue"
+	matchEntryKeyPrefix = "match:entry:"
+	// matchBatchSize - bitta tick'da ko‘rib chiqiladigan eng eski navbat a'zolari
+	matchBatchSize = 500
+	// matchStaleMargin - Redis'dagi yozuv yo‘qolgan (restart) queued attemptlar DB'da shuncha kechroq expired qilinadi
+	matchStaleMargin = time.Minute
+
+	matchETAKeyPrefix = "match:eta:"
+	// matchETAWindow - ETA shu davrdagi matched attemptlardan; matchETAMinSamples'dan kam bo‘lsa faqat til bo‘yicha
+	matchETAWindow     = 7 * 24 * time.Hour
+	matchETAMinSamples = 5
+	matchETACacheTTL   = time.Minute
+)
+
+type MatchQueueService interface {
+	Enqueue(ctx context.Context, userID string, req models.MatchQueueRequest) (*models.MatchQueueStatus, error)
+	Cancel(ctx context.Context, userID string) error
+	Status(ctx context.Context, userID string) (*models.MatchQueueStatus, error)
+	// Explain ikki userning hozirgi profillari bo‘yicha juftlik bahosi (debug, admin)
+	Explain(ctx context.Context, userA, userB string) (*models.MatchScore, error)
+	// RunMatcher ctx bekor qilinguncha navbatdagi userlarni juftlaydi; bir nechta instance'da parallel ishlashi xavfsiz
+	RunMatcher(ctx context.Context)
+}
+
+type matchQueueService struct {
+	stg   storage.IMatchAttemptStorage
+	redis storage.IRedisStorage
+	log   logger.ILogger
+
+	scorer   *MatchScorer
+	relax    MatchRelaxPolicy
+	minScore float64
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func NewMatchQueueService(stg storage.IStorage, redis storage.IRedisStorage, log logger.ILogger, cfg config.Config) MatchQueueService {
+	return &matchQueueService{
+		stg:      stg.MatchAttempt(),
+		redis:    redis,
+		log:      log,
+		scorer:   NewMatchScorer(cfg.MatchWeights, cfg.MatchTandemFallback),
+		relax:    NewMatchRelaxPolicy(cfg),
+		minScore: cfg.MatchMinScore,
+		timeout:  cfg.MatchQueueTimeout,
+		interval: cfg.MatchInterval,
+	}
+}
+
+func matchEntryKey(userID string) string {
+	return matchEntryKeyPrefix + userID
+}
+
+func (s *matchQueueService) Enqueue(ctx context.Context, userID string, req models.MatchQueueRequest) (*models.MatchQueueStatus, error) {
+	s.log.Info("MatchQueueService.Enqueue", logger.String("user_id", userID))
+
+	c, err := s.stg.LoadCandidate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountDeleted
+		}
+		return nil, err
+	}
+	if req.Language != "" {
+		c.Language = req.Language
+	}
+	c.Language = strings.ToLower(strings.TrimSpace(c.Language))
+	if c.Language == "" {
+		return nil, ErrMatchLanguageRequired
+	}
+	if req.Level != nil {
+		c.Level = *req.Level
+	}
+	c.Mode = models.MatchModeStandard
+	if req.Mode == models.MatchModeTandem {
+		if c.NativeLang == "" || strings.EqualFold(c.NativeLang, c.Language) {
+			return nil, ErrTandemNativeRequired
+		}
+		c.Mode = models.MatchModeTandem
+	}
+	var level *int
+	if c.Level > 0 {
+		level = &c.Level
+	}
+
+	attempt, err := s.stg.CreateQueued(ctx, userID, c.Language, level, c.Mode)
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return nil, ErrAlreadyQueued
+		}
+		return nil, err
+	}
+
+	c.AttemptID = attempt.ID
+	c.EnqueuedAt = time.Now().UnixMilli()
+	ok, err := s.push(ctx, *c)
+	if err != nil || !ok {
+		// DB va Redis bir-biridan farq qilmasligi uchun attempt yopiladi
+		if _, cerr := s.stg.SetStatus(context.WithoutCancel(ctx), attempt.ID, models.MatchStatusQueued, models.MatchStatusCanceled); cerr != nil {
+			s.log.Error("match attempt rollback failed", logger.Error(cerr), logger.String("attempt_id", attempt.ID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrAlreadyQueued
+	}
+
+	return s.Status(ctx, userID)
+}
+
+func (s *matchQueueService) Cancel(ctx context.Context, userID string) error {
+	s.log.Info("MatchQueueService.Cancel", logger.String("user_id", userID))
+
+	claimed, err := s.redis.ClaimMembers(ctx, matchQueueKey, []string{userID}, []string{matchEntryKey(userID)})
+	if err != nil {
+		return err
+	}
+
+	// Redis'da bo‘lmasa ham (masalan restart) DB'dagi queued attempt yopiladi
+	attempt, err := s.stg.GetLatestByUser(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	canceled := false
+	if attempt != nil && attempt.Status == models.MatchStatusQueued {
+		canceled, err = s.stg.SetStatus(ctx, attempt.ID, models.MatchStatusQueued, models.MatchStatusCanceled)
+		if err != nil {
+			return err
+		}
+	}
+	if !claimed && !canceled {
+		return ErrNotQueued
+	}
+	return nil
+}
+
+func (s *matchQueueService) Status(ctx context.Context, userID string) (*models.MatchQueueStatus, error) {
+	attempt, err := s.stg.GetLatestByUser(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	rank, size, ok, err := s.redis.QueueRank(ctx, matchQueueKey, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.MatchQueueStatus{Status: models.MatchStatusIdle, QueueSize: size}
+	if attempt == nil {
+		return res, nil
+	}
+	res.Status = attempt.Status
+	res.Attempt = attempt
+	if attempt.Status != models.MatchStatusQueued || !ok {
+		return res, nil
+	}
+	pos := rank + 1
+	res.Position = &pos
+
+	vals, err := s.redis.MGet(ctx, matchEntryKey(userID))
+	if err != nil {
+		return nil, err
+	}
+	var c models.MatchCandidate
+	if len(vals) == 0 || vals[0] == "" || json.Unmarshal([]byte(vals[0]), &c) != nil {
+		return res, nil
+	}
+	now := time.Now()
+	_, crit := s.relax.Apply(c, now)
+	res.Criteria = &crit
+	if res.EstimatedWait, err = s.estimateWait(ctx, c, now); err != nil {
+		s.log.Error("match queue: wait estimate failed", logger.Error(err), logger.String("user_id", userID))
+		res.EstimatedWait = nil
+	}
+	return res, nil
+}
+
+// matchWaitStats - ETA keshi (Redis) qiymati
+type matchWaitStats struct {
+	Median  time.Duration `json:"median"`
+	Samples int           `json:"samples"`
+	Basis   string        `json:"basis"`
+}
+
+// estimateWait - shu til juftligi (maqsad + ona tili) bo‘yicha matched attemptlar kutish medianasi,
+// namuna kam bo‘lsa faqat maqsad tili bo‘yicha; allaqachon kutilgan vaqt ayriladi.
+func (s *matchQueueService) estimateWait(ctx context.Context, c models.MatchCandidate, now time.Time) (*models.MatchETA, error) {
+	key := matchETAKeyPrefix + matchMode(c) + ":" + c.Language + ":" + strings.ToLower(c.NativeLang)
+	var st matchWaitStats
+	vals, err := s.redis.MGet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 || vals[0] == "" || json.Unmarshal([]byte(vals[0]), &st) != nil {
+		if st, err = s.loadWaitStats(ctx, c, now); err != nil {
+			return nil, err
+		}
+		if raw, err := json.Marshal(st); err == nil {
+			if err := s.redis.SetX(ctx, key, string(raw), matchETACacheTTL); err != nil {
+				s.log.Error("match queue: eta cache failed", logger.Error(err))
+			}
+		}
+	}
+
+	eta := &models.MatchETA{SampleSize: st.Samples, Basis: st.Basis}
+	if left := st.Median - now.Sub(time.UnixMilli(c.EnqueuedAt)); st.Samples > 0 && left > 0 {
+		eta.Seconds = int(left.Round(time.Second) / time.Second)
+	}
+	return eta, nil
+}
+
+func (s *matchQueueService) loadWaitStats(ctx context.Context, c models.MatchCandidate, now time.Time) (matchWaitStats, error) {
+	since := now.Add(-matchETAWindow)
+	if c.NativeLang != "" {
+		median, n, err := s.stg.WaitStats(ctx, c.Language, c.NativeLang, matchMode(c), since)
+		if err != nil {
+			return matchWaitStats{}, err
+		}
+		if n >= matchETAMinSamples {
+			return matchWaitStats{Median: median, Samples: n, Basis: "language_pair"}, nil
+		}
+	}
+	median, n, err := s.stg.WaitStats(ctx, c.Language, "", matchMode(c), since)
+	if err != nil {
+		return matchWaitStats{}, err
+	}
+	if n == 0 {
+		return matchWaitStats{Basis: "none"}, nil
+	}
+	return matchWaitStats{Median: median, Samples: n, Basis: "language"}, nil
+}
+
+func (s *matchQueueService) Explain(ctx context.Context, userA, userB string) (*models.MatchScore, error) {
+	a, err := s.stg.LoadCandidate(ctx, userA)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	b, err := s.stg.LoadCandidate(ctx, userB)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	score := s.scorer.Score(*a, *b)
+	return &score, nil
+}
+
+func (s *matchQueueService) RunMatcher(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		s.expire(ctx)
+		if n := s.pair(ctx); n > 0 {
+			s.log.Info("matcher paired users", logger.Int("pairs", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// push - kandidatni navbatga yozadi; member (userID) allaqachon navbatda bo‘lsa false.
+func (s *matchQueueService) push(ctx context.Context, c models.MatchCandidate) (bool, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return false, err
+	}
+	return s.redis.EnqueueUnique(ctx, matchQueueKey, c.UserID, c.EnqueuedAt, matchEntryKey(c.UserID), string(raw), s.timeout+matchStaleMargin)
+}
+
+// load - navbat a'zolari va ularning yozuvlari; yozuvi yo‘q (TTL tugagan) a'zolar uchun nil.
+func (s *matchQueueService) load(ctx context.Context, maxScore int64) ([]string, []*models.MatchCandidate, error) {
+	members, err := s.redis.QueueRange(ctx, matchQueueKey, maxScore, matchBatchSize)
+	if err != nil || len(members) == 0 {
+		return nil, nil, err
+	}
+	keys := make([]string, len(members))
+	for i, m := range members {
+		keys[i] = matchEntryKey(m)
+	}
+	vals, err := s.redis.MGet(ctx, keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]*models.MatchCandidate, len(members))
+	for i, v := range vals {
+		if v == "" {
+			continue
+		}
+		var c models.MatchCandidate
+		if err := json.Unmarshal([]byte(v), &c); err != nil {
+			s.log.Error("match entry decode failed", logger.Error(err), logger.String("user_id", members[i]))
+			continue
+		}
+		entries[i] = &c
+	}
+	return members, entries, nil
+}
+
+// expire - timeout'dan oshgan a'zolarni navbatdan olib, attemptlarini expired qiladi.
+func (s *matchQueueService) expire(ctx context.Context) {
+	now := time.Now()
+	members, entries, err := s.load(ctx, now.Add(-s.timeout).UnixMilli())
+	if err != nil {
+		s.log.Error("match queue expire: load failed", logger.Error(err))
+		return
+	}
+	for i, m := range members {
+		ok, err := s.redis.ClaimMembers(ctx, matchQueueKey, []string{m}, []string{matchEntryKey(m)})
+		if err != nil || !ok || entries[i] == nil {
+			continue
+		}
+		if _, err := s.stg.SetStatus(ctx, entries[i].AttemptID, models.MatchStatusQueued, models.MatchStatusExpired); err != nil {
+			s.log.Error("match attempt expire failed", logger.Error(err), logger.String("attempt_id", entries[i].AttemptID))
+		}
+	}
+
+	if _, err := s.stg.ExpireStale(ctx, now.Add(-s.timeout-matchStaleMargin)); err != nil {
+		s.log.Error("match queue expire: stale attempts", logger.Error(err))
+	}
+}
+
+// pair - eng eski kandidatdan boshlab unga eng yuqori ball bergan mos sherikni tanlaydi
+// (tandemda o‘zaro juftlik bir tomonlamadan ustun; teng bo‘lsa uzoqroq kutgani). Mezonlar kutish vaqtiga qarab
+// MatchRelaxPolicy bo‘yicha yumshatiladi. Juftlik avval Redis'dan atomar olinadi (ClaimMembers),
+// shuning uchun user ikki marta juftlanmaydi.
+func (s *matchQueueService) pair(ctx context.Context) int {
+	now := time.Now()
+	members, entries, err := s.load(ctx, now.UnixMilli())
+	if err != nil {
+		s.log.Error("matcher: load failed", logger.Error(err))
+		return 0
+	}
+
+	eff := make([]*models.MatchCandidate, len(entries))
+	for i, e := range entries {
+		if e != nil {
+			c, _ := s.relax.Apply(*e, now)
+			eff[i] = &c
+		}
+	}
+
+	pairs := 0
+	used := make([]bool, len(members))
+	for i := range members {
+		if used[i] || entries[i] == nil {
+			continue
+		}
+		best, bestScore := -1, models.MatchScore{}
+		for j := i + 1; j < len(members); j++ {
+			if used[j] || entries[j] == nil {
+				continue
+			}
+			score := s.scorer.ScoreAt(*eff[i], *eff[j], now)
+			if !score.Compatible || score.Total < s.minScore {
+				continue
+			}
+			if best < 0 || betterMatch(score, bestScore) {
+				best, bestScore = j, score
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		a, b := *entries[i], *entries[best]
+		ok, err := s.redis.ClaimMembers(ctx, matchQueueKey,
+			[]string{a.UserID, b.UserID}, []string{matchEntryKey(a.UserID), matchEntryKey(b.UserID)})
+		if err != nil {
+			s.log.Error("matcher: claim failed", logger.Error(err))
+			return pairs
+		}
+		// ok=false: boshqa instance yoki cancel oldinroq oldi; keyingi tick'da yangi holat bilan
+		used[i], used[best] = true, true
+		if ok && s.match(ctx, a, b, bestScore) {
+			pairs++
+		}
+	}
+	return pairs
+}
+
+func betterMatch(x, y models.MatchScore) bool {
+	xm, ym := x.Direction == models.TandemMutual, y.Direction == models.TandemMutual
+	if xm != ym {
+		return xm
+	}
+	return x.Total > y.Total
+}
+
+func (s *matchQueueService) match(ctx context.Context, a, b models.MatchCandidate, score models.MatchScore) bool {
+	sessionID, err := s.stg.Match(ctx, a, b, score.Direction)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.log.Error("matcher: match failed", logger.Error(err),
+				logger.String("a_user_id", a.UserID), logger.String("b_user_id", b.UserID))
+		}
+		s.requeue(ctx, a)
+		s.requeue(ctx, b)
+		return false
+	}
+	s.log.Info("matched", logger.String("session_id", sessionID),
+		logger.String("a_user_id", a.UserID), logger.String("b_user_id", b.UserID), logger.Any("score", score))
+	return true
+}
+
+// requeue - match bo‘lmagan kandidatni navbatdagi o‘rnini saqlab qaytaradi, agar attempt hali
+// queued va user mavjud bo‘lsa (bloklar yangilanadi); aks holda attempt canceled qilinadi.
+func (s *matchQueueService) requeue(ctx context.Context, c models.MatchCandidate) {
+	attempt, err := s.stg.GetLatestByUser(ctx, c.UserID)
+	if err != nil || attempt.ID != c.AttemptID || attempt.Status != models.MatchStatusQueued {
+		return
+	}
+
+	fresh, err := s.stg.LoadCandidate(ctx, c.UserID)
+	if err == nil {
+		fresh.AttemptID, fresh.EnqueuedAt = c.AttemptID, c.EnqueuedAt
+		fresh.Language, fresh.Level, fresh.Mode = c.Language, c.Level, c.Mode
+		if ok, perr := s.push(ctx, *fresh); perr == nil && ok {
+			return
+		} else if perr != nil {
+			err = perr
+		}
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		s.log.Error("matcher: requeue failed", logger.Error(err), logger.String("user_id", c.UserID))
+	}
+	if _, err := s.stg.SetStatus(ctx, c.AttemptID, models.MatchStatusQueued, models.MatchStatusCanceled); err != nil {
+		s.log.Error("matcher: cancel attempt failed", logger.Error(err), logger.String("attempt_id", c.AttemptID))
+	}
+}
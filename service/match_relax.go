@@ -0,0 +1,118 @@
+package service
+
+import (
+	"time"
+
+	"speakpall/api/models"
+	"speakpall/config"
+)
+
+const (
+	// relaxMaxLevelWiden - daraja oralig‘i har tomonga ko‘pi bilan shuncha kengayadi
+	relaxMaxLevelWiden = 2
+	minUserLevel       = 1
+	maxUserLevel       = 6
+)
+
+// MatchRelaxPolicy - navbatda kutish vaqtiga qarab mezonlarni bosqichma-bosqich yumshatadi:
+// daraja oralig‘i, mamlakat filtri, min_rating. Davomiyligi 0 bo‘lgan bosqich o‘chiq.
+// Bloklar, til/rejim, gender_filter va yosh chegarasi bu yerda hech qachon o‘zgarmaydi.
+type MatchRelaxPolicy struct {
+	LevelAfter   time.Duration
+	CountryAfter time.Duration
+	RatingAfter  time.Duration
+}
+
+func NewMatchRelaxPolicy(cfg config.Config) MatchRelaxPolicy {
+	return MatchRelaxPolicy{
+		LevelAfter:   cfg.MatchRelaxLevelAfter,
+		CountryAfter: cfg.MatchRelaxCountryAfter,
+		RatingAfter:  cfg.MatchRelaxRatingAfter,
+	}
+}
+
+// Apply - now paytidagi effektiv kandidat va uning mezonlari. Asl kandidat (Redis'dagi yozuv) o‘zgarmaydi,
+// shuning uchun yumshatish har tick'da EnqueuedAt'dan qayta hisoblanadi.
+func (p MatchRelaxPolicy) Apply(c models.MatchCandidate, now time.Time) (models.MatchCandidate, models.MatchCriteria) {
+	enqueued := time.UnixMilli(c.EnqueuedAt)
+	waited := now.Sub(enqueued)
+	crit := models.MatchCriteria{Language: c.Language, Mode: matchMode(c), GenderFilter: c.GenderFilter, Relaxed: []string{}}
+	var next time.Time
+	schedule := func(at time.Time) {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+
+	if steps := relaxSteps(waited, p.LevelAfter); p.LevelAfter > 0 {
+		if steps > relaxMaxLevelWiden {
+			steps = relaxMaxLevelWiden
+		}
+		if steps < relaxMaxLevelWiden {
+			schedule(enqueued.Add(time.Duration(steps+1) * p.LevelAfter))
+		}
+		if steps > 0 {
+			if c.MinLevel != nil || c.MaxLevel != nil {
+				c.MinLevel = widenLevel(c.MinLevel, -steps)
+				c.MaxLevel = widenLevel(c.MaxLevel, steps)
+			} else {
+				c.LevelGap = defaultLevelGap + steps
+			}
+			crit.Relaxed = append(crit.Relaxed, models.MatchRelaxLevel)
+		}
+	}
+
+	if p.CountryAfter > 0 && len(c.Countries) > 0 {
+		if waited >= p.CountryAfter {
+			c.Countries = nil
+			crit.Relaxed = append(crit.Relaxed, models.MatchRelaxCountry)
+		} else {
+			schedule(enqueued.Add(p.CountryAfter))
+		}
+	}
+
+	if p.RatingAfter > 0 && c.MinRating != nil {
+		steps := relaxSteps(waited, p.RatingAfter)
+		rating := *c.MinRating - steps
+		if steps > 0 {
+			crit.Relaxed = append(crit.Relaxed, models.MatchRelaxRating)
+		}
+		if rating <= 1 {
+			// 1 — har qanday reyting o‘tadi
+			c.MinRating = nil
+		} else {
+			c.MinRating = &rating
+			schedule(enqueued.Add(time.Duration(steps+1) * p.RatingAfter))
+		}
+	}
+
+	crit.MinLevel, crit.MaxLevel = c.MinLevel, c.MaxLevel
+	if c.MinLevel == nil && c.MaxLevel == nil {
+		gap := defaultLevelGap
+		if c.LevelGap > 0 {
+			gap = c.LevelGap
+		}
+		crit.LevelGap = &gap
+	}
+	crit.Countries, crit.MinRating = c.Countries, c.MinRating
+	if !next.IsZero() {
+		crit.NextRelaxAt = &next
+	}
+	return c, crit
+}
+
+func relaxSteps(waited, after time.Duration) int {
+	if after <= 0 || waited < after {
+		return 0
+	}
+	return int(waited / after)
+}
+
+// widenLevel - yangi qiymat (asl pointer o‘zgarmaydi), 1..6 oralig‘ida.
+func widenLevel(v *int, delta int) *int {
+	if v == nil {
+		return nil
+	}
+	n := min(max(*v+delta, minUserLevel), maxUserLevel)
+	return &n
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"speakpall/api/models"
+)
+
+// Juftlik bahosi signallari (MATCH_WEIGHT_<NAME> nomlari bilan bir xil)
+const (
+	SignalLanguage  = "language"
+	SignalLevel     = "level"
+	SignalGender    = "gender"
+	SignalCountry   = "country"
+	SignalRating    = "rating"
+	SignalInterests = "interests"
+	SignalTimezone  = "timezone"
+)
+
+const (
+	// defaultLevelGap - min/max_level berilmagan bo‘lsa ruxsat etilgan daraja farqi
+	defaultLevelGap = 1
+	// adultAge - shundan kichik user faqat yoshi ma'lum boshqa voyaga yetmagan bilan juftlanadi
+	adultAge = 18
+)
+
+// MatchSignalFunc - a nuqtai nazaridan b ni baholaydi: score 0..1, passed=false — a b ni qabul qilmaydi.
+// detail debug uchun (bo‘sh bo‘lishi mumkin).
+type MatchSignalFunc func(a, b models.MatchCandidate) (score float64, passed bool, detail string)
+
+// MatchSignalDef - nomlangan signal; yangi signal qo‘shish uchun MatchScorer.Register.
+type MatchSignalDef struct {
+	Name string
+	Eval MatchSignalFunc
+}
+
+// MatchScorer - signallarni ikki yo‘nalishda hisoblab, og‘irlikli ball va tafsilot qaytaradi.
+type MatchScorer struct {
+	signals []MatchSignalDef
+	weights map[string]float64
+	// tandemFallback - tandem rejimida ikkala user shuncha kutgandan keyin bir tomonlama juftlik ham olinadi
+	tandemFallback time.Duration
+}
+
+// NewMatchScorer - standart signallar bilan; weights'da yo‘q signal og‘irligi 1.
+func NewMatchScorer(weights map[string]float64, tandemFallback time.Duration) *MatchScorer {
+	s := &MatchScorer{weights: weights, tandemFallback: tandemFallback}
+	s.Register(SignalLanguage, languageSignal)
+	s.Register(SignalLevel, levelSignal)
+	s.Register(SignalGender, genderSignal)
+	s.Register(SignalCountry, countrySignal)
+	s.Register(SignalRating, ratingSignal)
+	s.Register(SignalInterests, interestsSignal)
+	s.Register(SignalTimezone, timezoneSignal)
+	return s
+}
+
+// Register - shu nomli signal bo‘lsa almashtiradi, aks holda oxiriga qo‘shadi.
+func (s *MatchScorer) Register(name string, eval MatchSignalFunc) {
+	for i := range s.signals {
+		if s.signals[i].Name == name {
+			s.signals[i].Eval = eval
+			return
+		}
+	}
+	s.signals = append(s.signals, MatchSignalDef{Name: name, Eval: eval})
+}
+
+func (s *MatchScorer) weight(name string) float64 {
+	if w, ok := s.weights[name]; ok {
+		return math.Max(w, 0)
+	}
+	return 1
+}
+
+// Score - hozirgi vaqt bo‘yicha ScoreAt.
+func (s *MatchScorer) Score(a, b models.MatchCandidate) models.MatchScore {
+	return s.ScoreAt(a, b, time.Now())
+}
+
+// ScoreAt - juftlik bahosi. Rejimlar har xil, til mos kelmasa (standard: bir xil maqsad tili,
+// tandem: til almashinuvi), blok yoki yosh chegarasi bo‘lsa signallar hisoblanmaydi.
+// Bu shartlar qat'iy — MatchRelaxPolicy ularni yumshatmaydi.
+// Filtr signal (passed=false) og‘irligi 0 bo‘lsa ham juftlikni rad etadi.
+func (s *MatchScorer) ScoreAt(a, b models.MatchCandidate, now time.Time) models.MatchScore {
+	res := models.MatchScore{Signals: []models.MatchSignal{}}
+	switch {
+	case a.UserID == b.UserID:
+		res.Reason = "same user"
+		return res
+	case matchMode(a) != matchMode(b):
+		res.Reason = "different queue mode"
+		return res
+	case containsString(a.Blocked, b.UserID) || containsString(b.Blocked, a.UserID):
+		res.Reason = "blocked"
+		return res
+	case !ageCompatible(a, b):
+		res.Reason = "age gate"
+		return res
+	}
+
+	if matchMode(a) == models.MatchModeTandem {
+		dir := tandemDirection(a, b)
+		switch {
+		case dir == "":
+			res.Reason = "no language swap"
+			return res
+		case dir != models.TandemMutual && !s.oneWayAllowed(a, b, now):
+			res.Reason = "one-way swap, waiting for mutual"
+			res.Direction = dir
+			return res
+		}
+		res = s.Breakdown(a, b)
+		res.Direction = dir
+		return res
+	}
+
+	if !strings.EqualFold(a.Language, b.Language) {
+		res.Reason = fmt.Sprintf("different queue language (%s / %s)", a.Language, b.Language)
+		return res
+	}
+	return s.Breakdown(a, b)
+}
+
+// oneWayAllowed - har ikki user o‘zi tandemFallback'dan ko‘p kutgan bo‘lsa.
+func (s *MatchScorer) oneWayAllowed(a, b models.MatchCandidate, now time.Time) bool {
+	since := now.Add(-s.tandemFallback).UnixMilli()
+	return a.EnqueuedAt <= since && b.EnqueuedAt <= since
+}
+
+// ageCompatible - voyaga yetmagan user faqat yoshi ma'lum voyaga yetmagan bilan; ikkalasining yoshi noma'lum bo‘lsa ruxsat.
+func ageCompatible(a, b models.MatchCandidate) bool {
+	return isMinor(a) == isMinor(b)
+}
+
+func isMinor(c models.MatchCandidate) bool {
+	return c.Age != nil && *c.Age < adultAge
+}
+
+func matchMode(c models.MatchCandidate) string {
+	if c.Mode == "" {
+		return models.MatchModeStandard
+	}
+	return c.Mode
+}
+
+// tandemDirection - mutual, a_teaches, b_teaches yoki "" (hech bir tomon sherigining maqsad tilida native emas).
+func tandemDirection(a, b models.MatchCandidate) string {
+	aTeaches := a.NativeLang != "" && strings.EqualFold(a.NativeLang, b.Language)
+	bTeaches := b.NativeLang != "" && strings.EqualFold(b.NativeLang, a.Language)
+	switch {
+	case aTeaches && bTeaches:
+		return models.TandemMutual
+	case aTeaches:
+		return models.MatchDirectionATeaches
+	case bTeaches:
+		return models.MatchDirectionBTeaches
+	}
+	return ""
+}
+
+// Breakdown - til/blok shartisiz signallar bo‘yicha baho (browse'da: sherik boshqa tilni o‘rganayotgan bo‘lishi mumkin).
+func (s *MatchScorer) Breakdown(a, b models.MatchCandidate) models.MatchScore {
+	res := models.MatchScore{Compatible: true, Signals: make([]models.MatchSignal, 0, len(s.signals))}
+	var sum, total float64
+	for _, sig := range s.signals {
+		sa, pa, da := sig.Eval(a, b)
+		sb, pb, db := sig.Eval(b, a)
+		ms := models.MatchSignal{
+			Name:   sig.Name,
+			Weight: s.weight(sig.Name),
+			Score:  round2((sa + sb) / 2),
+			Passed: pa && pb,
+		}
+		if da != "" {
+			ms.Detail = append(ms.Detail, "a→b: "+da)
+		}
+		if db != "" {
+			ms.Detail = append(ms.Detail, "b→a: "+db)
+		}
+		if !ms.Passed && res.Compatible {
+			res.Compatible = false
+			res.Reason = sig.Name + " filter"
+		}
+		sum += ms.Weight * ms.Score
+		total += ms.Weight
+		res.Signals = append(res.Signals, ms)
+	}
+	if total > 0 {
+		res.Total = round2(sum / total)
+	}
+	return res
+}
+
+// languageSignal - b o‘rganayotgan til a ning ona tili bo‘lsa a b ga yordam bera oladi (tandem).
+func languageSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if a.NativeLang == "" {
+		return 0, true, "native language unknown"
+	}
+	if strings.EqualFold(a.NativeLang, b.Language) {
+		return 1, true, "native " + a.NativeLang + " is partner's target"
+	}
+	return 0, true, ""
+}
+
+// levelSignal - a ning min/max_level oralig‘i (bo‘lmasa ±LevelGap, standart defaultLevelGap); ball darajalar yaqinligi.
+func levelSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if a.MinLevel != nil || a.MaxLevel != nil {
+		if b.Level == 0 {
+			return 0, false, "partner level unknown, range required"
+		}
+		if a.MinLevel != nil && b.Level < *a.MinLevel {
+			return 0, false, fmt.Sprintf("level %d < min %d", b.Level, *a.MinLevel)
+		}
+		if a.MaxLevel != nil && b.Level > *a.MaxLevel {
+			return 0, false, fmt.Sprintf("level %d > max %d", b.Level, *a.MaxLevel)
+		}
+	}
+	if a.Level == 0 || b.Level == 0 {
+		return 0.5, true, "level unknown"
+	}
+	gap := a.Level - b.Level
+	if gap < 0 {
+		gap = -gap
+	}
+	allowed := defaultLevelGap
+	if a.LevelGap > 0 {
+		allowed = a.LevelGap
+	}
+	if a.MinLevel == nil && a.MaxLevel == nil && gap > allowed {
+		return 0, false, fmt.Sprintf("level gap %d > %d", gap, allowed)
+	}
+	return 1 - float64(gap)/5, true, ""
+}
+
+func genderSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if a.GenderFilter == "" || a.GenderFilter == "any" {
+		return 1, true, ""
+	}
+	if strings.EqualFold(a.GenderFilter, b.Gender) {
+		return 1, true, ""
+	}
+	return 0, false, fmt.Sprintf("gender filter %s, partner %q", a.GenderFilter, b.Gender)
+}
+
+func countrySignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if len(a.Countries) == 0 || containsFold(a.Countries, b.Country) {
+		return 1, true, ""
+	}
+	return 0, false, fmt.Sprintf("country %q not in allow list", b.Country)
+}
+
+// ratingSignal - reytingi yo‘q (yangi) user filtrdan o‘tadi va neytral 0.5 oladi.
+func ratingSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if b.Rating == nil {
+		return 0.5, true, "partner not rated"
+	}
+	if a.MinRating != nil && *b.Rating < float64(*a.MinRating) {
+		return 0, false, fmt.Sprintf("rating %.2f < min %d", *b.Rating, *a.MinRating)
+	}
+	return *b.Rating / 5, true, ""
+}
+
+// interestsSignal - Jaccard o‘xshashligi (umumiy / jami).
+func interestsSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	if len(a.Interests) == 0 || len(b.Interests) == 0 {
+		return 0, true, "no interests"
+	}
+	set := make(map[int]bool, len(a.Interests))
+	for _, id := range a.Interests {
+		set[id] = true
+	}
+	common, union := 0, len(set)
+	for _, id := range b.Interests {
+		if set[id] {
+			common++
+			set[id] = false
+		} else if _, seen := set[id]; !seen {
+			union++
+			set[id] = false
+		}
+	}
+	return float64(common) / float64(union), true, fmt.Sprintf("%d common of %d", common, union)
+}
+
+// timezoneSignal - UTC farqi 0 soat — 1, 12 soat va undan ko‘p — 0; noma'lum zona neytral 0.5.
+func timezoneSignal(a, b models.MatchCandidate) (float64, bool, string) {
+	oa, okA := utcOffsetHours(a.Timezone)
+	ob, okB := utcOffsetHours(b.Timezone)
+	if !okA || !okB {
+		return 0.5, true, "timezone unknown"
+	}
+	diff := math.Abs(oa - ob)
+	if diff > 12 {
+		diff = 24 - diff
+	}
+	return math.Max(0, 1-diff/12), true, fmt.Sprintf("%.1fh apart", diff)
+}
+
+func utcOffsetHours(tz string) (float64, bool) {
+	if tz == "" {
+		return 0, false
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return 0, false
+	}
+	_, off := time.Now().In(loc).Zone()
+	return float64(off) / 3600, true
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata" // timezoneSignal testlari tizim tzdata'siga bog‘liq bo‘lmasin
+
+	"speakpall/api/models"
+)
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+func cand(id, lang string) models.MatchCandidate {
+	return models.MatchCandidate{UserID: id, Language: lang}
+}
+
+type signalCase struct {
+	name       string
+	a, b       models.MatchCandidate
+	wantScore  float64
+	wantPassed bool
+}
+
+func runSignalCases(t *testing.T, eval MatchSignalFunc, cases []signalCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			score, passed, detail := eval(c.a, c.b)
+			if round2(score) != c.wantScore || passed != c.wantPassed {
+				t.Fatalf("got score=%v passed=%v (%s), want score=%v passed=%v", score, passed, detail, c.wantScore, c.wantPassed)
+			}
+		})
+	}
+}
+
+func TestLanguageSignal(t *testing.T) {
+	runSignalCases(t, languageSignal, []signalCase{
+		{name: "native is partner target", a: models.MatchCandidate{NativeLang: "uz"}, b: models.MatchCandidate{Language: "UZ"}, wantScore: 1, wantPassed: true},
+		{name: "no reciprocity", a: models.MatchCandidate{NativeLang: "uz"}, b: models.MatchCandidate{Language: "en"}, wantScore: 0, wantPassed: true},
+		{name: "native unknown", a: models.MatchCandidate{}, b: models.MatchCandidate{Language: "en"}, wantScore: 0, wantPassed: true},
+	})
+}
+
+func TestTandemDirection(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b models.MatchCandidate
+		want string
+	}{
+		{"mutual", models.MatchCandidate{Language: "en", NativeLang: "uz"}, models.MatchCandidate{Language: "uz", NativeLang: "en"}, models.TandemMutual},
+		{"a teaches", models.MatchCandidate{Language: "de", NativeLang: "uz"}, models.MatchCandidate{Language: "uz", NativeLang: "en"}, models.MatchDirectionATeaches},
+		{"b teaches", models.MatchCandidate{Language: "en", NativeLang: "uz"}, models.MatchCandidate{Language: "de", NativeLang: "en"}, models.MatchDirectionBTeaches},
+		{"no swap", models.MatchCandidate{Language: "en", NativeLang: "uz"}, models.MatchCandidate{Language: "en", NativeLang: "ru"}, ""},
+		{"case insensitive", models.MatchCandidate{Language: "EN", NativeLang: "uz"}, models.MatchCandidate{Language: "UZ", NativeLang: "en"}, models.TandemMutual},
+		{"unknown native", models.MatchCandidate{Language: "en"}, models.MatchCandidate{Language: ""}, ""},
+	}
+	for _, c := range cases {
+		if got := tandemDirection(c.a, c.b); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLevelSignal(t *testing.T) {
+	runSignalCases(t, levelSignal, []signalCase{
+		{name: "inside range", a: models.MatchCandidate{Level: 3, MinLevel: intPtr(2), MaxLevel: intPtr(4)}, b: models.MatchCandidate{Level: 4}, wantScore: 0.8, wantPassed: true},
+		{name: "below min", a: models.MatchCandidate{Level: 3, MinLevel: intPtr(3)}, b: models.MatchCandidate{Level: 2}, wantScore: 0, wantPassed: false},
+		{name: "above max", a: models.MatchCandidate{Level: 3, MaxLevel: intPtr(4)}, b: models.MatchCandidate{Level: 5}, wantScore: 0, wantPassed: false},
+		{name: "range with unknown partner level", a: models.MatchCandidate{MinLevel: intPtr(1)}, b: models.MatchCandidate{}, wantScore: 0, wantPassed: false},
+		{name: "range allows wide gap", a: models.MatchCandidate{Level: 1, MaxLevel: intPtr(6)}, b: models.MatchCandidate{Level: 6}, wantScore: 0, wantPassed: true},
+		{name: "same level", a: models.MatchCandidate{Level: 3}, b: models.MatchCandidate{Level: 3}, wantScore: 1, wantPassed: true},
+		{name: "default gap", a: models.MatchCandidate{Level: 3}, b: models.MatchCandidate{Level: 2}, wantScore: 0.8, wantPassed: true},
+		{name: "gap over default", a: models.MatchCandidate{Level: 3}, b: models.MatchCandidate{Level: 5}, wantScore: 0, wantPassed: false},
+		{name: "relaxed gap", a: models.MatchCandidate{Level: 3, LevelGap: 2}, b: models.MatchCandidate{Level: 5}, wantScore: 0.6, wantPassed: true},
+		{name: "unknown level", a: models.MatchCandidate{}, b: models.MatchCandidate{Level: 5}, wantScore: 0.5, wantPassed: true},
+	})
+}
+
+func TestGenderSignal(t *testing.T) {
+	runSignalCases(t, genderSignal, []signalCase{
+		{name: "no filter", a: models.MatchCandidate{}, b: models.MatchCandidate{Gender: "male"}, wantScore: 1, wantPassed: true},
+		{name: "any", a: models.MatchCandidate{GenderFilter: "any"}, b: models.MatchCandidate{Gender: "male"}, wantScore: 1, wantPassed: true},
+		{name: "match", a: models.MatchCandidate{GenderFilter: "female"}, b: models.MatchCandidate{Gender: "Female"}, wantScore: 1, wantPassed: true},
+		{name: "mismatch", a: models.MatchCandidate{GenderFilter: "female"}, b: models.MatchCandidate{Gender: "male"}, wantScore: 0, wantPassed: false},
+		{name: "partner gender unknown", a: models.MatchCandidate{GenderFilter: "female"}, b: models.MatchCandidate{}, wantScore: 0, wantPassed: false},
+	})
+}
+
+func TestCountrySignal(t *testing.T) {
+	runSignalCases(t, countrySignal, []signalCase{
+		{name: "no allow list", a: models.MatchCandidate{}, b: models.MatchCandidate{Country: "UZ"}, wantScore: 1, wantPassed: true},
+		{name: "allowed", a: models.MatchCandidate{Countries: []string{"KZ", "UZ"}}, b: models.MatchCandidate{Country: "uz"}, wantScore: 1, wantPassed: true},
+		{name: "not allowed", a: models.MatchCandidate{Countries: []string{"KZ"}}, b: models.MatchCandidate{Country: "UZ"}, wantScore: 0, wantPassed: false},
+		{name: "partner country unknown", a: models.MatchCandidate{Countries: []string{"KZ"}}, b: models.MatchCandidate{}, wantScore: 0, wantPassed: false},
+	})
+}
+
+func TestRatingSignal(t *testing.T) {
+	runSignalCases(t, ratingSignal, []signalCase{
+		{name: "unrated passes min", a: models.MatchCandidate{MinRating: intPtr(4)}, b: models.MatchCandidate{}, wantScore: 0.5, wantPassed: true},
+		{name: "rated without min", a: models.MatchCandidate{}, b: models.MatchCandidate{Rating: floatPtr(4)}, wantScore: 0.8, wantPassed: true},
+		{name: "at min", a: models.MatchCandidate{MinRating: intPtr(4)}, b: models.MatchCandidate{Rating: floatPtr(4)}, wantScore: 0.8, wantPassed: true},
+		{name: "below min", a: models.MatchCandidate{MinRating: intPtr(4)}, b: models.MatchCandidate{Rating: floatPtr(3.9)}, wantScore: 0, wantPassed: false},
+	})
+}
+
+func TestInterestsSignal(t *testing.T) {
+	runSignalCases(t, interestsSignal, []signalCase{
+		{name: "identical", a: models.MatchCandidate{Interests: []int{1, 2}}, b: models.MatchCandidate{Interests: []int{2, 1}}, wantScore: 1, wantPassed: true},
+		{name: "half overlap", a: models.MatchCandidate{Interests: []int{1, 2, 3}}, b: models.MatchCandidate{Interests: []int{2, 3, 4}}, wantScore: 0.5, wantPassed: true},
+		{name: "disjoint", a: models.MatchCandidate{Interests: []int{1}}, b: models.MatchCandidate{Interests: []int{2}}, wantScore: 0, wantPassed: true},
+		{name: "duplicates counted once", a: models.MatchCandidate{Interests: []int{1, 2}}, b: models.MatchCandidate{Interests: []int{2, 2, 3}}, wantScore: 0.33, wantPassed: true},
+		{name: "no interests", a: models.MatchCandidate{}, b: models.MatchCandidate{Interests: []int{1}}, wantScore: 0, wantPassed: true},
+	})
+}
+
+func TestTimezoneSignal(t *testing.T) {
+	runSignalCases(t, timezoneSignal, []signalCase{
+		{name: "same zone", a: models.MatchCandidate{Timezone: "Asia/Tashkent"}, b: models.MatchCandidate{Timezone: "Asia/Tashkent"}, wantScore: 1, wantPassed: true},
+		{name: "5h apart", a: models.MatchCandidate{Timezone: "UTC"}, b: models.MatchCandidate{Timezone: "Asia/Tashkent"}, wantScore: 0.58, wantPassed: true},
+		{name: "12h apart", a: models.MatchCandidate{Timezone: "UTC"}, b: models.MatchCandidate{Timezone: "Etc/GMT-12"}, wantScore: 0, wantPassed: true},
+		{name: "wraps around date line", a: models.MatchCandidate{Timezone: "Pacific/Kiritimati"}, b: models.MatchCandidate{Timezone: "Etc/GMT+10"}, wantScore: 1, wantPassed: true},
+		{name: "unknown zone", a: models.MatchCandidate{Timezone: "Mars/Olympus"}, b: models.MatchCandidate{Timezone: "UTC"}, wantScore: 0.5, wantPassed: true},
+		{name: "missing zone", a: models.MatchCandidate{}, b: models.MatchCandidate{Timezone: "UTC"}, wantScore: 0.5, wantPassed: true},
+	})
+}
+
+func TestAgeCompatible(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b *int
+		want bool
+	}{
+		{"both adults", intPtr(30), intPtr(18), true},
+		{"both minors", intPtr(15), intPtr(17), true},
+		{"minor and adult", intPtr(17), intPtr(18), false},
+		{"adult and minor", intPtr(40), intPtr(16), false},
+		{"minor and unknown", intPtr(16), nil, false},
+		{"unknown and minor", nil, intPtr(16), false},
+		{"adult and unknown", intPtr(25), nil, true},
+		{"both unknown", nil, nil, true},
+	}
+	for _, c := range cases {
+		a, b := models.MatchCandidate{Age: c.a}, models.MatchCandidate{Age: c.b}
+		if got := ageCompatible(a, b); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestScoreAtGates(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMatchScorer(nil, 2*time.Minute)
+	waited := func(c models.MatchCandidate, d time.Duration) models.MatchCandidate {
+		c.EnqueuedAt = now.Add(-d).UnixMilli()
+		return c
+	}
+	tandem := func(id, lang, native string) models.MatchCandidate {
+		return models.MatchCandidate{UserID: id, Language: lang, NativeLang: native, Mode: models.MatchModeTandem}
+	}
+	blocked := cand("a", "en")
+	blocked.Blocked = []string{"b"}
+	minor := cand("a", "en")
+	minor.Age = intPtr(16)
+
+	cases := []struct {
+		name           string
+		a, b           models.MatchCandidate
+		wantCompatible bool
+		wantReason     string
+		wantDirection  string
+	}{
+		{name: "same language", a: cand("a", "en"), b: cand("b", "EN"), wantCompatible: true},
+		{name: "same user", a: cand("a", "en"), b: cand("a", "en"), wantReason: "same user"},
+		{name: "different language", a: cand("a", "en"), b: cand("b", "de"), wantReason: "different queue language (en / de)"},
+		{name: "blocked by a", a: blocked, b: cand("b", "en"), wantReason: "blocked"},
+		{name: "blocked by b", a: cand("b", "en"), b: blocked, wantReason: "blocked"},
+		{name: "age gate", a: minor, b: cand("b", "en"), wantReason: "age gate"},
+		{name: "different mode", a: cand("a", "en"), b: tandem("b", "en", "uz"), wantReason: "different queue mode"},
+		{name: "tandem mutual", a: tandem("a", "en", "uz"), b: tandem("b", "uz", "en"), wantCompatible: true, wantDirection: models.TandemMutual},
+		{name: "tandem no swap", a: tandem("a", "en", "uz"), b: tandem("b", "en", "ru"), wantReason: "no language swap"},
+		{name: "tandem one-way before fallback",
+			a: waited(tandem("a", "de", "uz"), 3*time.Minute), b: waited(tandem("b", "uz", "en"), time.Minute),
+			wantReason: "one-way swap, waiting for mutual", wantDirection: models.MatchDirectionATeaches},
+		{name: "tandem one-way after fallback",
+			a: waited(tandem("a", "de", "uz"), 3*time.Minute), b: waited(tandem("b", "uz", "en"), 2*time.Minute),
+			wantCompatible: true, wantDirection: models.MatchDirectionATeaches},
+		{name: "tandem age gate", a: func() models.MatchCandidate { c := tandem("a", "en", "uz"); c.Age = intPtr(15); return c }(),
+			b: tandem("b", "uz", "en"), wantReason: "age gate"},
+		{name: "filter signal rejects", a: func() models.MatchCandidate { c := cand("a", "en"); c.GenderFilter = "female"; return c }(),
+			b: cand("b", "en"), wantReason: "gender filter"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := s.ScoreAt(c.a, c.b, now)
+			if got.Compatible != c.wantCompatible || got.Reason != c.wantReason || got.Direction != c.wantDirection {
+				t.Fatalf("got compatible=%v reason=%q direction=%q, want %v %q %q",
+					got.Compatible, got.Reason, got.Direction, c.wantCompatible, c.wantReason, c.wantDirection)
+			}
+			if got.Signals == nil {
+				t.Fatal("signals must be a non-nil slice")
+			}
+		})
+	}
+}
+
+func TestBreakdownWeightedTotal(t *testing.T) {
+	weights := map[string]float64{
+		SignalLanguage: 3, SignalLevel: 2, SignalGender: 1, SignalCountry: 1,
+		SignalRating: 1, SignalInterests: 2, SignalTimezone: 1,
+	}
+	s := NewMatchScorer(weights, time.Minute)
+	a := models.MatchCandidate{UserID: "a", Language: "en", NativeLang: "uz", Level: 3, Rating: floatPtr(4), Timezone: "UTC", Interests: []int{1, 2}}
+	b := models.MatchCandidate{UserID: "b", Language: "en", NativeLang: "ru", Level: 3, Timezone: "UTC", Interests: []int{2, 3}}
+
+	got := s.Breakdown(a, b)
+	want := []models.MatchSignal{
+		{Name: SignalLanguage, Weight: 3, Score: 0, Passed: true},
+		{Name: SignalLevel, Weight: 2, Score: 1, Passed: true},
+		{Name: SignalGender, Weight: 1, Score: 1, Passed: true},
+		{Name: SignalCountry, Weight: 1, Score: 1, Passed: true},
+		{Name: SignalRating, Weight: 1, Score: 0.65, Passed: true, Detail: []string{"a→b: partner not rated"}},
+		{Name: SignalInterests, Weight: 2, Score: 0.33, Passed: true, Detail: []string{"a→b: 1 common of 3", "b→a: 1 common of 3"}},
+		{Name: SignalTimezone, Weight: 1, Score: 1, Passed: true, Detail: []string{"a→b: 0.0h apart", "b→a: 0.0h apart"}},
+	}
+	if !reflect.DeepEqual(got.Signals, want) {
+		t.Fatalf("signals:\n got  %+v\n want %+v", got.Signals, want)
+	}
+	// (3*0 + 2*1 + 1 + 1 + 0.65 + 2*0.33 + 1) / 11 = 6.31 / 11
+	if !got.Compatible || got.Total != 0.57 {
+		t.Fatalf("got compatible=%v total=%v, want true 0.57", got.Compatible, got.Total)
+	}
+}
+
+func TestBreakdownWeights(t *testing.T) {
+	a := models.MatchCandidate{UserID: "a", Language: "en", NativeLang: "uz", GenderFilter: "female"}
+	b := models.MatchCandidate{UserID: "b", Language: "uz", NativeLang: "en", Gender: "male"}
+
+	t.Run("zero weight filter still rejects", func(t *testing.T) {
+		s := NewMatchScorer(map[string]float64{SignalGender: 0}, time.Minute)
+		got := s.Breakdown(a, b)
+		if got.Compatible || got.Reason != "gender filter" {
+			t.Fatalf("got compatible=%v reason=%q", got.Compatible, got.Reason)
+		}
+	})
+
+	t.Run("negative weight clamps to zero", func(t *testing.T) {
+		s := NewMatchScorer(map[string]float64{SignalLanguage: -5}, time.Minute)
+		if w := s.weight(SignalLanguage); w != 0 {
+			t.Fatalf("weight %v, want 0", w)
+		}
+		if w := s.weight(SignalTimezone); w != 1 {
+			t.Fatalf("missing weight %v, want default 1", w)
+		}
+	})
+
+	t.Run("only weighted signal counts", func(t *testing.T) {
+		weights := map[string]float64{SignalLanguage: 1}
+		for _, name := range []string{SignalLevel, SignalGender, SignalCountry, SignalRating, SignalInterests, SignalTimezone} {
+			weights[name] = 0
+		}
+		s := NewMatchScorer(weights, time.Minute)
+		a := a
+		a.GenderFilter = ""
+		if got := s.Breakdown(a, b); !got.Compatible || got.Total != 1 {
+			t.Fatalf("got compatible=%v total=%v, want mutual language only = 1", got.Compatible, got.Total)
+		}
+	})
+
+	t.Run("register replaces and appends", func(t *testing.T) {
+		s := NewMatchScorer(nil, time.Minute)
+		n := len(s.signals)
+		s.Register(SignalLanguage, func(_, _ models.MatchCandidate) (float64, bool, string) { return 1, true, "" })
+		s.Register("streak", func(_, _ models.MatchCandidate) (float64, bool, string) { return 0, false, "" })
+		if len(s.signals) != n+1 || s.signals[0].Name != SignalLanguage || s.signals[n].Name != "streak" {
+			t.Fatalf("unexpected signals %+v", s.signals)
+		}
+		a := a
+		a.GenderFilter = ""
+		got := s.Breakdown(a, b)
+		if got.Signals[0].Score != 1 || got.Compatible || got.Reason != "streak filter" {
+			t.Fatalf("got %+v", got)
+		}
+	})
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/jwt"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/security"
+	"speakpall/pkg/totp"
+	"speakpall/storage"
+)
+
+var (
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolling    = errors.New("start two-factor enrollment first")
+	ErrMFAInvalidCode     = errors.New("invalid two-factor code")
+	ErrMFATokenInvalid    = errors.New("invalid or expired mfa token")
+	ErrMFARequired        = errors.New("two-factor authentication is mandatory for this role")
+	ErrMFATooManyAttempts = errors.New("too many invalid two-factor codes, sign in again later")
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+
+	mfaFailPrefix = "mfa_fail:"
+	mfaLockPrefix = "mfa_lock:"
+	// mfaUsedPrefix - ishlatilgan yoki yoqilgan mfa_pending token jti (muddati tugaguncha)
+	mfaUsedPrefix = "mfa_used:"
+)
+
+type MFAService interface {
+	Enroll(ctx context.Context, userID string) (models.MFAEnrollResponse, error)
+	// Confirm birinchi kod bilan yoqadi va bir martalik tiklash kodlarini qaytaradi
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+
+	// BeginLogin birinchi faktordan keyin: 2FA kerak bo‘lmasa nil
+	BeginLogin(ctx context.Context, userID, role string) (*models.MFAPendingResponse, error)
+	EnrollPending(ctx context.Context, mfaToken string) (models.MFAEnrollResponse, error)
+	// CompleteLogin mfa_pending token + kod -> userID (enrollment shu yerda tasdiqlansa tiklash kodlari ham)
+	CompleteLogin(ctx context.Context, mfaToken, code string) (string, []string, error)
+}
+
+type mfaService struct {
+	stg      storage.IMFAStorage
+	userStg  storage.IUserStorage
+	limiter  RateLimiter
+	log      logger.ILogger
+	issuer   string
+	forAdmin bool
+
+	maxFailures int
+	lockout     time.Duration
+}
+
+func NewMFAService(stg storage.IStorage, limiter RateLimiter, log logger.ILogger, cfg config.Config) MFAService {
+	return &mfaService{
+		stg:         stg.MFA(),
+		userStg:     stg.User(),
+		limiter:     limiter,
+		log:         log,
+		issuer:      cfg.MFAIssuer,
+		forAdmin:    cfg.MFARequiredForAdmin,
+		maxFailures: cfg.MFAMaxFailures,
+		lockout:     cfg.MFALockout,
+	}
+}
+
+func (s *mfaService) Enroll(ctx context.Context, userID string) (models.MFAEnrollResponse, error) {
+	s.log.Info("MFAService.Enroll", logger.String("user_id", userID))
+	u, err := s.userStg.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.MFAEnrollResponse{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.MFAEnrollResponse{}, err
+	}
+	ok, err := s.stg.UpsertPending(ctx, userID, secret)
+	if err != nil {
+		return models.MFAEnrollResponse{}, err
+	}
+	if !ok {
+		return models.MFAEnrollResponse{}, ErrMFAAlreadyEnabled
+	}
+
+	return models.MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.issuer, u.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	s.log.Info("MFAService.Confirm", logger.String("user_id", userID))
+	m, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMFANotEnrolling
+	}
+	if m.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(m.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	confirmed, err := s.stg.Confirm(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return codes, nil
+}
+
+// Disable: amaldagi kod talab qilinadi; rol uchun majburiy bo‘lsa rad etiladi.
+func (s *mfaService) Disable(ctx context.Context, userID, code string) error {
+	s.log.Info("MFAService.Disable", logger.String("user_id", userID))
+	u, err := s.userStg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.requiredFor(u.Role) {
+		return ErrMFARequired
+	}
+	if err := s.verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.stg.Delete(ctx, userID)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	s.log.Info("MFAService.RegenerateRecoveryCodes", logger.String("user_id", userID))
+	if err := s.verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.stg.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) BeginLogin(ctx context.Context, userID, role string) (*models.MFAPendingResponse, error) {
+	m, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := m != nil && m.ConfirmedAt != nil
+	if !enabled && !s.requiredFor(role) {
+		return nil, nil
+	}
+
+	token, err := jwt.GenerateMFAPendingToken(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAPendingResponse{
+		MFARequired:        true,
+		MFAToken:           token,
+		EnrollmentRequired: !enabled,
+	}, nil
+}
+
+// EnrollPending: 2FA majburiy, lekin hali yoqilmagan rol uchun login paytidagi enrollment.
+func (s *mfaService) EnrollPending(ctx context.Context, mfaToken string) (models.MFAEnrollResponse, error) {
+	t, err := s.pendingToken(ctx, mfaToken)
+	if err != nil {
+		return models.MFAEnrollResponse{}, err
+	}
+	return s.Enroll(ctx, t.userID)
+}
+
+// CompleteLogin: token bir martalik (muvaffaqiyatdan keyin jti yoqiladi). Noto‘g‘ri kodlar verify bilan bir xil
+// user hisoblagichida sanaladi — lockout boshlanganda token ham yoqiladi.
+func (s *mfaService) CompleteLogin(ctx context.Context, mfaToken, code string) (string, []string, error) {
+	t, err := s.pendingToken(ctx, mfaToken)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.checkLock(ctx, t.userID); err != nil {
+		return "", nil, err
+	}
+
+	m, err := s.get(ctx, t.userID)
+	if err != nil {
+		return "", nil, err
+	}
+	var codes []string
+	if m != nil && m.ConfirmedAt == nil {
+		// login paytidagi enrollment shu kod bilan tasdiqlanadi
+		codes, err = s.Confirm(ctx, t.userID, code)
+	} else {
+		err = s.checkCode(ctx, t.userID, code)
+	}
+	if err := s.recordResult(ctx, t.userID, err); err != nil {
+		if errors.Is(err, ErrMFATooManyAttempts) {
+			if lerr := s.limiter.Lock(ctx, mfaUsedPrefix+t.jti, time.Until(t.expiresAt)); lerr != nil {
+				return "", nil, lerr
+			}
+		}
+		return "", nil, err
+	}
+
+	if err := s.limiter.Lock(ctx, mfaUsedPrefix+t.jti, time.Until(t.expiresAt)); err != nil {
+		return "", nil, err
+	}
+	return t.userID, codes, nil
+}
+
+// verify: Disable va RegenerateRecoveryCodes uchun — CompleteLogin bilan bir xil user lockout'i ostida
+// (o‘g‘irlangan access token bilan kodlarni cheksiz taxmin qilib 2FA'ni o‘chirib bo‘lmaydi).
+func (s *mfaService) verify(ctx context.Context, userID, code string) error {
+	if err := s.checkLock(ctx, userID); err != nil {
+		return err
+	}
+	return s.recordResult(ctx, userID, s.checkCode(ctx, userID, code))
+}
+
+func (s *mfaService) checkLock(ctx context.Context, userID string) error {
+	left, err := s.limiter.LockedFor(ctx, mfaLockPrefix+userID)
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		return ErrMFATooManyAttempts
+	}
+	return nil
+}
+
+// recordResult - kod tekshiruvi natijasi: muvaffaqiyatda hisoblagich tozalanadi, noto‘g‘ri kod sanaladi;
+// maxFailures'ga yetganda user lockout muddatiga bloklanadi va ErrMFATooManyAttempts qaytadi.
+func (s *mfaService) recordResult(ctx context.Context, userID string, err error) error {
+	if err == nil {
+		if rerr := s.limiter.Reset(ctx, mfaFailPrefix+userID); rerr != nil {
+			s.log.Error("reset mfa failures failed", logger.Error(rerr), logger.String("user_id", userID))
+		}
+		return nil
+	}
+	if !errors.Is(err, ErrMFAInvalidCode) || s.maxFailures <= 0 {
+		return err
+	}
+
+	res, lerr := s.limiter.Allow(ctx, mfaFailPrefix+userID, s.maxFailures, s.lockout)
+	if lerr != nil {
+		return lerr
+	}
+	if res.Allowed && res.Count < s.maxFailures {
+		return ErrMFAInvalidCode
+	}
+
+	s.log.Warning("mfa locked out", logger.String("user_id", userID))
+	if lerr := s.limiter.Lock(ctx, mfaLockPrefix+userID, s.lockout); lerr != nil {
+		return lerr
+	}
+	if rerr := s.limiter.Reset(ctx, mfaFailPrefix+userID); rerr != nil {
+		s.log.Error("reset mfa failures failed", logger.Error(rerr), logger.String("user_id", userID))
+	}
+	return ErrMFATooManyAttempts
+}
+
+// checkCode: TOTP (har qadam bir marta) yoki bir martalik tiklash kodi.
+func (s *mfaService) checkCode(ctx context.Context, userID, code string) error {
+	m, err := s.get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	if step, ok := totp.Validate(m.Secret, code, time.Now()); ok {
+		used, err := s.stg.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrMFAInvalidCode // shu kod allaqachon ishlatilgan
+		}
+		return nil
+	}
+
+	used, err := s.stg.UseRecoveryCode(ctx, userID, security.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	s.log.Warning("mfa recovery code used", logger.String("user_id", userID))
+	return nil
+}
+
+func (s *mfaService) get(ctx context.Context, userID string) (*models.UserMFA, error) {
+	m, err := s.stg.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *mfaService) requiredFor(role string) bool {
+	return s.forAdmin && role == models.RoleAdmin
+}
+
+type mfaPendingToken struct {
+	userID    string
+	jti       string
+	expiresAt time.Time
+}
+
+// pendingToken - imzo, typ va muddatni tekshiradi; ishlatilgan/yoqilgan jti rad etiladi.
+func (s *mfaService) pendingToken(ctx context.Context, token string) (mfaPendingToken, error) {
+	claims, err := jwt.ExtractClaims(token)
+	if err != nil {
+		return mfaPendingToken{}, ErrMFATokenInvalid
+	}
+	if t, _ := claims["typ"].(string); t != "mfa_pending" {
+		return mfaPendingToken{}, ErrMFATokenInvalid
+	}
+	t := mfaPendingToken{}
+	t.userID, _ = claims["user_id"].(string)
+	t.jti, _ = claims["jti"].(string)
+	expStr, _ := claims["exp"].(string)
+	exp, _ := strconv.ParseInt(expStr, 10, 64)
+	if t.userID == "" || t.jti == "" || exp == 0 {
+		return mfaPendingToken{}, ErrMFATokenInvalid
+	}
+	t.expiresAt = time.Unix(exp, 0)
+
+	used, err := s.limiter.LockedFor(ctx, mfaUsedPrefix+t.jti)
+	if err != nil {
+		return mfaPendingToken{}, err
+	}
+	if used > 0 {
+		return mfaPendingToken{}, ErrMFATokenInvalid
+	}
+	return t, nil
+}
+
+// generateRecoveryCodes: "abcd-efgh-ijkl" ko‘rinishida; faqat hashlari saqlanadi.
+func generateRecoveryCodes() ([]string, []string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	hashes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(buf))[:12]
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12])
+		hashes = append(hashes, security.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
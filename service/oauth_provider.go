@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/facebook"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/jwks"
+)
+
+var ErrOAuthExchange = errors.New("oauth provider exchange failed")
+
+// OAuthProvider - bitta tashqi login provayderi (Google, Apple, Facebook, ixtiyoriy OIDC).
+type OAuthProvider interface {
+	Name() string
+	// AuthCodeURL PKCE (S256) challenge va nonce bilan login sahifasi manzilini qaytaradi
+	AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error)
+	// Exchange code'ni tokenga almashtiradi va foydalanuvchini tekshirib qaytaradi
+	Exchange(ctx context.Context, code, verifier, nonce string) (*models.ExternalIdentity, error)
+}
+
+// OAuthRegistry - provayderlar nomi bo‘yicha. ClientID berilmagan provayderlar ro‘yxatga kirmaydi.
+type OAuthRegistry struct {
+	providers map[string]OAuthProvider
+}
+
+func NewOAuthRegistry(cfg config.Config) *OAuthRegistry {
+	r := &OAuthRegistry{providers: map[string]OAuthProvider{}}
+
+	if cfg.Google.ClientID != "" {
+		r.Register(newOIDCProvider(models.ProviderGoogle, cfg.Google, googleIssuers...))
+	}
+	if cfg.Apple.ClientID != "" {
+		p := newOIDCProvider("apple", cfg.Apple)
+		// Apple name/email scope'lari bilan faqat form_post qabul qiladi
+		p.authParams = append(p.authParams, oauth2.SetAuthURLParam("response_mode", "form_post"))
+		r.Register(p)
+	}
+	if cfg.Facebook.ClientID != "" {
+		r.Register(newFacebookProvider(cfg.Facebook))
+	}
+	for name, pc := range cfg.OIDC {
+		if pc.ClientID != "" && pc.Issuer != "" {
+			r.Register(newOIDCProvider(name, pc))
+		}
+	}
+	return r
+}
+
+func (r *OAuthRegistry) Register(p OAuthProvider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *OAuthRegistry) Get(name string) (OAuthProvider, bool) {
+	p, ok := r.providers[strings.ToLower(name)]
+	return p, ok
+}
+
+func (r *OAuthRegistry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ---------------- OIDC ----------------
+
+type oidcEndpoints struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	name       string
+	cfg        config.OAuthProviderConfig
+	issuers    []string
+	audiences  []string
+	authParams []oauth2.AuthCodeOption
+	client     *http.Client
+
+	mu        sync.Mutex
+	endpoints *oidcEndpoints
+	keys      *jwks.Cache
+}
+
+// newOIDCProvider - issuers bo‘sh bo‘lsa cfg.Issuer ishlatiladi (Google ikki xil iss yuboradi).
+func newOIDCProvider(name string, cfg config.OAuthProviderConfig, issuers ...string) *oidcProvider {
+	if len(issuers) == 0 {
+		issuers = []string{strings.TrimRight(cfg.Issuer, "/")}
+	}
+	return &oidcProvider{
+		name:      name,
+		cfg:       cfg,
+		issuers:   issuers,
+		audiences: append([]string{cfg.ClientID}, cfg.ClientIDs...),
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *oidcProvider) Name() string { return p.name }
+
+// discover - endpointlarni bir marta yuklaydi; config'dagi qiymatlar discovery'dan ustun.
+func (p *oidcProvider) discover(ctx context.Context) (*oidcEndpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	ep := &oidcEndpoints{
+		AuthURL:     p.cfg.AuthURL,
+		TokenURL:    p.cfg.TokenURL,
+		UserInfoURL: p.cfg.UserInfoURL,
+		JWKSURL:     p.cfg.JWKSURL,
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		var doc oidcEndpoints
+		wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getJSON(ctx, p.client, wellKnown, "", &doc); err != nil {
+			return nil, fmt.Errorf("%s discovery: %w", p.name, err)
+		}
+		ep.AuthURL = firstNonEmpty(ep.AuthURL, doc.AuthURL)
+		ep.TokenURL = firstNonEmpty(ep.TokenURL, doc.TokenURL)
+		ep.UserInfoURL = firstNonEmpty(ep.UserInfoURL, doc.UserInfoURL)
+		ep.JWKSURL = firstNonEmpty(ep.JWKSURL, doc.JWKSURL)
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		return nil, fmt.Errorf("%s: incomplete oidc endpoints", p.name)
+	}
+
+	p.endpoints = ep
+	p.keys = jwks.New(ep.JWKSURL, time.Hour)
+	return ep, nil
+}
+
+func (p *oidcProvider) oauthConfig(ep *oidcEndpoints) *oauth2.Config {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+		if p.name == "apple" {
+			scopes = []string{"openid", "email", "name"}
+		}
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       scopes,
+		Endpoint:     oauth2.Endpoint{AuthURL: ep.AuthURL, TokenURL: ep.TokenURL},
+	}
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts := append([]oauth2.AuthCodeOption{
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	}, p.authParams...)
+	return p.oauthConfig(ep).AuthCodeURL(state, opts...), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*models.ExternalIdentity, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := p.oauthConfig(ep).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthExchange, err)
+	}
+	rawID, _ := tok.Extra("id_token").(string)
+	if rawID == "" {
+		return nil, fmt.Errorf("%w: id_token missing", ErrOAuthExchange)
+	}
+
+	claims, err := p.verifyIDToken(ctx, rawID, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	ident := &models.ExternalIdentity{Provider: p.name, EmailVerified: claimBool(claims["email_verified"])}
+	ident.Subject, _ = claims["sub"].(string)
+	ident.Email, _ = claims["email"].(string)
+	ident.Name, _ = claims["name"].(string)
+	if pic, _ := claims["picture"].(string); pic != "" {
+		ident.Picture = &pic
+	}
+
+	// ba'zi provayderlar email/ismni faqat userinfo'da beradi
+	if (ident.Email == "" || ident.Name == "") && ep.UserInfoURL != "" {
+		var info struct {
+			Sub           string      `json:"sub"`
+			Email         string      `json:"email"`
+			EmailVerified interface{} `json:"email_verified"`
+			Name          string      `json:"name"`
+		}
+		if err := getJSON(ctx, p.client, ep.UserInfoURL, tok.AccessToken, &info); err == nil && info.Sub == ident.Subject {
+			if ident.Email == "" {
+				ident.Email, ident.EmailVerified = info.Email, claimBool(info.EmailVerified)
+			}
+			ident.Name = firstNonEmpty(ident.Name, info.Name)
+		}
+	}
+
+	if ident.Subject == "" {
+		return nil, fmt.Errorf("%w: sub missing", ErrInvalidIDToken)
+	}
+	ident.Name = firstNonEmpty(ident.Name, ident.Email)
+	return ident, nil
+}
+
+func (p *oidcProvider) verifyIDToken(ctx context.Context, rawID, nonce string) (gojwt.MapClaims, error) {
+	token, err := gojwt.Parse(rawID, func(t *gojwt.Token) (interface{}, error) {
+		// faqat asimmetrik algoritmlar: HS*/none rad etiladi
+		switch t.Method.(type) {
+		case *gojwt.SigningMethodRSA, *gojwt.SigningMethodRSAPSS, *gojwt.SigningMethodECDSA, *gojwt.SigningMethodEd25519:
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	claims, ok := token.Claims.(gojwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	}
+	if !verifyAnyIssuer(claims, p.issuers) {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	}
+	if !verifyAnyAudience(claims, p.audiences) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// ---------------- Facebook ----------------
+
+// facebookProvider - Facebook Login (OIDC emas): foydalanuvchi Graph API'dan olinadi.
+type facebookProvider struct {
+	oauthConfig *oauth2.Config
+	userInfoURL string
+	client      *http.Client
+}
+
+func newFacebookProvider(cfg config.OAuthProviderConfig) *facebookProvider {
+	endpoint := facebook.Endpoint
+	if cfg.AuthURL != "" {
+		endpoint.AuthURL = cfg.AuthURL
+	}
+	if cfg.TokenURL != "" {
+		endpoint.TokenURL = cfg.TokenURL
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"email", "public_profile"}
+	}
+	return &facebookProvider{
+		oauthConfig: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       scopes,
+			Endpoint:     endpoint,
+		},
+		userInfoURL: firstNonEmpty(cfg.UserInfoURL, "https://graph.facebook.com/me?fields=id,name,email,picture.type(large)"),
+		client:      &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *facebookProvider) Name() string { return "facebook" }
+
+func (p *facebookProvider) AuthCodeURL(_ context.Context, state, verifier, _ string) (string, error) {
+	return p.oauthConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *facebookProvider) Exchange(ctx context.Context, code, verifier, _ string) (*models.ExternalIdentity, error) {
+	tok, err := p.oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthExchange, err)
+	}
+
+	var me struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Email   string `json:"email"`
+		Picture struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		} `json:"picture"`
+	}
+	if err := getJSON(ctx, p.client, p.userInfoURL, tok.AccessToken, &me); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthExchange, err)
+	}
+	if me.ID == "" {
+		return nil, fmt.Errorf("%w: facebook id missing", ErrOAuthExchange)
+	}
+
+	// Facebook faqat tasdiqlangan emailni qaytaradi; mavjud akkauntga bog‘lash baribir autoLinkUserID shartidan o‘tadi
+	ident := &models.ExternalIdentity{
+		Provider:      p.Name(),
+		Subject:       me.ID,
+		Email:         me.Email,
+		EmailVerified: me.Email != "",
+		Name:          firstNonEmpty(me.Name, me.Email),
+	}
+	if me.Picture.Data.URL != "" {
+		pic := me.Picture.Data.URL
+		ident.Picture = &pic
+	}
+	return ident, nil
+}
+
+// ---------------- helpers ----------------
+
+func getJSON(ctx context.Context, client *http.Client, rawURL, bearer string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/oauth2"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/security"
+	"speakpall/storage"
+)
+
+var (
+	ErrOAuthProviderUnknown  = errors.New("unknown oauth provider")
+	ErrOAuthStateInvalid     = errors.New("invalid or expired oauth state")
+	ErrOAuthEmailRequired    = errors.New("provider did not return a verified email")
+	ErrIdentityAlreadyLinked = errors.New("this provider account is linked to another user")
+	ErrProviderAlreadyLinked = errors.New("a different account of this provider is already linked")
+	ErrIdentityNotLinked     = errors.New("provider is not linked")
+	ErrOAuthLinkMismatch     = errors.New("provider linking must be completed by the account that started it")
+)
+
+const oauthStatePrefix = "oauth_state:"
+
+// oauthState - Redis'da state kaliti ostida saqlanadi (bir martalik).
+type oauthState struct {
+	Provider   string `json:"provider"`
+	Verifier   string `json:"verifier"`
+	Nonce      string `json:"nonce"`
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// OAuthResult - callback natijasi: login (token beriladi) yoki mavjud akkauntga bog‘lash.
+type OAuthResult struct {
+	UserID string
+	Linked bool
+}
+
+type OAuthService interface {
+	Providers() []string
+	// Start linkUserID bo‘sh bo‘lmasa, callback identity'ni shu userga bog‘laydi
+	Start(ctx context.Context, provider, linkUserID string) (models.OAuthStartResponse, error)
+	// Callback callerUserID — so‘rovni yuborgan (JWT) user; bog‘lash state'i faqat uni boshlagan user bilan yakunlanadi
+	Callback(ctx context.Context, provider, code, state, callerUserID string) (OAuthResult, error)
+
+	ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error)
+	Unlink(ctx context.Context, userID, provider string) error
+}
+
+type oauthService struct {
+	registry *OAuthRegistry
+	userStg  storage.IUserStorage
+	identStg storage.IIdentityStorage
+	redis    storage.IRedisStorage
+	log      logger.ILogger
+}
+
+func NewOAuthService(stg storage.IStorage, redis storage.IRedisStorage, log logger.ILogger, registry *OAuthRegistry) OAuthService {
+	return &oauthService{
+		registry: registry,
+		userStg:  stg.User(),
+		identStg: stg.Identity(),
+		redis:    redis,
+		log:      log,
+	}
+}
+
+func (s *oauthService) Providers() []string {
+	return s.registry.Names()
+}
+
+func (s *oauthService) Start(ctx context.Context, provider, linkUserID string) (models.OAuthStartResponse, error) {
+	p, ok := s.registry.Get(provider)
+	if !ok {
+		return models.OAuthStartResponse{}, ErrOAuthProviderUnknown
+	}
+
+	state, err := security.GenerateToken(32)
+	if err != nil {
+		return models.OAuthStartResponse{}, err
+	}
+	nonce, err := security.GenerateToken(16)
+	if err != nil {
+		return models.OAuthStartResponse{}, err
+	}
+	st := oauthState{
+		Provider:   p.Name(),
+		Verifier:   oauth2.GenerateVerifier(),
+		Nonce:      nonce,
+		LinkUserID: linkUserID,
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, st.Verifier, st.Nonce)
+	if err != nil {
+		s.log.Error("oauth auth url failed", logger.Error(err), logger.String("provider", p.Name()))
+		return models.OAuthStartResponse{}, err
+	}
+
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return models.OAuthStartResponse{}, err
+	}
+	if err := s.redis.SetX(ctx, oauthStatePrefix+state, string(raw), config.OAuthStateExpireTime); err != nil {
+		return models.OAuthStartResponse{}, err
+	}
+
+	return models.OAuthStartResponse{AuthURL: authURL, State: state}, nil
+}
+
+// Callback: state bir martalik (GETDEL), provayder mos kelishi shart.
+// Qidiruv tartibi: (provider, subject) -> tasdiqlangan email -> yangi parolsiz user.
+func (s *oauthService) Callback(ctx context.Context, provider, code, state, callerUserID string) (OAuthResult, error) {
+	p, ok := s.registry.Get(provider)
+	if !ok {
+		return OAuthResult{}, ErrOAuthProviderUnknown
+	}
+
+	raw, err := s.redis.GetDel(ctx, oauthStatePrefix+state)
+	if err != nil || raw == "" {
+		return OAuthResult{}, ErrOAuthStateInvalid
+	}
+	var st oauthState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil || st.Provider != p.Name() {
+		return OAuthResult{}, ErrOAuthStateInvalid
+	}
+	// bog‘lash havolasi boshqa odamga yuborilsa, uning provayder akkaunti hujumchiga bog‘lanib qolmasin
+	if st.LinkUserID != callerUserID {
+		s.log.Warning("oauth state user mismatch", logger.String("provider", p.Name()), logger.String("link_user_id", st.LinkUserID))
+		return OAuthResult{}, ErrOAuthLinkMismatch
+	}
+
+	ident, err := p.Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		s.log.Error("oauth exchange failed", logger.Error(err), logger.String("provider", p.Name()))
+		return OAuthResult{}, err
+	}
+	ident.Email = normalizeEmail(ident.Email)
+
+	existing, err := s.identStg.GetByProviderSubject(ctx, ident.Provider, ident.Subject)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return OAuthResult{}, err
+	}
+
+	// 1) tizimga kirgan user o‘z akkauntiga bog‘layapti
+	if st.LinkUserID != "" {
+		if existing != nil {
+			if existing.UserID != st.LinkUserID {
+				return OAuthResult{}, ErrIdentityAlreadyLinked
+			}
+			return OAuthResult{UserID: st.LinkUserID, Linked: true}, nil
+		}
+		if err := s.link(ctx, st.LinkUserID, ident); err != nil {
+			return OAuthResult{}, err
+		}
+		return OAuthResult{UserID: st.LinkUserID, Linked: true}, nil
+	}
+
+	// 2) avval bog‘langan identity
+	if existing != nil {
+		if err := s.identStg.TouchLogin(ctx, existing.ID); err != nil {
+			s.log.Error("identity touch failed", logger.Error(err), logger.String("identity_id", existing.ID))
+		}
+		return OAuthResult{UserID: existing.UserID}, nil
+	}
+
+	// tasdiqlanmagan email bilan mavjud akkauntga bog‘lanish yoki yangi akkaunt ochish mumkin emas
+	if ident.Email == "" || !ident.EmailVerified {
+		return OAuthResult{}, ErrOAuthEmailRequired
+	}
+
+	// 3) email bo‘yicha mavjud akkaunt — faqat u emailni tasdiqlagan bo‘lsa (GoogleAuth bilan bir xil qoida)
+	userID, err := autoLinkUserID(ctx, s.userStg, ident.Email)
+	if err != nil {
+		return OAuthResult{}, err
+	}
+	if userID != "" {
+		if err := s.link(ctx, userID, ident); err != nil {
+			return OAuthResult{}, err
+		}
+		return OAuthResult{UserID: userID}, nil
+	}
+
+	// 4) yangi user (parolsiz)
+	userID, err = s.userStg.CreateExternalUser(ctx, *ident)
+	if err != nil {
+		return OAuthResult{}, err
+	}
+	if err := s.link(ctx, userID, ident); err != nil {
+		return OAuthResult{}, err
+	}
+	return OAuthResult{UserID: userID}, nil
+}
+
+func (s *oauthService) link(ctx context.Context, userID string, ident *models.ExternalIdentity) error {
+	s.log.Info("OAuthService.link", logger.String("user_id", userID), logger.String("provider", ident.Provider))
+	var email *string
+	if ident.Email != "" {
+		email = &ident.Email
+	}
+	if err := s.identStg.Create(ctx, models.UserIdentity{
+		UserID:   userID,
+		Provider: ident.Provider,
+		Subject:  ident.Subject,
+		Email:    email,
+	}); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			// (provider, subject) boshqa userda yoki userda shu provayderning boshqa akkaunti bor
+			if other, gerr := s.identStg.GetByProviderSubject(ctx, ident.Provider, ident.Subject); gerr == nil && other.UserID != userID {
+				return ErrIdentityAlreadyLinked
+			}
+			return ErrProviderAlreadyLinked
+		}
+		return err
+	}
+
+	if ident.Picture != nil && *ident.Picture != "" {
+		if err := s.userStg.SetAvatarIfEmpty(ctx, userID, *ident.Picture); err != nil {
+			s.log.Error("set identity avatar failed", logger.Error(err), logger.String("user_id", userID))
+		}
+	}
+	return nil
+}
+
+func (s *oauthService) ListIdentities(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	return s.identStg.ListByUser(ctx, userID)
+}
+
+// Unlink: parol, boshqa provayder yoki passkey qolmasa rad etiladi — aks holda user akkauntga kira olmay qoladi.
+func (s *oauthService) Unlink(ctx context.Context, userID, provider string) error {
+	s.log.Info("OAuthService.Unlink", logger.String("user_id", userID), logger.String("provider", provider))
+	err := s.identStg.Unlink(ctx, userID, provider)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrIdentityNotLinked
+	case errors.Is(err, storage.ErrLastLoginMethod):
+		return ErrNoPasswordSet
+	}
+	return err
+}
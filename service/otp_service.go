@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	generateotpcode "speakpall/pkg/generateOTPCode"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/mailer"
+	"speakpall/pkg/security"
+	"speakpall/storage"
+)
+
+var (
+	ErrOTPInvalid         = errors.New("invalid or expired code")
+	ErrOTPTooManyAttempts = errors.New("too many attempts, request a new code")
+	ErrOTPCooldown        = errors.New("a code was sent recently, try again later")
+)
+
+// mailSendTimeout - so‘rovdan tashqarida (fon) token/kod yaratish va email yuborish uchun
+const mailSendTimeout = 30 * time.Second
+
+type OTPService interface {
+	SendCode(ctx context.Context, email, purpose string) error
+	VerifyCode(ctx context.Context, email, purpose, code string) error
+
+	RequestLoginCode(ctx context.Context, email string) error
+	VerifyLoginCode(ctx context.Context, email, code string) (models.LoginUser, error)
+}
+
+type otpService struct {
+	stg        storage.IAuthEmailTokenStorage
+	userStg    storage.IUserStorage
+	log        logger.ILogger
+	mailerCore *mailer.Mailer
+}
+
+func NewOTPService(stg storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer) OTPService {
+	return &otpService{
+		stg:        stg.AuthEmailToken(),
+		userStg:    stg.User(),
+		log:        log,
+		mailerCore: mailerCore,
+	}
+}
+
+// SendCode: yangi kod yaratadi, eskilarini bekor qiladi, hashini saqlaydi va emailga yuboradi.
+func (s *otpService) SendCode(ctx context.Context, email, purpose string) error {
+	email = normalizeEmail(email)
+	s.log.Info("OTPService.SendCode", logger.String("purpose", purpose))
+
+	// qayta yuborish oralig‘i (cooldown)
+	last, err := s.stg.GetLatestActive(ctx, email, purpose, time.Now())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if last != nil && time.Since(last.CreatedAt) < config.OTPResendDelay {
+		return ErrOTPCooldown
+	}
+
+	code, err := generateotpcode.GenerateOTPCode(config.OTPCodeLength)
+	if err != nil {
+		return errors.New("failed to generate code")
+	}
+
+	// bir vaqtda faqat oxirgi kod amal qiladi
+	if err := s.stg.InvalidateActive(ctx, email, purpose); err != nil {
+		return err
+	}
+	if _, err := s.stg.Create(ctx, email, security.HashToken(code), purpose, time.Now().Add(config.OTPExpireTime)); err != nil {
+		return err
+	}
+
+	subject, body := otpMail(purpose, code)
+	if err := s.mailerCore.Send(email, subject, body); err != nil {
+		s.log.Error("otp mail send failed", logger.Error(err))
+		return errors.New("failed to send code")
+	}
+	return nil
+}
+
+// VerifyCode: bir martalik, urinishlar soni cheklangan va muddati tekshiriladi.
+func (s *otpService) VerifyCode(ctx context.Context, email, purpose, code string) error {
+	email = normalizeEmail(email)
+
+	t, err := s.stg.GetLatestActive(ctx, email, purpose, time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrOTPInvalid
+		}
+		return err
+	}
+
+	if t.Attempts >= config.OTPMaxAttempts {
+		_, _ = s.stg.MarkUsed(ctx, t.ID)
+		return ErrOTPTooManyAttempts
+	}
+
+	if !security.CompareTokenHash(t.CodeHash, code) {
+		attempts, err := s.stg.IncrementAttempts(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		if attempts >= config.OTPMaxAttempts {
+			_, _ = s.stg.MarkUsed(ctx, t.ID)
+			return ErrOTPTooManyAttempts
+		}
+		return ErrOTPInvalid
+	}
+
+	ok, err := s.stg.MarkUsed(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// parallel so‘rov kodni allaqachon ishlatib bo‘ldi
+		return ErrOTPInvalid
+	}
+	return nil
+}
+
+// RequestLoginCode: email mavjud bo‘lmasa ham bir xil javob qaytariladi (enumeration'dan himoya).
+// Kod fonda yuboriladi — javob vaqti ham SMTP'ga bog‘liq bo‘lmaydi; xatolar faqat log qilinadi.
+func (s *otpService) RequestLoginCode(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	u, err := s.userStg.GetLoginByEmail(ctx, email)
+	if err != nil || u.ID == "" {
+		s.log.Info("OTPService.RequestLoginCode: unknown email, skipping")
+		return nil
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.SendCode(ctx, email, models.OTPPurposeLogin); err != nil && !errors.Is(err, ErrOTPCooldown) {
+			s.log.Error("login code not sent", logger.Error(err), logger.String("user_id", u.ID))
+		}
+	}()
+	return nil
+}
+
+func (s *otpService) VerifyLoginCode(ctx context.Context, email, code string) (models.LoginUser, error) {
+	if err := s.VerifyCode(ctx, email, models.OTPPurposeLogin, code); err != nil {
+		return models.LoginUser{}, err
+	}
+	u, err := s.userStg.GetLoginByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		return models.LoginUser{}, ErrOTPInvalid
+	}
+	return u, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func otpMail(purpose, code string) (string, string) {
+	var subject, intro string
+	switch purpose {
+	case models.OTPPurposeVerify:
+		subject, intro = "Verify your email", "Use this code to verify your email address:"
+	case models.OTPPurposeChangeEmail:
+		subject, intro = "Confirm your new email", "Use this code to confirm your new email address:"
+	case models.OTPPurposeDeleteAccount:
+		subject, intro = "Confirm account deletion", "Use this code to confirm deleting your account:"
+	default:
+		subject, intro = "Your login code", "Use this code to sign in:"
+	}
+
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en"><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><title>%s</title></head>
+<body style="font-family:Arial,sans-serif;background:#f4f4f4;margin:0;padding:24px">
+  <div style="max-width:600px;margin:0 auto;background:#fff;padding:24px;border-radius:8px">
+    <h2 style="margin:0 0 12px">%s</h2>
+    <p style="margin:0 0 16px">%s</p>
+    <p style="font-size:28px;letter-spacing:6px;font-weight:bold;margin:0 0 16px">%s</p>
+    <p style="color:#888;margin:16px 0 0">The code expires in %d minutes. If you didn’t request it, just ignore this email.</p>
+  </div>
+</body></html>`, subject, subject, intro, code, int(config.OTPExpireTime.Minutes()))
+
+	return subject, body
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/profileutil"
+	"speakpall/storage"
+)
+
+var ErrInvalidPartnerFilter = errors.New("invalid partner filter")
+
+const (
+	partnerDefaultLimit = 20
+	// partnerPoolSize - compatibility saralashda baholanadigan eng faol nomzodlar soni
+	partnerPoolSize = 500
+)
+
+type PartnerService interface {
+	// Browse chaqiruvchining match_preferences'i standart filtr sifatida qo‘llanadi (IgnorePrefs=false)
+	Browse(ctx context.Context, userID string, f models.PartnerFilter) (models.PartnersResponse, error)
+}
+
+type partnerService struct {
+	stg     storage.IPartnerStorage
+	matches storage.IMatchAttemptStorage
+	scorer  *MatchScorer
+	log     logger.ILogger
+}
+
+func NewPartnerService(stg storage.IStorage, log logger.ILogger, cfg config.Config) PartnerService {
+	return &partnerService{
+		stg:     stg.Partner(),
+		matches: stg.MatchAttempt(),
+		scorer:  NewMatchScorer(cfg.MatchWeights, cfg.MatchTandemFallback),
+		log:     log,
+	}
+}
+
+func (s *partnerService) Browse(ctx context.Context, userID string, f models.PartnerFilter) (models.PartnersResponse, error) {
+	s.log.Info("PartnerService.Browse", logger.String("user_id", userID))
+
+	if err := profileutil.ValidateLevelRange(f.MinLevel, f.MaxLevel); err != nil {
+		return models.PartnersResponse{}, fmt.Errorf("%w: %v", ErrInvalidPartnerFilter, err)
+	}
+	if f.Sort == "" {
+		f.Sort = models.PartnerSortCompatibility
+	}
+	if f.Limit <= 0 {
+		f.Limit = partnerDefaultLimit
+	}
+	cur, err := decodePartnerCursor(f.Cursor, f.Sort)
+	if err != nil {
+		return models.PartnersResponse{}, err
+	}
+
+	// chaqiruvchi profili: preferences va compatibility uchun
+	me, err := s.matches.LoadCandidate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PartnersResponse{}, ErrAccountDeleted
+		}
+		return models.PartnersResponse{}, err
+	}
+
+	q := models.PartnerSearch{
+		ViewerID:   userID,
+		NativeLang: f.NativeLang,
+		TargetLang: f.TargetLang,
+		MinLevel:   f.MinLevel,
+		MaxLevel:   f.MaxLevel,
+		Countries:  profileutil.NormalizeCountries(f.Countries),
+		Interests:  profileutil.DedupInts(f.Interests),
+		// yosh chegarasi preferences emas, IgnorePrefs uni o‘chirmaydi
+		AdultAge:   adultAge,
+		MinorsOnly: isMinor(*me),
+	}
+	if !f.IgnorePrefs {
+		if q.NativeLang == "" && q.TargetLang == "" {
+			q.AnyLang = me.Language
+		}
+		if q.MinLevel == nil && q.MaxLevel == nil {
+			q.MinLevel, q.MaxLevel = me.MinLevel, me.MaxLevel
+		}
+		if me.GenderFilter != "any" {
+			q.Gender = me.GenderFilter
+		}
+		if len(q.Countries) == 0 {
+			q.Countries = me.Countries
+		}
+		q.MinRating = me.MinRating
+	}
+	now := time.Now()
+	if f.OnlineOnly {
+		since := now.Add(-config.OnlineWindow)
+		q.OnlineSince = &since
+	}
+
+	if f.Sort == models.PartnerSortLastSeen {
+		return s.browseByLastSeen(ctx, q, *me, cur, f.Limit, now)
+	}
+	return s.browseByCompatibility(ctx, q, *me, cur, f.Limit, now)
+}
+
+// browseByLastSeen - to‘liq keyset pagination (last_seen, id) DB'da.
+func (s *partnerService) browseByLastSeen(ctx context.Context, q models.PartnerSearch, me models.MatchCandidate, cur *partnerCursor, limit int, now time.Time) (models.PartnersResponse, error) {
+	if cur != nil {
+		seen := time.UnixMicro(cur.seen).UTC()
+		q.AfterSeen, q.AfterID = &seen, cur.id
+	}
+	q.Limit = limit + 1
+	list, err := s.stg.Search(ctx, q)
+	if err != nil {
+		return models.PartnersResponse{}, err
+	}
+
+	resp := models.PartnersResponse{Partners: list}
+	if len(list) > limit {
+		resp.Partners = list[:limit]
+		last := resp.Partners[limit-1]
+		seen := time.Unix(0, 0)
+		if last.LastSeen != nil {
+			seen = *last.LastSeen
+		}
+		next := encodePartnerCursor(partnerCursor{sort: models.PartnerSortLastSeen, seen: seen.UnixMicro(), id: last.ID})
+		resp.NextCursor = &next
+	}
+	s.decorate(resp.Partners, me, now)
+	return resp, nil
+}
+
+// browseByCompatibility - eng faol partnerPoolSize nomzod baholanadi va (ball, id) bo‘yicha saralanadi;
+// kursor (ball, id) juftligi, shuning uchun sahifalar orasida takrorlanish bo‘lmaydi.
+func (s *partnerService) browseByCompatibility(ctx context.Context, q models.PartnerSearch, me models.MatchCandidate, cur *partnerCursor, limit int, now time.Time) (models.PartnersResponse, error) {
+	q.Limit = partnerPoolSize
+	list, err := s.stg.Search(ctx, q)
+	if err != nil {
+		return models.PartnersResponse{}, err
+	}
+	s.decorate(list, me, now)
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Compatibility != list[j].Compatibility {
+			return list[i].Compatibility > list[j].Compatibility
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	start := 0
+	if cur != nil {
+		for start < len(list) {
+			p := list[start]
+			if p.Compatibility < cur.score || (p.Compatibility == cur.score && p.ID > cur.id) {
+				break
+			}
+			start++
+		}
+	}
+	page := list[start:]
+	resp := models.PartnersResponse{Partners: page}
+	if len(page) > limit {
+		resp.Partners = page[:limit]
+		last := resp.Partners[limit-1]
+		next := encodePartnerCursor(partnerCursor{sort: models.PartnerSortCompatibility, score: last.Compatibility, id: last.ID})
+		resp.NextCursor = &next
+	}
+	return resp, nil
+}
+
+// decorate - online belgisi va chaqiruvchiga nisbatan compatibility bali.
+func (s *partnerService) decorate(list []models.Partner, me models.MatchCandidate, now time.Time) {
+	for i := range list {
+		p := &list[i]
+		p.Online = p.LastSeen != nil && now.Sub(*p.LastSeen) <= config.OnlineWindow
+		p.Compatibility = s.scorer.Breakdown(me, partnerCandidate(*p)).Total
+	}
+}
+
+func partnerCandidate(p models.Partner) models.MatchCandidate {
+	c := models.MatchCandidate{
+		UserID:     p.ID,
+		Language:   deref(p.TargetLang),
+		NativeLang: deref(p.NativeLang),
+		Gender:     deref(p.Gender),
+		Country:    deref(p.CountryCode),
+		Timezone:   deref(p.Timezone),
+		Age:        p.Age,
+		Rating:     p.Rating,
+		Interests:  p.Interests,
+	}
+	if p.Level != nil {
+		c.Level = *p.Level
+	}
+	return c
+}
+
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// partnerCursor - "<sort>|<qiymat>|<id>" base64url ko‘rinishida; qiymat last_seen (unix mikrosekund) yoki ball.
+type partnerCursor struct {
+	sort  string
+	seen  int64
+	score float64
+	id    string
+}
+
+func encodePartnerCursor(c partnerCursor) string {
+	v := strconv.FormatInt(c.seen, 10)
+	if c.sort == models.PartnerSortCompatibility {
+		v = strconv.FormatFloat(c.score, 'f', -1, 64)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(c.sort + "|" + v + "|" + c.id))
+}
+
+func decodePartnerCursor(raw, sortBy string) (*partnerCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidPartnerFilter)
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	parts := strings.SplitN(string(b), "|", 3)
+	if len(parts) != 3 || parts[0] != sortBy {
+		return nil, invalid
+	}
+	if _, err := uuid.Parse(parts[2]); err != nil {
+		return nil, invalid
+	}
+	c := &partnerCursor{sort: parts[0], id: parts[2]}
+	if sortBy == models.PartnerSortCompatibility {
+		c.score, err = strconv.ParseFloat(parts[1], 64)
+	} else {
+		c.seen, err = strconv.ParseInt(parts[1], 10, 64)
+	}
+	if err != nil {
+		return nil, invalid
+	}
+	return c, nil
+}
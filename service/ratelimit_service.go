@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"speakpall/config"
+	"speakpall/pkg/logger"
+)
+
+const (
+	rateLimitPrefix      = "rl:"
+	loginFailPrefix      = "login_fail:"
+	loginLockPrefix      = "login_lock:"
+	loginLockoutPrefix   = "login_lockouts:"
+	loginEmailFailPrefix = "login_email_fail:"
+	loginEmailLockPrefix = "login_email_lock:"
+
+	// lockout darajasi shu oyna ichidagi lockoutlar soni bo‘yicha o‘sadi
+	loginLockoutMemory = 24 * time.Hour
+	loginLockoutLimit  = 1000
+)
+
+type RateLimitService interface {
+	// Allow rule — config.RateLimits dagi qoida nomi, key — IP, email yoki user ID
+	Allow(ctx context.Context, rule, key string) (RateLimitResult, error)
+
+	// LoginLockedFor (email, IP) juftligi yoki butun email qulflangan bo‘lsa qolgan muddat
+	LoginLockedFor(ctx context.Context, email, ip string) (time.Duration, error)
+	// LoginFailed xatoni qayd qiladi; limitdan oshsa lockout muddatini qaytaradi
+	LoginFailed(ctx context.Context, email, ip string) (time.Duration, error)
+	LoginSucceeded(ctx context.Context, email, ip string)
+}
+
+type rateLimitService struct {
+	limiter RateLimiter
+	rules   map[string]config.RateLimitRule
+
+	maxFailures      int
+	emailMaxFailures int
+	failureWindow    time.Duration
+	lockoutBase      time.Duration
+	lockoutMax       time.Duration
+
+	log logger.ILogger
+}
+
+func NewRateLimitService(limiter RateLimiter, cfg config.Config, log logger.ILogger) RateLimitService {
+	return &rateLimitService{
+		limiter:          limiter,
+		rules:            cfg.RateLimits,
+		maxFailures:      cfg.LoginMaxFailures,
+		emailMaxFailures: cfg.LoginEmailMaxFailures,
+		failureWindow:    cfg.LoginFailureWindow,
+		lockoutBase:      cfg.LoginLockoutBase,
+		lockoutMax:       cfg.LoginLockoutMax,
+		log:              log,
+	}
+}
+
+func (s *rateLimitService) Allow(ctx context.Context, rule, key string) (RateLimitResult, error) {
+	r, ok := s.rules[rule]
+	if !ok || r.Limit <= 0 || key == "" {
+		return RateLimitResult{Allowed: true}, nil
+	}
+	return s.limiter.Allow(ctx, rateLimitPrefix+rule+":"+key, r.Limit, r.Window)
+}
+
+// loginSubject - lockout kaliti: faqat email bo‘lsa, begona odam istalgan akkauntni cheksiz qulflab qo‘ya oladi.
+func loginSubject(email, ip string) string {
+	return normalizeEmail(email) + "|" + ip
+}
+
+func (s *rateLimitService) LoginLockedFor(ctx context.Context, email, ip string) (time.Duration, error) {
+	left, err := s.limiter.LockedFor(ctx, loginLockPrefix+loginSubject(email, ip))
+	if err != nil {
+		return 0, err
+	}
+	emailLeft, err := s.limiter.LockedFor(ctx, loginEmailLockPrefix+normalizeEmail(email))
+	if err != nil {
+		return 0, err
+	}
+	return max(left, emailLeft), nil
+}
+
+// LoginFailed: (email, IP) juftligi maxFailures ta xatodan keyin lockoutBase, keyingi har lockoutda ikki baravar
+// (lockoutMax gacha). Taqsimlangan hujum uchun email bo‘yicha alohida, ancha yuqori chegara bor.
+func (s *rateLimitService) LoginFailed(ctx context.Context, email, ip string) (time.Duration, error) {
+	emailLock, err := s.emailFailed(ctx, normalizeEmail(email))
+	if err != nil {
+		return 0, err
+	}
+	if s.maxFailures <= 0 {
+		return emailLock, nil
+	}
+	subject := loginSubject(email, ip)
+
+	res, err := s.limiter.Allow(ctx, loginFailPrefix+subject, s.maxFailures, s.failureWindow)
+	if err != nil {
+		return 0, err
+	}
+	if res.Allowed && res.Count < s.maxFailures {
+		return emailLock, nil
+	}
+
+	lockouts, err := s.limiter.Allow(ctx, loginLockoutPrefix+subject, loginLockoutLimit, loginLockoutMemory)
+	if err != nil {
+		return 0, err
+	}
+	level := lockouts.Count - 1 // oldingi lockoutlar soni
+
+	d := s.lockoutBase
+	for i := 0; i < level && d < s.lockoutMax; i++ {
+		d *= 2
+	}
+	if d > s.lockoutMax {
+		d = s.lockoutMax
+	}
+
+	s.log.Warning("login locked out", logger.String("email", normalizeEmail(email)), logger.String("ip", ip), logger.Int("level", level), logger.String("duration", d.String()))
+	if err := s.limiter.Lock(ctx, loginLockPrefix+subject, d); err != nil {
+		return 0, err
+	}
+	if err := s.limiter.Reset(ctx, loginFailPrefix+subject); err != nil {
+		s.log.Error("reset login failures failed", logger.Error(err))
+	}
+	return max(d, emailLock), nil
+}
+
+// emailFailed - barcha IP'lar bo‘yicha umumiy chegara; oshsa email failureWindow ga qulflanadi.
+func (s *rateLimitService) emailFailed(ctx context.Context, email string) (time.Duration, error) {
+	if s.emailMaxFailures <= 0 {
+		return 0, nil
+	}
+	res, err := s.limiter.Allow(ctx, loginEmailFailPrefix+email, s.emailMaxFailures, s.failureWindow)
+	if err != nil {
+		return 0, err
+	}
+	if res.Allowed && res.Count < s.emailMaxFailures {
+		return 0, nil
+	}
+
+	s.log.Warning("login locked out for all addresses", logger.String("email", email), logger.String("duration", s.failureWindow.String()))
+	if err := s.limiter.Lock(ctx, loginEmailLockPrefix+email, s.failureWindow); err != nil {
+		return 0, err
+	}
+	if err := s.limiter.Reset(ctx, loginEmailFailPrefix+email); err != nil {
+		s.log.Error("reset login failures failed", logger.Error(err))
+	}
+	return s.failureWindow, nil
+}
+
+// LoginSucceeded faqat shu (email, IP) hisoblagichini tozalaydi: email bo‘yicha umumiy hisob o‘z oynasida eskiradi.
+func (s *rateLimitService) LoginSucceeded(ctx context.Context, email, ip string) {
+	if err := s.limiter.Reset(ctx, loginFailPrefix+loginSubject(email, ip)); err != nil {
+		s.log.Error("reset login failures failed", logger.Error(err))
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"speakpall/storage"
+)
+
+type RateLimitResult struct {
+	Allowed    bool
+	Count      int // oynadagi hodisalar soni (joriy bilan)
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimiter - sliding-window hisoblagich va lock kalitlari.
+// Redis implementatsiyasi bir nechta instansiya uchun, xotiradagisi testlar va bitta instansiya uchun.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor lock qolgan vaqtini qaytaradi (lock yo‘q bo‘lsa 0)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// ---------------- Redis ----------------
+
+type redisRateLimiter struct {
+	redis storage.IRedisStorage
+}
+
+func NewRedisRateLimiter(redis storage.IRedisStorage) RateLimiter {
+	return &redisRateLimiter{redis: redis}
+}
+
+func (l *redisRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	allowed, count, retry, err := l.redis.SlidingWindow(ctx, key, limit, window)
+	if err != nil {
+		return RateLimitResult{Allowed: true}, err
+	}
+	return RateLimitResult{Allowed: allowed, Count: count, Remaining: max(limit-count, 0), RetryAfter: retry}, nil
+}
+
+func (l *redisRateLimiter) Lock(ctx context.Context, key string, d time.Duration) error {
+	return l.redis.SetX(ctx, key, 1, d)
+}
+
+func (l *redisRateLimiter) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := l.redis.TTL(ctx, key)
+	if err != nil || ttl < 0 {
+		// -2: kalit yo‘q, -1: muddatsiz (lock har doim muddatli)
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (l *redisRateLimiter) Reset(ctx context.Context, key string) error {
+	return l.redis.Delete(ctx, key)
+}
+
+// ---------------- xotira ----------------
+
+type memoryRateLimiter struct {
+	mu    sync.Mutex
+	hits  map[string][]time.Time
+	locks map[string]time.Time
+	now   func() time.Time
+}
+
+// NewMemoryRateLimiter - Redis'siz ishlaydi (testlar, lokal ishga tushirish).
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{
+		hits:  map[string][]time.Time{},
+		locks: map[string]time.Time{},
+		now:   time.Now,
+	}
+}
+
+func (l *memoryRateLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	hits := l.hits[key]
+	i := 0
+	for i < len(hits) && !hits[i].After(now.Add(-window)) {
+		i++
+	}
+	hits = hits[i:]
+
+	if len(hits) >= limit {
+		l.hits[key] = hits
+		return RateLimitResult{Count: len(hits), RetryAfter: hits[0].Add(window).Sub(now)}, nil
+	}
+	l.hits[key] = append(hits, now)
+	return RateLimitResult{Allowed: true, Count: len(hits) + 1, Remaining: limit - len(hits) - 1}, nil
+}
+
+func (l *memoryRateLimiter) Lock(_ context.Context, key string, d time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.locks[key] = l.now().Add(d)
+	return nil
+}
+
+func (l *memoryRateLimiter) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	left := until.Sub(l.now())
+	if left <= 0 {
+		delete(l.locks, key)
+		return 0, nil
+	}
+	return left, nil
+}
+
+func (l *memoryRateLimiter) Reset(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.hits, key)
+	delete(l.locks, key)
+	return nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"speakpall/config"
+	"speakpall/pkg/logger"
+)
+
+// fakeClock - memoryRateLimiter.now uchun qo‘lda suriladigan vaqt
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*memoryRateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := NewMemoryRateLimiter().(*memoryRateLimiter)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestMemoryRateLimiterSlidingWindow(t *testing.T) {
+	const limit, window = 3, time.Minute
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		wantAllowed bool
+		wantCount   int
+		wantRetry   time.Duration
+	}{
+		{name: "first", wantAllowed: true, wantCount: 1},
+		{name: "second", advance: 10 * time.Second, wantAllowed: true, wantCount: 2},
+		{name: "third", advance: 10 * time.Second, wantAllowed: true, wantCount: 3},
+		{name: "over limit", advance: 10 * time.Second, wantCount: 3, wantRetry: 30 * time.Second},
+		{name: "still full", advance: 29 * time.Second, wantCount: 3, wantRetry: time.Second},
+		{name: "oldest slid out", advance: time.Second, wantAllowed: true, wantCount: 3},
+		{name: "window cleared", advance: 2 * time.Minute, wantAllowed: true, wantCount: 1},
+	}
+
+	l, clock := newTestLimiter()
+	ctx := context.Background()
+	for _, st := range steps {
+		clock.advance(st.advance)
+		res, err := l.Allow(ctx, "k", limit, window)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", st.name, err)
+		}
+		if res.Allowed != st.wantAllowed || res.Count != st.wantCount || res.RetryAfter != st.wantRetry {
+			t.Errorf("%s: got allowed=%v count=%d retry=%s, want allowed=%v count=%d retry=%s",
+				st.name, res.Allowed, res.Count, res.RetryAfter, st.wantAllowed, st.wantCount, st.wantRetry)
+		}
+		if res.Allowed && res.Remaining != limit-res.Count {
+			t.Errorf("%s: remaining %d, want %d", st.name, res.Remaining, limit-res.Count)
+		}
+	}
+}
+
+func TestMemoryRateLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter()
+	ctx := context.Background()
+	if res, _ := l.Allow(ctx, "a", 1, time.Minute); !res.Allowed {
+		t.Fatal("first hit on a must be allowed")
+	}
+	if res, _ := l.Allow(ctx, "a", 1, time.Minute); res.Allowed {
+		t.Fatal("second hit on a must be limited")
+	}
+	if res, _ := l.Allow(ctx, "b", 1, time.Minute); !res.Allowed {
+		t.Fatal("b must not share a's window")
+	}
+}
+
+func TestMemoryRateLimiterReset(t *testing.T) {
+	l, _ := newTestLimiter()
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, _ = l.Allow(ctx, "k", 2, time.Minute)
+	}
+	if res, _ := l.Allow(ctx, "k", 2, time.Minute); res.Allowed {
+		t.Fatal("expected limit before reset")
+	}
+	_ = l.Lock(ctx, "k", time.Minute)
+
+	if err := l.Reset(ctx, "k"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	res, _ := l.Allow(ctx, "k", 2, time.Minute)
+	if !res.Allowed || res.Count != 1 {
+		t.Fatalf("after reset got allowed=%v count=%d, want allowed count=1", res.Allowed, res.Count)
+	}
+	if left, _ := l.LockedFor(ctx, "k"); left != 0 {
+		t.Fatalf("reset must clear the lock, %s left", left)
+	}
+}
+
+func TestMemoryRateLimiterLock(t *testing.T) {
+	l, clock := newTestLimiter()
+	ctx := context.Background()
+
+	if left, _ := l.LockedFor(ctx, "k"); left != 0 {
+		t.Fatalf("no lock yet, got %s", left)
+	}
+	_ = l.Lock(ctx, "k", time.Minute)
+	clock.advance(20 * time.Second)
+	if left, _ := l.LockedFor(ctx, "k"); left != 40*time.Second {
+		t.Fatalf("got %s left, want 40s", left)
+	}
+	clock.advance(40 * time.Second)
+	if left, _ := l.LockedFor(ctx, "k"); left != 0 {
+		t.Fatalf("lock must expire, got %s left", left)
+	}
+}
+
+func TestLoginLockoutEscalation(t *testing.T) {
+	l, clock := newTestLimiter()
+	cfg := config.Config{
+		LoginMaxFailures:   3,
+		LoginFailureWindow: 15 * time.Minute,
+		LoginLockoutBase:   time.Minute,
+		LoginLockoutMax:    4 * time.Minute,
+	}
+	s := NewRateLimitService(l, cfg, logger.New("test"))
+	ctx := context.Background()
+	const email, ip = " User@Example.com ", "10.0.0.1"
+
+	// har bir lockout oldingisidan ikki baravar uzun, LoginLockoutMax bilan cheklangan
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute} {
+		for i := 1; i < cfg.LoginMaxFailures; i++ {
+			d, err := s.LoginFailed(ctx, email, ip)
+			if err != nil || d != 0 {
+				t.Fatalf("failure %d: got lockout %s err %v, want none", i, d, err)
+			}
+		}
+		d, err := s.LoginFailed(ctx, email, ip)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if d != want {
+			t.Fatalf("got lockout %s, want %s", d, want)
+		}
+		if left, _ := s.LoginLockedFor(ctx, "user@example.com", ip); left != want {
+			t.Fatalf("locked for %s, want %s (email must be normalized)", left, want)
+		}
+		if left, _ := s.LoginLockedFor(ctx, email, "10.0.0.2"); left != 0 {
+			t.Fatalf("other address must not be locked, %s left", left)
+		}
+		clock.advance(want)
+		if left, _ := s.LoginLockedFor(ctx, email, ip); left != 0 {
+			t.Fatalf("lock must expire, %s left", left)
+		}
+	}
+}
+
+func TestLoginSucceededResetsFailures(t *testing.T) {
+	l, _ := newTestLimiter()
+	cfg := config.Config{LoginMaxFailures: 2, LoginFailureWindow: time.Minute, LoginLockoutBase: time.Minute, LoginLockoutMax: time.Hour}
+	s := NewRateLimitService(l, cfg, logger.New("test"))
+	ctx := context.Background()
+
+	if d, _ := s.LoginFailed(ctx, "a@b.c", "10.0.0.1"); d != 0 {
+		t.Fatalf("first failure must not lock, got %s", d)
+	}
+	s.LoginSucceeded(ctx, "a@b.c", "10.0.0.1")
+	if d, _ := s.LoginFailed(ctx, "a@b.c", "10.0.0.1"); d != 0 {
+		t.Fatalf("success must reset the failure count, got lockout %s", d)
+	}
+}
+
+func TestLoginEmailCeiling(t *testing.T) {
+	l, clock := newTestLimiter()
+	cfg := config.Config{
+		LoginMaxFailures:      3,
+		LoginEmailMaxFailures: 5,
+		LoginFailureWindow:    15 * time.Minute,
+		LoginLockoutBase:      time.Minute,
+		LoginLockoutMax:       time.Hour,
+	}
+	s := NewRateLimitService(l, cfg, logger.New("test"))
+	ctx := context.Background()
+
+	// har IP o‘z chegarasidan past qoladi, lekin email bo‘yicha umumiy chegaraga yetadi
+	for i := 1; i < cfg.LoginEmailMaxFailures; i++ {
+		if d, err := s.LoginFailed(ctx, "a@b.c", fmt.Sprintf("10.0.0.%d", i)); err != nil || d != 0 {
+			t.Fatalf("failure %d: got lockout %s err %v, want none", i, d, err)
+		}
+	}
+	if d, _ := s.LoginFailed(ctx, "a@b.c", "10.0.0.99"); d != cfg.LoginFailureWindow {
+		t.Fatalf("got lockout %s, want %s", d, cfg.LoginFailureWindow)
+	}
+	if left, _ := s.LoginLockedFor(ctx, "a@b.c", "10.0.1.1"); left != cfg.LoginFailureWindow {
+		t.Fatalf("email must be locked for every address, %s left", left)
+	}
+	if left, _ := s.LoginLockedFor(ctx, "other@b.c", "10.0.0.1"); left != 0 {
+		t.Fatalf("other email must not be locked, %s left", left)
+	}
+
+	clock.advance(cfg.LoginFailureWindow)
+	if left, _ := s.LoginLockedFor(ctx, "a@b.c", "10.0.1.1"); left != 0 {
+		t.Fatalf("email lock must expire, %s left", left)
+	}
+}
@@ -8,12 +8,18 @@ import (
 	"speakpall/storage"
 )
 
-const refreshTokenPrefix = "refresh_token:"
+const (
+	refreshTokenPrefix = "refresh_token:"
+	deniedAccessPrefix = "denied_access:"
+)
 
 type RedisService interface {
 	SetX(ctx context.Context, key string, value interface{}, duration time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
 	DeleteRefreshToken(ctx context.Context, userID string) error
+
+	DenyAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenDenied(ctx context.Context, jti string) (bool, error)
 }
 
 type redisService struct {
@@ -55,3 +61,20 @@ func (s *redisService) DeleteRefreshToken(ctx context.Context, userID string) er
 	}
 	return s.redis.Delete(ctx, key)
 }
+
+// DenyAccessToken - access token jti si muddati tugaguncha (ttl) denylist'da turadi.
+func (s *redisService) DenyAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // token allaqachon muddati o'tgan
+	}
+	return s.SetX(ctx, deniedAccessPrefix+jti, 1, ttl)
+}
+
+func (s *redisService) IsAccessTokenDenied(ctx context.Context, jti string) (bool, error) {
+	if _, has := ctx.Deadline(); !has {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+	}
+	return s.redis.Exists(ctx, deniedAccessPrefix+jti)
+}
@@ -4,8 +4,10 @@ import (
 	// tgbotapi import qilinmoqda
 
 	"speakpall/config"
+	"speakpall/pkg/blobstore"
 	"speakpall/pkg/logger"
 	"speakpall/pkg/mailer"
+	"speakpall/pkg/password"
 	"speakpall/storage"
 )
 
@@ -20,6 +22,18 @@ type IServiceManager interface {
 	Matchs() MatchsService
 	Interes() InteresService
 	Friend() FriendService
+	OTP() OTPService
+	EmailChange() EmailChangeService
+	Session() SessionService
+	OAuth() OAuthService
+	RateLimit() RateLimitService
+	MFA() MFAService
+	WebAuthn() WebAuthnService
+	Account() AccountService
+	Export() ExportService
+	Audit() AuditService
+	MatchQueue() MatchQueueService
+	Partner() PartnerService
 }
 
 type service struct {
@@ -32,21 +46,48 @@ type service struct {
 	settingsService SettingsService
 	matchsService   MatchsService
 	interesService  InteresService
-	friendService  FriendService
+	friendService   FriendService
+	otpService      OTPService
+	emailChange     EmailChangeService
+	sessionService  SessionService
+	oauthService    OAuthService
+	rateLimit       RateLimitService
+	mfaService      MFAService
+	webAuthn        WebAuthnService
+	account         AccountService
+	export          ExportService
+	audit           AuditService
+	matchQueue      MatchQueueService
+	partner         PartnerService
 }
 
-func New(storage storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer, redis storage.IRedisStorage, googleCfg config.OAuthProviderConfig) IServiceManager {
+func New(storage storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer, redis storage.IRedisStorage, blobs blobstore.Store, policy *password.Policy, cfg config.Config) IServiceManager {
+	audit := NewAuditService(storage, log)
+	limiter := NewRedisRateLimiter(redis)
+
 	return &service{
-		userService: NewUserService(storage, log, mailerCore),
+		userService: NewUserService(storage, redis, log, mailerCore, policy, cfg.AppURL),
 		mailer:      NewMailerService(mailerCore),
 
 		redisService:    NewRedisService(redis, log),
-		googleService:   NewGoogleService(GoogleOAuthConfig(googleCfg)), // <-- config ni uzatish!
+		googleService:   NewGoogleService(GoogleOAuthConfig(cfg.Google)), // <-- config ni uzatish!
 		profileService:  NewProfileService(storage, log),
 		settingsService: NewSettingsService(storage, log),
 		matchsService:   NewMatchsService(storage, log),
-		interesService: NewInteresService(storage,log),
-		friendService: NewFriendService(storage,log),
+		interesService:  NewInteresService(storage, log),
+		friendService:   NewFriendService(storage, log),
+		otpService:      NewOTPService(storage, log, mailerCore),
+		emailChange:     NewEmailChangeService(storage, log, mailerCore, cfg.AppURL),
+		sessionService:  NewSessionService(storage, redis, log),
+		oauthService:    NewOAuthService(storage, redis, log, NewOAuthRegistry(cfg)),
+		rateLimit:       NewRateLimitService(limiter, cfg, log),
+		mfaService:      NewMFAService(storage, limiter, log, cfg),
+		webAuthn:        NewWebAuthnService(storage, redis, log, cfg),
+		account:         NewAccountService(storage, log, NewOTPService(storage, log, mailerCore), NewSessionService(storage, redis, log), audit, cfg),
+		export:          NewExportService(storage, log, mailerCore, blobs, cfg),
+		audit:           audit,
+		matchQueue:      NewMatchQueueService(storage, redis, log, cfg),
+		partner:         NewPartnerService(storage, log, cfg),
 	}
 }
 
@@ -76,10 +117,58 @@ func (s *service) Matchs() MatchsService {
 	return s.matchsService
 }
 
-func (s *service) Interes() InteresService  {
+func (s *service) Interes() InteresService {
 	return s.interesService
 }
 
-func (s *service) Friend()  FriendService {
+func (s *service) Friend() FriendService {
 	return s.friendService
-}
\ No newline at end of file
+}
+
+func (s *service) OTP() OTPService {
+	return s.otpService
+}
+
+func (s *service) EmailChange() EmailChangeService {
+	return s.emailChange
+}
+
+func (s *service) Session() SessionService {
+	return s.sessionService
+}
+
+func (s *service) OAuth() OAuthService {
+	return s.oauthService
+}
+
+func (s *service) RateLimit() RateLimitService {
+	return s.rateLimit
+}
+
+func (s *service) MFA() MFAService {
+	return s.mfaService
+}
+
+func (s *service) WebAuthn() WebAuthnService {
+	return s.webAuthn
+}
+
+func (s *service) Account() AccountService {
+	return s.account
+}
+
+func (s *service) Export() ExportService {
+	return s.export
+}
+
+func (s *service) Audit() AuditService {
+	return s.audit
+}
+
+func (s *service) MatchQueue() MatchQueueService {
+	return s.matchQueue
+}
+
+func (s *service) Partner() PartnerService {
+	return s.partner
+}
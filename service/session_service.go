@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/pkg/jwt"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+var (
+	ErrSessionInvalid      = errors.New("session is invalid or revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// bekor qilingan sessiya access tokenlari muddati tugaguncha Redis'da saqlanadi
+const revokedSessionPrefix = "revoked_session:"
+
+type SessionService interface {
+	Issue(ctx context.Context, userID, role, userAgent, ip string) (models.LoginResponse, error)
+	Refresh(ctx context.Context, refreshToken, userAgent, ip string) (models.LoginResponse, error)
+	Revoke(ctx context.Context, sessionID string) error
+	RevokeAllForUser(ctx context.Context, userID, exceptSessionID string) error
+
+	List(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error)
+	RevokeForUser(ctx context.Context, userID, sessionID string) error
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+type sessionService struct {
+	stg     storage.ISessionStorage
+	userStg storage.IUserStorage
+	redis   storage.IRedisStorage
+	log     logger.ILogger
+}
+
+func NewSessionService(stg storage.IStorage, redis storage.IRedisStorage, log logger.ILogger) SessionService {
+	return &sessionService{
+		stg:     stg.Session(),
+		userStg: stg.User(),
+		redis:   redis,
+		log:     log,
+	}
+}
+
+// Issue: yangi refresh token oilasini (auth_sessions qatori) ochadi va token juftligini qaytaradi.
+func (s *sessionService) Issue(ctx context.Context, userID, role, userAgent, ip string) (models.LoginResponse, error) {
+	sessionID := uuid.NewString()
+
+	rt, jti, err := jwt.GenerateRefreshToken(userID, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	at, err := jwt.GenerateAccessToken(userID, role, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	if err := s.stg.Create(ctx, models.AuthSession{
+		ID:         sessionID,
+		UserID:     userID,
+		RefreshJTI: jti,
+		UserAgent:  userAgent,
+		IPAddress:  ip,
+		ExpiresAt:  time.Now().Add(jwt.RefreshTokenTTL),
+	}); err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	return models.LoginResponse{
+		ID:           userID,
+		Role:         role,
+		AccessToken:  at,
+		RefreshToken: rt,
+	}, nil
+}
+
+// Refresh: har chaqiruvda refresh tokenni almashtiradi (rotation).
+// Almashtirilgan (eski) token qayta kelsa — butun oila bekor qilinadi.
+func (s *sessionService) Refresh(ctx context.Context, refreshToken, userAgent, ip string) (models.LoginResponse, error) {
+	claims, err := jwt.ExtractClaims(refreshToken)
+	if err != nil {
+		return models.LoginResponse{}, ErrRefreshTokenInvalid
+	}
+	if t, _ := claims["typ"].(string); t != "refresh" {
+		return models.LoginResponse{}, ErrRefreshTokenInvalid
+	}
+	userID, _ := claims["user_id"].(string)
+	sessionID, _ := claims["sid"].(string)
+	jti, _ := claims["jti"].(string)
+	if userID == "" || sessionID == "" || jti == "" {
+		return models.LoginResponse{}, ErrRefreshTokenInvalid
+	}
+
+	sess, err := s.stg.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.LoginResponse{}, ErrSessionInvalid
+		}
+		return models.LoginResponse{}, err
+	}
+	if sess.UserID != userID || sess.RevokedAt != nil || time.Now().After(sess.ExpiresAt) {
+		return models.LoginResponse{}, ErrSessionInvalid
+	}
+	if sess.RefreshJTI != jti {
+		s.revokeReused(ctx, sess.ID, userID)
+		return models.LoginResponse{}, ErrRefreshTokenReused
+	}
+
+	// rol DB'dan olinadi — refresh tokenda rol yo‘q
+	u, err := s.userStg.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.LoginResponse{}, ErrSessionInvalid
+	}
+
+	rt, newJTI, err := jwt.GenerateRefreshToken(userID, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	at, err := jwt.GenerateAccessToken(userID, u.Role, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	ok, err := s.stg.Rotate(ctx, sessionID, jti, newJTI, time.Now().Add(jwt.RefreshTokenTTL), userAgent, ip)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	if !ok {
+		// parallel so‘rov shu tokenni allaqachon almashtirdi
+		s.revokeReused(ctx, sess.ID, userID)
+		return models.LoginResponse{}, ErrRefreshTokenReused
+	}
+
+	return models.LoginResponse{
+		ID:           userID,
+		Role:         u.Role,
+		AccessToken:  at,
+		RefreshToken: rt,
+	}, nil
+}
+
+func (s *sessionService) Revoke(ctx context.Context, sessionID string) error {
+	s.log.Info("SessionService.Revoke", logger.String("session_id", sessionID))
+	if err := s.stg.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.markRevoked(ctx, sessionID)
+}
+
+func (s *sessionService) RevokeAllForUser(ctx context.Context, userID, exceptSessionID string) error {
+	s.log.Info("SessionService.RevokeAllForUser", logger.String("user_id", userID))
+	ids, err := s.stg.RevokeAllByUser(ctx, userID, exceptSessionID)
+	if err != nil {
+		return err
+	}
+	// bitta Redis xatosi qolgan sessiyalarni belgilashni to‘xtatmaydi
+	var errs []error
+	for _, id := range ids {
+		if err := s.markRevoked(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *sessionService) List(ctx context.Context, userID, currentSessionID string) ([]models.SessionInfo, error) {
+	s.log.Info("SessionService.List", logger.String("user_id", userID))
+	list, err := s.stg.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.SessionInfo, 0, len(list))
+	for _, sess := range list {
+		out = append(out, models.SessionInfo{
+			ID:         sess.ID,
+			Device:     sess.UserAgent,
+			IPAddress:  sess.IPAddress,
+			CreatedAt:  sess.CreatedAt,
+			LastUsedAt: sess.LastUsedAt,
+			Current:    sess.ID == currentSessionID,
+		})
+	}
+	return out, nil
+}
+
+// RevokeForUser: faqat o‘ziga tegishli sessiyani bekor qila oladi.
+func (s *sessionService) RevokeForUser(ctx context.Context, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+	sess, err := s.stg.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if sess.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.Revoke(ctx, sessionID)
+}
+
+// IsRevoked: JWTMiddleware uchun arzon tekshiruv (faqat Redis).
+func (s *sessionService) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if _, has := ctx.Deadline(); !has {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+	}
+	return s.redis.Exists(ctx, revokedSessionPrefix+sessionID)
+}
+
+// markRevoked: xato qaytariladi — Redis'ga yozilmasa access tokenlar muddati tugaguncha ishlayveradi.
+func (s *sessionService) markRevoked(ctx context.Context, sessionID string) error {
+	// access token AccessTokenTTL dan ortiq yashamaydi
+	if err := s.redis.SetX(ctx, revokedSessionPrefix+sessionID, 1, jwt.AccessTokenTTL); err != nil {
+		s.log.Error("mark session revoked in redis failed", logger.Error(err), logger.String("session_id", sessionID))
+		return err
+	}
+	return nil
+}
+
+func (s *sessionService) revokeReused(ctx context.Context, sessionID, userID string) {
+	s.log.Warning("refresh token reuse detected", logger.String("session_id", sessionID), logger.String("user_id", userID))
+	if err := s.Revoke(ctx, sessionID); err != nil {
+		s.log.Error("revoke reused session failed", logger.Error(err), logger.String("session_id", sessionID))
+	}
+}
@@ -2,51 +2,96 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
 	"speakpall/api/models"
+	"speakpall/config"
 	"speakpall/pkg/logger"
 	"speakpall/pkg/mailer"
+	"speakpall/pkg/password"
 	"speakpall/pkg/security"
 	"speakpall/storage"
 )
 
+var (
+	ErrGoogleEmailNotVerified = errors.New("google email is not verified")
+	ErrGoogleAlreadyLinked    = errors.New("google account is linked to another user")
+	ErrGoogleNotLinked        = errors.New("google account is not linked")
+	ErrNoPasswordSet          = errors.New("set a password or add a passkey before unlinking the last sign-in method")
+	ErrAccountLinkRequired    = errors.New("an account with this email exists; sign in to it and link the provider from account settings")
+	ErrInvalidRole            = errors.New("invalid role")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrCannotChangeOwnRole    = errors.New("you cannot change your own role")
+	ErrResetTokenInvalid      = errors.New("invalid or expired token")
+)
+
+// lastSeenTouchPrefix - LastSeenTouchInterval davomida turadigan kalit: bor bo‘lsa last_seen yozilmaydi
+const lastSeenTouchPrefix = "last_seen_touch:"
+
 type UserService interface {
 	Create(ctx context.Context, req models.SignupRequest) (string, error)
 	GetForLoginByEmail(ctx context.Context, email string) (models.LoginUser, error)
 	GetByID(ctx context.Context, id string) (*models.User, error)
 
 	ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error
-	GoogleAuth(ctx context.Context, email, name, googleID string) (string, error)
-
-	SetRole(ctx context.Context, userID, role string) error
-
-	CreatePasswordResetToken(ctx context.Context, email string) (string, error)
-	ValidatePasswordResetToken(ctx context.Context, token string) (string, error)
-	ResetPassword(ctx context.Context, userID string, newPassword string) error
+	// UpgradePasswordHash parol tekshirilgandan keyin: hash eskirgan bo‘lsa (bcrypt yoki eski parametrlar) qayta hashlaydi
+	UpgradePasswordHash(ctx context.Context, userID, currentHash, password string) error
+	GoogleAuth(ctx context.Context, gu models.GoogleUser) (string, error)
+	LinkGoogle(ctx context.Context, userID string, gu models.GoogleUser) error
+	UnlinkGoogle(ctx context.Context, userID string) error
+
+	// SetRole actorID — rolni o‘zgartirayotgan admin (o‘z rolini o‘zgartira olmaydi)
+	SetRole(ctx context.Context, actorID, userID, role string) error
+
+	SendVerificationEmail(ctx context.Context, email string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, email, code string) error
+	IsEmailVerified(ctx context.Context, userID string) (bool, error)
+	// TouchLastSeen online holati uchun (JWT middleware); ko‘pi bilan har LastSeenTouchInterval'da yoziladi
+	TouchLastSeen(ctx context.Context, userID string) error
+
+	CreatePasswordResetToken(ctx context.Context, email string) error
+	// ResetPassword token parol siyosatidan o‘tgandan keyingina ishlatiladi; user ID qaytaradi
+	ResetPassword(ctx context.Context, token, newPassword string) (string, error)
 }
 
 type userService struct {
 	stg        storage.IUserStorage
+	identStg   storage.IIdentityStorage
+	redis      storage.IRedisStorage
 	log        logger.ILogger
 	mailerCore *mailer.Mailer
+	otp        OTPService
+	policy     *password.Policy
+	appURL     string
 }
 
-func NewUserService(stg storage.IStorage, log logger.ILogger, mailerCore *mailer.Mailer) UserService {
+func NewUserService(stg storage.IStorage, redis storage.IRedisStorage, log logger.ILogger, mailerCore *mailer.Mailer, policy *password.Policy, appURL string) UserService {
 	return &userService{
 		stg:        stg.User(),
+		identStg:   stg.Identity(),
+		redis:      redis,
 		log:        log,
 		mailerCore: mailerCore,
+		otp:        NewOTPService(stg, log, mailerCore),
+		policy:     policy,
+		appURL:     strings.TrimRight(appURL, "/"),
 	}
 }
 
 func (s *userService) Create(ctx context.Context, req models.SignupRequest) (string, error) {
 	s.log.Info("UserService.Create", logger.String("email", req.Email))
 
+	if err := s.policy.Validate(req.Password, password.UserInfo{Email: req.Email, DisplayName: req.DisplayName}); err != nil {
+		return "", err
+	}
+
 	// request.Password -> hash
 	hashed, err := security.HashPassword(req.Password)
 	if err != nil {
@@ -60,6 +105,11 @@ func (s *userService) Create(ctx context.Context, req models.SignupRequest) (str
 		return "", err
 	}
 	s.log.Info("user created", logger.String("userID", id))
+
+	// tasdiqlash xati yuborilmasa ham ro‘yxatdan o‘tish bekor qilinmaydi (resend bor)
+	if err := s.SendVerificationEmail(ctx, req.Email); err != nil {
+		s.log.Error("send verification email failed", logger.Error(err), logger.String("userID", id))
+	}
 	return id, nil
 }
 
@@ -79,85 +129,275 @@ func (s *userService) GetByID(ctx context.Context, id string) (*models.User, err
 }
 
 func (s *userService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
-    user, err := s.stg.GetUserByID(ctx, userID)
-    if err != nil {
-        return err
-    }
+	user, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
 
-    if err := security.CompareHashAndPassword(user.PasswordHash, oldPassword); err != nil {
-        return errors.New("old password is incorrect")
-    }
+	if err := security.CompareHashAndPassword(user.PasswordHash, oldPassword); err != nil {
+		return errors.New("old password is incorrect")
+	}
+	if err := s.policy.Validate(newPassword, password.UserInfo{Email: user.Email, DisplayName: user.DisplayName}); err != nil {
+		return err
+	}
 
-    newHash, err := security.HashPassword(newPassword)
-    if err != nil {
-        return errors.New("failed to hash new password")
-    }
+	newHash, err := security.HashPassword(newPassword)
+	if err != nil {
+		return errors.New("failed to hash new password")
+	}
 
-    return s.stg.UpdatePasswordHash(ctx, userID, newHash)
+	return s.stg.UpdatePasswordHash(ctx, userID, newHash)
 }
 
+func (s *userService) UpgradePasswordHash(ctx context.Context, userID, currentHash, password string) error {
+	if !security.NeedsRehash(currentHash) {
+		return nil
+	}
+	s.log.Info("UserService.UpgradePasswordHash", logger.String("userID", userID))
 
+	newHash, err := security.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	// login bilan parallel ChangePassword/ResetPassword yozgan yangi hash eski parol hashi bilan almashtirilmasin
+	replaced, err := s.stg.ReplacePasswordHash(ctx, userID, currentHash, newHash)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		s.log.Info("password hash changed concurrently, rehash skipped", logger.String("userID", userID))
+	}
+	return nil
+}
 
-func (s *userService) GoogleAuth(ctx context.Context, email, name, googleID string) (string, error) {
-	// 1) bor-yo‘qligini tekshiramiz
-	u, err := s.stg.GetLoginByEmail(ctx, email)
-	if err == nil && u.ID != "" {
+// GoogleAuth: avval google_id bo‘yicha, so‘ng (Google tasdiqlagan) email bo‘yicha qidiradi;
+// topilmasa parolsiz yangi user yaratadi.
+func (s *userService) GoogleAuth(ctx context.Context, gu models.GoogleUser) (string, error) {
+	if gu.GoogleID == "" {
+		return "", errors.New("google account id is missing")
+	}
+
+	// 1) google_id bo‘yicha (yoki /auth/google/start orqali bog‘langan identity)
+	if u, err := s.stg.GetLoginByGoogleID(ctx, gu.GoogleID); err == nil && u.ID != "" {
+		s.setGoogleAvatar(ctx, u.ID, gu.Picture)
 		return u.ID, nil
 	}
+	if ident, err := s.identStg.GetByProviderSubject(ctx, models.ProviderGoogle, gu.GoogleID); err == nil {
+		s.setGoogleAvatar(ctx, ident.UserID, gu.Picture)
+		return ident.UserID, nil
+	}
 
-	// 2) yo‘q bo‘lsa, random parol yaratib, hashlab create qilamiz
-	buf := make([]byte, 24)
-	if _, err := rand.Read(buf); err != nil {
-		return "", errors.New("failed to generate random password")
+	// tasdiqlanmagan email bilan mavjud akkauntga bog‘lanish yoki yangi akkaunt ochish mumkin emas
+	if !gu.EmailVerified {
+		return "", ErrGoogleEmailNotVerified
 	}
-	randomPass := base64.RawURLEncoding.EncodeToString(buf)
+	gu.Email = normalizeEmail(gu.Email)
 
-	hashed, err := security.HashPassword(randomPass)
+	// 2) email bo‘yicha — emaili tasdiqlangan mavjud akkauntga google_id bog‘lanadi
+	userID, err := autoLinkUserID(ctx, s.stg, gu.Email)
 	if err != nil {
-		return "", errors.New("failed to hash password")
+		return "", err
+	}
+	if userID != "" {
+		if err := s.linkGoogle(ctx, userID, gu); err != nil {
+			return "", err
+		}
+		return userID, nil
 	}
 
-	req := models.SignupRequest{
-		DisplayName: name,
-		Email:       email,
-		Password:    hashed, // CreateUser hash kutadi (oldinda hashlangan)
+	// 3) yangi user (parolsiz)
+	id, err := s.stg.CreateGoogleUser(ctx, gu)
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return "", ErrGoogleAlreadyLinked
+		}
+		return "", err
 	}
-	id, err := s.stg.CreateUser(ctx, req)
+	s.mirrorGoogleIdentity(ctx, id, gu)
+	return id, nil
+}
+
+// LinkGoogle: tizimga kirgan user akkauntiga Google akkauntini bog‘laydi.
+func (s *userService) LinkGoogle(ctx context.Context, userID string, gu models.GoogleUser) error {
+	s.log.Info("UserService.LinkGoogle", logger.String("userID", userID))
+	if gu.GoogleID == "" {
+		return errors.New("google account id is missing")
+	}
+	if u, err := s.stg.GetLoginByGoogleID(ctx, gu.GoogleID); err == nil && u.ID != "" && u.ID != userID {
+		return ErrGoogleAlreadyLinked
+	}
+	if ident, err := s.identStg.GetByProviderSubject(ctx, models.ProviderGoogle, gu.GoogleID); err == nil && ident.UserID != userID {
+		return ErrGoogleAlreadyLinked
+	}
+	return s.linkGoogle(ctx, userID, gu)
+}
+
+// UnlinkGoogle: parol, boshqa provayder yoki passkey qolmasa rad etiladi — aks holda user akkauntga kira olmay qoladi.
+func (s *userService) UnlinkGoogle(ctx context.Context, userID string) error {
+	s.log.Info("UserService.UnlinkGoogle", logger.String("userID", userID))
+	err := s.identStg.Unlink(ctx, userID, models.ProviderGoogle)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrGoogleNotLinked
+	case errors.Is(err, storage.ErrLastLoginMethod):
+		return ErrNoPasswordSet
+	}
+	return err
+}
+
+// autoLinkUserID - tashqi provayder login'ida email bo‘yicha avtomatik bog‘lanadigan akkaunt ("" — yo‘q).
+// Akkaunt emaili tasdiqlanmagan bo‘lsa ErrAccountLinkRequired: aks holda begona odam egasining emaili bilan
+// parol qo‘yib ro‘yxatdan o‘tib, egasi provayder orqali kirganda o‘sha akkauntga ega bo‘lib qoladi (pre-hijacking).
+func autoLinkUserID(ctx context.Context, stg storage.IUserStorage, email string) (string, error) {
+	u, err := stg.GetLoginByEmail(ctx, email)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
 		return "", err
 	}
+	if !u.EmailVerified {
+		return "", ErrAccountLinkRequired
+	}
+	return u.ID, nil
+}
 
-	// Eslatma: agar google_id ni ham saqlamoqchi bo‘lsangiz,
-	// storage ga `UpdateGoogleID(userID, googleID)` kabi metod qo‘shib, shu yerda chaqirasiz.
+func (s *userService) linkGoogle(ctx context.Context, userID string, gu models.GoogleUser) error {
+	googleID := gu.GoogleID
+	if err := s.stg.UpdateGoogleID(ctx, userID, &googleID); err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return ErrGoogleAlreadyLinked
+		}
+		return err
+	}
+	s.mirrorGoogleIdentity(ctx, userID, gu)
+	s.setGoogleAvatar(ctx, userID, gu.Picture)
+	return nil
+}
 
-	return id, nil
+// mirrorGoogleIdentity: google_id bog‘lanishi user_identities'ga ham yoziladi (allaqachon bo‘lsa o‘tkaziladi).
+func (s *userService) mirrorGoogleIdentity(ctx context.Context, userID string, gu models.GoogleUser) {
+	email := gu.Email
+	err := s.identStg.Create(ctx, models.UserIdentity{
+		UserID:   userID,
+		Provider: models.ProviderGoogle,
+		Subject:  gu.GoogleID,
+		Email:    &email,
+	})
+	if err != nil && !errors.Is(err, storage.ErrAlreadyExists) {
+		s.log.Error("mirror google identity failed", logger.Error(err), logger.String("userID", userID))
+	}
+}
+
+func (s *userService) setGoogleAvatar(ctx context.Context, userID string, picture *string) {
+	if picture == nil || *picture == "" {
+		return
+	}
+	if err := s.stg.SetAvatarIfEmpty(ctx, userID, *picture); err != nil {
+		s.log.Error("set google avatar failed", logger.Error(err), logger.String("userID", userID))
+	}
+}
+
+func (s *userService) SetRole(ctx context.Context, actorID, userID, role string) error {
+	s.log.Info("UserService.SetRole", logger.String("actorID", actorID), logger.String("userID", userID), logger.String("role", role))
+	if !models.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if actorID == userID {
+		return ErrCannotChangeOwnRole
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.stg.UpdateRole(ctx, userID, role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *userService) SendVerificationEmail(ctx context.Context, email string) error {
+	return s.otp.SendCode(ctx, email, models.OTPPurposeVerify)
+}
+
+// ResendVerificationEmail: har doim nil — noma'lum, tasdiqlangan yoki cooldown ichidagi email farqlanmaydi
+// (enumeration'dan himoya). Kod fonda yuboriladi, xatolar faqat log qilinadi.
+func (s *userService) ResendVerificationEmail(ctx context.Context, email string) error {
+	u, err := s.stg.GetLoginByEmail(ctx, normalizeEmail(email))
+	if err != nil || u.ID == "" || u.EmailVerified {
+		return nil
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.SendVerificationEmail(ctx, email); err != nil && !errors.Is(err, ErrOTPCooldown) {
+			s.log.Error("resend verification email failed", logger.Error(err), logger.String("userID", u.ID))
+		}
+	}()
+	return nil
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, email, code string) error {
+	if err := s.otp.VerifyCode(ctx, email, models.OTPPurposeVerify, code); err != nil {
+		return err
+	}
+	return s.stg.MarkEmailVerified(ctx, normalizeEmail(email))
+}
+
+func (s *userService) IsEmailVerified(ctx context.Context, userID string) (bool, error) {
+	u, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerified, nil
 }
 
-func (s *userService) SetRole(ctx context.Context, userID, role string) error {
-	if role != models.RoleAdmin && role != models.RoleUser {
-		return fmt.Errorf("invalid role: %s", role)
+// TouchLastSeen - har so‘rovda DB'ga yozmaslik uchun avval Redis'da SET NX EX: kalit bor bo‘lsa
+// oraliq hali o‘tmagan. Redis xatosida DB'ga tushadi (UPDATE'ning o‘zi ham oraliqni tekshiradi).
+func (s *userService) TouchLastSeen(ctx context.Context, userID string) error {
+	first, err := s.redis.SetNX(ctx, lastSeenTouchPrefix+userID, 1, config.LastSeenTouchInterval)
+	if err != nil {
+		s.log.Warning("last_seen throttle unavailable", logger.Error(err), logger.String("user_id", userID))
+	} else if !first {
+		return nil
 	}
-	return s.stg.UpdateRole(ctx, userID, role)
+	return s.stg.TouchLastSeen(ctx, userID, config.LastSeenTouchInterval)
 }
 
-func (s *userService) CreatePasswordResetToken(ctx context.Context, email string) (string, error) {
+// CreatePasswordResetToken: email mavjud bo‘lmasa ham xato qaytarmaydi (javob bir xil bo‘lishi uchun).
+// Token yaratish va email yuborish fonda — javob vaqti ham email mavjudligini bildirmaydi.
+func (s *userService) CreatePasswordResetToken(ctx context.Context, email string) error {
 	// userni topamiz
-	u, err := s.stg.GetLoginByEmail(ctx, email)
+	u, err := s.stg.GetLoginByEmail(ctx, normalizeEmail(email))
 	if err != nil || u.ID == "" {
-		return "", errors.New("user not found")
+		s.log.Info("UserService.CreatePasswordResetToken: unknown email, skipping")
+		return nil
 	}
 
-	// 32 baytli URL-safe token generatsiya
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", errors.New("failed to generate token")
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.sendPasswordReset(ctx, u.ID, email); err != nil {
+			s.log.Error("password reset not sent", logger.Error(err), logger.String("userID", u.ID))
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset - yangi tokenni saqlab, havolani emailga yuboradi.
+func (s *userService) sendPasswordReset(ctx context.Context, userID, email string) error {
+	// 32 baytli URL-safe token generatsiya; DB'da faqat SHA-256 saqlanadi
+	token, err := security.GenerateToken(32)
+	if err != nil {
+		return errors.New("failed to generate token")
 	}
-	token := base64.RawURLEncoding.EncodeToString(b)
 
-	// 1 soat amal qiladi
-	expiresAt := time.Now().Add(1 * time.Hour)
-	if err := s.stg.SavePasswordResetToken(ctx, u.ID, token, expiresAt); err != nil {
-		return "", err
+	expiresAt := time.Now().Add(config.PasswordResetExpireTime)
+	if err := s.stg.SavePasswordResetToken(ctx, userID, security.HashToken(token), expiresAt); err != nil {
+		return err
 	}
 
 	// Email yuborish (HTML)
@@ -169,27 +409,49 @@ func (s *userService) CreatePasswordResetToken(ctx context.Context, email string
   <div style="max-width:600px;margin:0 auto;background:#fff;padding:24px;border-radius:8px">
     <h2 style="margin:0 0 12px">Password Reset</h2>
     <p style="margin:0 0 16px">Hi, click the button below to reset your password:</p>
-    <p><a href="https://yourapp.com/reset-password?token=%s"
+    <p><a href="%s/reset-password?token=%s"
           style="display:inline-block;background:#007bff;color:#fff;text-decoration:none;padding:12px 20px;border-radius:4px">Reset Password</a></p>
     <p style="color:#888;margin:16px 0 0">If you didn’t request this, just ignore this email.</p>
   </div>
-</body></html>`, token)
+</body></html>`, s.appURL, token)
+
+	return s.mailerCore.Send(email, subject, body)
+}
 
-	if err := s.mailerCore.Send(email, subject, body); err != nil {
-		return "", errors.New("failed to send reset email")
+// ResetPassword: token bir martalik — avval siyosat tekshiriladi (zaif parol tokenni yoqib yubormasin),
+// so‘ng token ishlatilgan deb belgilanadi.
+func (s *userService) ResetPassword(ctx context.Context, token, newPassword string) (string, error) {
+	tokenHash := security.HashToken(token)
+	userID, err := s.stg.PeekPasswordResetToken(ctx, tokenHash, time.Now())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrResetTokenInvalid
+		}
+		return "", err
 	}
 
-	return token, nil
-}
+	u, err := s.stg.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.policy.Validate(newPassword, password.UserInfo{Email: u.Email, DisplayName: u.DisplayName}); err != nil {
+		return "", err
+	}
 
-func (s *userService) ValidatePasswordResetToken(ctx context.Context, token string) (string, error) {
-	return s.stg.GetUserIDByPasswordResetToken(ctx, token, time.Now())
-}
+	if _, err := s.stg.ConsumePasswordResetToken(ctx, tokenHash, time.Now()); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrResetTokenInvalid
+		}
+		return "", err
+	}
 
-func (s *userService) ResetPassword(ctx context.Context, userID string, newPassword string) error {
 	hash, err := security.HashPassword(newPassword)
 	if err != nil {
-		return errors.New("failed to hash new password")
+		return "", errors.New("failed to hash new password")
+	}
+	if err := s.stg.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return "", err
 	}
-	return s.stg.UpdatePasswordHash(ctx, userID, hash)
+	// qolgan ochiq reset havolalari ham yaroqsiz bo‘ladi
+	return userID, s.stg.InvalidatePasswordResetTokens(ctx, userID)
 }
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"speakpall/api/models"
+	"speakpall/config"
+	"speakpall/pkg/logger"
+	"speakpall/pkg/webauthn"
+	"speakpall/storage"
+)
+
+var (
+	ErrPasskeyChallengeInvalid  = errors.New("passkey challenge is invalid or expired")
+	ErrPasskeyInvalid           = errors.New("passkey verification failed")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
+	ErrPasskeyNotFound          = errors.New("passkey not found")
+)
+
+const (
+	webAuthnRegPrefix   = "webauthn_reg:"
+	webAuthnLoginPrefix = "webauthn_login:"
+
+	defaultPasskeyName = "Passkey"
+)
+
+// webAuthnSession - challenge kaliti ostida Redis'da (bir martalik).
+// Login'da UserID bo‘sh bo‘lsa discoverable credential — istalgan user.
+type webAuthnSession struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// PasskeyLoginResult - UserVerified: authenticator PIN/biometrika tekshirgan (UV flag).
+type PasskeyLoginResult struct {
+	UserID       string
+	UserVerified bool
+}
+
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID string) (webauthn.CreationOptions, error)
+	FinishRegistration(ctx context.Context, userID, name string, credential []byte) (*models.WebAuthnCredential, error)
+
+	// BeginLogin email bo‘sh bo‘lsa usernameless (discoverable) login
+	BeginLogin(ctx context.Context, email string) (webauthn.RequestOptions, error)
+	FinishLogin(ctx context.Context, credential []byte) (PasskeyLoginResult, error)
+
+	List(ctx context.Context, userID string) ([]models.WebAuthnCredential, error)
+	Rename(ctx context.Context, userID, id, name string) error
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type webAuthnService struct {
+	stg     storage.IWebAuthnStorage
+	userStg storage.IUserStorage
+	redis   storage.IRedisStorage
+	rp      webauthn.Config
+	log     logger.ILogger
+}
+
+func NewWebAuthnService(stg storage.IStorage, redis storage.IRedisStorage, log logger.ILogger, cfg config.Config) WebAuthnService {
+	return &webAuthnService{
+		stg:     stg.WebAuthn(),
+		userStg: stg.User(),
+		redis:   redis,
+		rp: webauthn.Config{
+			RPID:    cfg.WebAuthnRPID,
+			RPName:  cfg.WebAuthnRPName,
+			Origins: cfg.WebAuthnOrigins,
+			Timeout: config.WebAuthnChallengeExpireTime,
+		},
+		log: log,
+	}
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID string) (webauthn.CreationOptions, error) {
+	s.log.Info("WebAuthnService.BeginRegistration", logger.String("user_id", userID))
+	u, err := s.userStg.GetUserByID(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+	existing, err := s.stg.ListByUser(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	challenge, err := s.saveChallenge(ctx, webAuthnRegPrefix, webAuthnSession{UserID: userID})
+	if err != nil {
+		return webauthn.CreationOptions{}, err
+	}
+
+	displayName := u.DisplayName
+	if displayName == "" {
+		displayName = u.Email
+	}
+	// bir authenticator'da ikki marta ro‘yxatdan o‘tmaslik uchun
+	return s.rp.NewCreationOptions(challenge, []byte(u.ID), u.Email, displayName, descriptors(existing)), nil
+}
+
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID, name string, credential []byte) (*models.WebAuthnCredential, error) {
+	s.log.Info("WebAuthnService.FinishRegistration", logger.String("user_id", userID))
+	var resp webauthn.AttestationResponse
+	if err := json.Unmarshal(credential, &resp); err != nil {
+		return nil, ErrPasskeyInvalid
+	}
+
+	challenge, sess, err := s.takeChallenge(ctx, webAuthnRegPrefix, resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if sess.UserID != userID {
+		return nil, ErrPasskeyChallengeInvalid
+	}
+
+	cred, err := s.rp.VerifyRegistration(challenge, resp)
+	if err != nil {
+		s.log.Warning("passkey registration rejected", logger.String("user_id", userID), logger.Error(err))
+		return nil, ErrPasskeyInvalid
+	}
+
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	c := models.WebAuthnCredential{
+		UserID:         userID,
+		CredentialID:   cred.ID,
+		PublicKey:      cred.PublicKey,
+		SignCount:      cred.SignCount,
+		AAGUID:         cred.AAGUID,
+		Transports:     cred.Transports,
+		BackupEligible: cred.BackupEligible,
+		Name:           name,
+	}
+	id, err := s.stg.Create(ctx, c)
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return nil, ErrPasskeyAlreadyRegistered
+		}
+		return nil, err
+	}
+	c.ID = id
+	return &c, nil
+}
+
+func (s *webAuthnService) BeginLogin(ctx context.Context, email string) (webauthn.RequestOptions, error) {
+	var (
+		sess  webAuthnSession
+		allow []webauthn.CredentialDescriptor
+	)
+	if email != "" {
+		// user topilmasa ham bir xil javob — email borligini oshkor qilmaslik uchun
+		if u, err := s.userStg.GetLoginByEmail(ctx, normalizeEmail(email)); err == nil && u.ID != "" {
+			list, err := s.stg.ListByUser(ctx, u.ID)
+			if err != nil {
+				return webauthn.RequestOptions{}, err
+			}
+			sess.UserID = u.ID
+			allow = descriptors(list)
+		}
+	}
+
+	challenge, err := s.saveChallenge(ctx, webAuthnLoginPrefix, sess)
+	if err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+	return s.rp.NewRequestOptions(challenge, allow), nil
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, credential []byte) (PasskeyLoginResult, error) {
+	var resp webauthn.AssertionResponse
+	if err := json.Unmarshal(credential, &resp); err != nil || len(resp.RawID) == 0 {
+		return PasskeyLoginResult{}, ErrPasskeyInvalid
+	}
+
+	challenge, sess, err := s.takeChallenge(ctx, webAuthnLoginPrefix, resp.Response.ClientDataJSON)
+	if err != nil {
+		return PasskeyLoginResult{}, err
+	}
+
+	cred, err := s.stg.GetByCredentialID(ctx, resp.RawID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PasskeyLoginResult{}, ErrPasskeyInvalid
+		}
+		return PasskeyLoginResult{}, err
+	}
+	if sess.UserID != "" && sess.UserID != cred.UserID {
+		return PasskeyLoginResult{}, ErrPasskeyInvalid
+	}
+	if len(resp.Response.UserHandle) > 0 && !bytes.Equal(resp.Response.UserHandle, []byte(cred.UserID)) {
+		return PasskeyLoginResult{}, ErrPasskeyInvalid
+	}
+
+	res, err := s.rp.VerifyAssertion(challenge, resp, cred.PublicKey, cred.SignCount)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCount) {
+			s.log.Warning("passkey sign counter regressed, possible cloned authenticator",
+				logger.String("user_id", cred.UserID), logger.String("credential", cred.ID))
+		}
+		return PasskeyLoginResult{}, ErrPasskeyInvalid
+	}
+
+	ok, err := s.stg.UpdateSignCount(ctx, cred.ID, cred.SignCount, res.SignCount)
+	if err != nil {
+		return PasskeyLoginResult{}, err
+	}
+	if !ok {
+		// shu orada boshqa login hisoblagichni o‘zgartirdi
+		return PasskeyLoginResult{}, ErrPasskeyInvalid
+	}
+	return PasskeyLoginResult{UserID: cred.UserID, UserVerified: res.UserVerified}, nil
+}
+
+func (s *webAuthnService) List(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	return s.stg.ListByUser(ctx, userID)
+}
+
+func (s *webAuthnService) Rename(ctx context.Context, userID, id, name string) error {
+	s.log.Info("WebAuthnService.Rename", logger.String("user_id", userID), logger.String("id", id))
+	if err := s.stg.Rename(ctx, userID, id, name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *webAuthnService) Delete(ctx context.Context, userID, id string) error {
+	s.log.Info("WebAuthnService.Delete", logger.String("user_id", userID), logger.String("id", id))
+	if err := s.stg.Delete(ctx, userID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *webAuthnService) saveChallenge(ctx context.Context, prefix string, sess webAuthnSession) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(sess)
+	if err != nil {
+		return nil, err
+	}
+	key := prefix + base64.RawURLEncoding.EncodeToString(challenge)
+	if err := s.redis.SetX(ctx, key, string(raw), config.WebAuthnChallengeExpireTime); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// takeChallenge - clientDataJSON'dagi challenge bo‘yicha sessiyani oladi va o‘chiradi (GETDEL).
+func (s *webAuthnService) takeChallenge(ctx context.Context, prefix string, clientDataJSON []byte) ([]byte, webAuthnSession, error) {
+	var sess webAuthnSession
+	challenge, err := webauthn.ClientDataChallenge(clientDataJSON)
+	if err != nil {
+		return nil, sess, ErrPasskeyInvalid
+	}
+	raw, err := s.redis.GetDel(ctx, prefix+base64.RawURLEncoding.EncodeToString(challenge))
+	if err != nil || raw == "" {
+		return nil, sess, ErrPasskeyChallengeInvalid
+	}
+	if err := json.Unmarshal([]byte(raw), &sess); err != nil {
+		return nil, sess, ErrPasskeyChallengeInvalid
+	}
+	return challenge, sess, nil
+}
+
+func descriptors(list []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, 0, len(list))
+	for _, c := range list {
+		out = append(out, webauthn.CredentialDescriptor{Type: "public-key", ID: c.CredentialID, Transports: c.Transports})
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type auditRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewAuditRepo(db *pgxpool.Pool, log logger.ILogger) storage.IAuditStorage {
+	return &auditRepo{db: db, log: log}
+}
+
+func (r *auditRepo) Insert(ctx context.Context, e models.AuditEvent) error {
+	const q = `
+INSERT INTO audit_events (type, actor_id, target_user_id, ip_address, user_agent, payload)
+VALUES ($1, $2, $3, NULLIF($4::text, '')::inet, NULLIF($5, ''), COALESCE($6::jsonb, '{}'::jsonb))`
+	var payload *string
+	if len(e.Payload) > 0 {
+		p := string(e.Payload)
+		payload = &p
+	}
+	if _, err := r.db.Exec(ctx, q, e.Type, e.ActorID, e.TargetUserID, e.IPAddress, e.UserAgent, payload); err != nil {
+		r.log.Error("Audit.Insert: failed", logger.Error(err), logger.String("type", e.Type))
+		return err
+	}
+	return nil
+}
+
+// List - filtrlar dinamik yig‘iladi; qiymatlar faqat parametr sifatida uzatiladi.
+func (r *auditRepo) List(ctx context.Context, f models.AuditEventFilter) ([]models.AuditEvent, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch {
+	case f.UserID != "" && f.TargetOnly:
+		where = append(where, "target_user_id = "+arg(f.UserID))
+	case f.UserID != "":
+		p := arg(f.UserID)
+		where = append(where, "(target_user_id = "+p+" OR actor_id = "+p+")")
+	}
+	if f.Type != "" {
+		where = append(where, "type = "+arg(f.Type))
+	}
+	if f.From != nil {
+		where = append(where, "created_at >= "+arg(*f.From))
+	}
+	if f.To != nil {
+		where = append(where, "created_at < "+arg(*f.To))
+	}
+	if f.Before > 0 {
+		where = append(where, "id < "+arg(f.Before))
+	}
+
+	q := `
+SELECT id, type, actor_id::text, target_user_id::text, COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), payload, created_at
+FROM audit_events`
+	if len(where) > 0 {
+		q += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	q += "\nORDER BY id DESC LIMIT " + arg(f.Limit)
+
+	list, err := queryList(ctx, r.db, q, func(row pgx.Rows) (e models.AuditEvent, err error) {
+		err = row.Scan(&e.ID, &e.Type, &e.ActorID, &e.TargetUserID, &e.IPAddress, &e.UserAgent, &e.Payload, &e.CreatedAt)
+		return
+	}, args...)
+	if err != nil {
+		r.log.Error("Audit.List: query failed", logger.Error(err))
+		return nil, err
+	}
+	return list, nil
+}
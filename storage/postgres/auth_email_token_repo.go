@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type authEmailTokenRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewAuthEmailTokenRepo(db *pgxpool.Pool, log logger.ILogger) storage.IAuthEmailTokenStorage {
+	return &authEmailTokenRepo{db: db, log: log}
+}
+
+// Create: codeHash bu yerga KELGUNCHA hashlangan bo‘lishi kerak.
+func (r *authEmailTokenRepo) Create(ctx context.Context, email, codeHash, purpose string, expiresAt time.Time) (string, error) {
+	const q = `
+INSERT INTO auth_email_tokens (email, code, purpose, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id`
+	var id string
+	if err := r.db.QueryRow(ctx, q, email, codeHash, purpose, expiresAt).Scan(&id); err != nil {
+		r.log.Error("AuthEmailToken.Create: insert failed", logger.Error(err), logger.String("purpose", purpose))
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *authEmailTokenRepo) GetLatestActive(ctx context.Context, email, purpose string, now time.Time) (*models.AuthEmailToken, error) {
+	const q = `
+SELECT id, email, code, purpose, used, attempts, expires_at, created_at
+FROM auth_email_tokens
+WHERE email = $1 AND purpose = $2 AND used = false AND expires_at > $3
+ORDER BY created_at DESC
+LIMIT 1`
+	var t models.AuthEmailToken
+	err := r.db.QueryRow(ctx, q, email, purpose, now).Scan(
+		&t.ID, &t.Email, &t.CodeHash, &t.Purpose, &t.Used, &t.Attempts, &t.ExpiresAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *authEmailTokenRepo) IncrementAttempts(ctx context.Context, id string) (int, error) {
+	const q = `UPDATE auth_email_tokens SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`
+	var attempts int
+	if err := r.db.QueryRow(ctx, q, id).Scan(&attempts); err != nil {
+		r.log.Error("AuthEmailToken.IncrementAttempts: update failed", logger.Error(err), logger.String("id", id))
+		return 0, err
+	}
+	return attempts, nil
+}
+
+// MarkUsed: token faqat bir marta ishlatiladi — parallel so‘rovlardan faqat bittasi true oladi.
+func (r *authEmailTokenRepo) MarkUsed(ctx context.Context, id string) (bool, error) {
+	const q = `UPDATE auth_email_tokens SET used = true WHERE id = $1 AND used = false`
+	tag, err := r.db.Exec(ctx, q, id)
+	if err != nil {
+		r.log.Error("AuthEmailToken.MarkUsed: update failed", logger.Error(err), logger.String("id", id))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *authEmailTokenRepo) InvalidateActive(ctx context.Context, email, purpose string) error {
+	const q = `UPDATE auth_email_tokens SET used = true WHERE email = $1 AND purpose = $2 AND used = false`
+	if _, err := r.db.Exec(ctx, q, email, purpose); err != nil {
+		r.log.Error("AuthEmailToken.InvalidateActive: update failed", logger.Error(err), logger.String("purpose", purpose))
+		return err
+	}
+	return nil
+}
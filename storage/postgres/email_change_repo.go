@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type emailChangeRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewEmailChangeRepo(db *pgxpool.Pool, log logger.ILogger) storage.IEmailChangeStorage {
+	return &emailChangeRepo{db: db, log: log}
+}
+
+func (r *emailChangeRepo) Create(ctx context.Context, userID, oldEmail, newEmail string) (string, error) {
+	const q = `
+INSERT INTO email_change_requests (user_id, old_email, new_email)
+VALUES ($1, $2, $3)
+RETURNING id`
+	var id string
+	if err := r.db.QueryRow(ctx, q, userID, oldEmail, newEmail).Scan(&id); err != nil {
+		r.log.Error("EmailChange.Create: insert failed", logger.Error(err), logger.String("user_id", userID))
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *emailChangeRepo) GetPending(ctx context.Context, userID string, since time.Time) (*models.EmailChange, error) {
+	const q = `
+SELECT id, user_id, old_email, new_email, revert_expires_at, confirmed_at, reverted_at, created_at
+FROM email_change_requests
+WHERE user_id = $1 AND confirmed_at IS NULL AND created_at > $2
+ORDER BY created_at DESC
+LIMIT 1`
+	return r.scanOne(r.db.QueryRow(ctx, q, userID, since))
+}
+
+func (r *emailChangeRepo) Confirm(ctx context.Context, id, revertTokenHash string, revertExpiresAt time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var userID, newEmail string
+	err = tx.QueryRow(ctx, `
+UPDATE email_change_requests
+SET confirmed_at = now(), revert_token_hash = $2, revert_expires_at = $3
+WHERE id = $1 AND confirmed_at IS NULL
+RETURNING user_id, new_email`, id, revertTokenHash, revertExpiresAt).Scan(&userID, &newEmail)
+	if err != nil {
+		r.log.Error("EmailChange.Confirm: update request failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET email = $1, email_verified = true, updated_at = now() WHERE id = $2`,
+		newEmail, userID,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyExists
+		}
+		r.log.Error("EmailChange.Confirm: update user failed", logger.Error(err), logger.String("user_id", userID))
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *emailChangeRepo) GetByRevertToken(ctx context.Context, revertTokenHash string, now time.Time) (*models.EmailChange, error) {
+	const q = `
+SELECT id, user_id, old_email, new_email, revert_expires_at, confirmed_at, reverted_at, created_at
+FROM email_change_requests
+WHERE revert_token_hash = $1 AND reverted_at IS NULL AND revert_expires_at > $2`
+	return r.scanOne(r.db.QueryRow(ctx, q, revertTokenHash, now))
+}
+
+func (r *emailChangeRepo) Revert(ctx context.Context, id string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var userID, oldEmail string
+	err = tx.QueryRow(ctx, `
+UPDATE email_change_requests
+SET reverted_at = now()
+WHERE id = $1 AND reverted_at IS NULL
+RETURNING user_id, old_email`, id).Scan(&userID, &oldEmail)
+	if err != nil {
+		r.log.Error("EmailChange.Revert: update request failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET email = $1, email_verified = true, updated_at = now() WHERE id = $2`,
+		oldEmail, userID,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyExists
+		}
+		r.log.Error("EmailChange.Revert: update user failed", logger.Error(err), logger.String("user_id", userID))
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *emailChangeRepo) scanOne(row pgx.Row) (*models.EmailChange, error) {
+	var ec models.EmailChange
+	if err := row.Scan(
+		&ec.ID, &ec.UserID, &ec.OldEmail, &ec.NewEmail,
+		&ec.RevertExpiresAt, &ec.ConfirmedAt, &ec.RevertedAt, &ec.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &ec, nil
+}
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type exportRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewExportRepo(db *pgxpool.Pool, log logger.ILogger) storage.IExportStorage {
+	return &exportRepo{db: db, log: log}
+}
+
+const dataExportColumns = `id, user_id, status, COALESCE(blob_key, ''), size_bytes, expires_at, created_at, completed_at`
+
+func scanDataExport(row pgx.Row) (*models.DataExport, error) {
+	var e models.DataExport
+	if err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.BlobKey, &e.SizeBytes, &e.ExpiresAt, &e.CreatedAt, &e.CompletedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Create - users qatori lock qilinadi, shunda parallel so‘rovlar kunlik limitni chetlab o‘ta olmaydi.
+func (r *exportRepo) Create(ctx context.Context, userID string, since time.Time) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return "", err
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, `
+SELECT EXISTS (
+  SELECT 1 FROM data_exports
+  WHERE user_id = $1 AND created_at > $2 AND status <> 'failed'
+)`, userID, since).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", storage.ErrAlreadyExists
+	}
+
+	var id string
+	if err := tx.QueryRow(ctx, `INSERT INTO data_exports (user_id) VALUES ($1) RETURNING id`, userID).Scan(&id); err != nil {
+		r.log.Error("Export.Create: insert failed", logger.Error(err), logger.String("user_id", userID))
+		return "", err
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (r *exportRepo) GetLatestByUser(ctx context.Context, userID string) (*models.DataExport, error) {
+	q := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+	return scanDataExport(r.db.QueryRow(ctx, q, userID))
+}
+
+// ClaimNext - SKIP LOCKED: bir nechta instance bitta eksportni ikki marta olmaydi.
+func (r *exportRepo) ClaimNext(ctx context.Context, staleBefore time.Time) (*models.DataExport, error) {
+	q := `
+UPDATE data_exports SET status = 'running', started_at = now()
+WHERE id = (
+  SELECT id FROM data_exports
+  WHERE status = 'queued' OR (status = 'running' AND started_at < $1)
+  ORDER BY created_at
+  FOR UPDATE SKIP LOCKED
+  LIMIT 1
+)
+RETURNING ` + dataExportColumns
+	return scanDataExport(r.db.QueryRow(ctx, q, staleBefore))
+}
+
+func (r *exportRepo) MarkReady(ctx context.Context, id, blobKey string, size int64, tokenHash string, expiresAt time.Time) error {
+	const q = `
+UPDATE data_exports
+SET status = 'ready', blob_key = $2, size_bytes = $3, token_hash = $4, expires_at = $5, completed_at = now()
+WHERE id = $1`
+	if _, err := r.db.Exec(ctx, q, id, blobKey, size, tokenHash, expiresAt); err != nil {
+		r.log.Error("Export.MarkReady: failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+	return nil
+}
+
+func (r *exportRepo) MarkFailed(ctx context.Context, id, reason string) error {
+	const q = `UPDATE data_exports SET status = 'failed', error = $2, completed_at = now() WHERE id = $1`
+	if _, err := r.db.Exec(ctx, q, id, reason); err != nil {
+		r.log.Error("Export.MarkFailed: failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+	return nil
+}
+
+func (r *exportRepo) GetByToken(ctx context.Context, tokenHash string, now time.Time) (*models.DataExport, error) {
+	q := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE token_hash = $1 AND status = 'ready' AND expires_at > $2`
+	return scanDataExport(r.db.QueryRow(ctx, q, tokenHash, now))
+}
+
+func (r *exportRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	q := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE status = 'ready' AND expires_at <= $1 ORDER BY expires_at LIMIT $2`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (models.DataExport, error) {
+		e, err := scanDataExport(row)
+		if err != nil {
+			return models.DataExport{}, err
+		}
+		return *e, nil
+	}, now, limit)
+}
+
+func (r *exportRepo) MarkExpired(ctx context.Context, id string) error {
+	const q = `UPDATE data_exports SET status = 'expired', token_hash = NULL WHERE id = $1 AND status = 'ready'`
+	_, err := r.db.Exec(ctx, q, id)
+	return err
+}
+
+func (r *exportRepo) ListBlocks(ctx context.Context, userID string) ([]models.ExportBlock, error) {
+	const q = `SELECT blocked_id, created_at FROM blocks WHERE blocker_id = $1 ORDER BY created_at`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (b models.ExportBlock, err error) {
+		err = row.Scan(&b.UserID, &b.CreatedAt)
+		return
+	}, userID)
+}
+
+func (r *exportRepo) ListCallSessions(ctx context.Context, userID string) ([]models.ExportCallSession, error) {
+	const q = `
+SELECT id, CASE WHEN a_user_id = $1 THEN b_user_id ELSE a_user_id END, started_at, ended_at, topic, state
+FROM sessions
+WHERE a_user_id = $1 OR b_user_id = $1
+ORDER BY started_at`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (s models.ExportCallSession, err error) {
+		err = row.Scan(&s.ID, &s.PartnerID, &s.StartedAt, &s.EndedAt, &s.Topic, &s.State)
+		return
+	}, userID)
+}
+
+func (r *exportRepo) ListAuthSessions(ctx context.Context, userID string) ([]models.ExportAuthSession, error) {
+	const q = `
+SELECT id, COALESCE(user_agent, ''), COALESCE(host(ip_address), ''), created_at, last_used_at, expires_at, revoked_at
+FROM auth_sessions
+WHERE user_id = $1
+ORDER BY created_at`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (s models.ExportAuthSession, err error) {
+		err = row.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt)
+		return
+	}, userID)
+}
+
+func (r *exportRepo) ListFeedbackGiven(ctx context.Context, userID string) ([]models.ExportFeedback, error) {
+	return r.listFeedback(ctx, `rater_id = $1`, userID)
+}
+
+func (r *exportRepo) ListFeedbackReceived(ctx context.Context, userID string) ([]models.ExportFeedback, error) {
+	return r.listFeedback(ctx, `ratee_id = $1`, userID)
+}
+
+func (r *exportRepo) listFeedback(ctx context.Context, where, userID string) ([]models.ExportFeedback, error) {
+	q := `SELECT session_id, rater_id, ratee_id, rating, comment, created_at FROM session_feedback WHERE ` + where + ` ORDER BY created_at`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (f models.ExportFeedback, err error) {
+		err = row.Scan(&f.SessionID, &f.RaterID, &f.RateeID, &f.Rating, &f.Comment, &f.CreatedAt)
+		return
+	}, userID)
+}
+
+func (r *exportRepo) ListMessages(ctx context.Context, userID string) ([]models.ExportMessage, error) {
+	const q = `SELECT id, session_id, kind, body, created_at FROM messages WHERE sender_id = $1 ORDER BY id`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (m models.ExportMessage, err error) {
+		err = row.Scan(&m.ID, &m.SessionID, &m.Kind, &m.Body, &m.CreatedAt)
+		return
+	}, userID)
+}
+
+func (r *exportRepo) ListNotifications(ctx context.Context, userID string) ([]models.ExportNotification, error) {
+	const q = `SELECT id, kind, title, body, payload, created_at, read_at FROM notifications WHERE user_id = $1 ORDER BY created_at`
+	return queryList(ctx, r.db, q, func(row pgx.Rows) (n models.ExportNotification, err error) {
+		err = row.Scan(&n.ID, &n.Kind, &n.Title, &n.Body, &n.Payload, &n.CreatedAt, &n.ReadAt)
+		return
+	}, userID)
+}
+
+// queryList - bo‘sh natijada ham nil emas, [] qaytaradi (JSON'da "null" chiqmasligi uchun).
+func queryList[T any](ctx context.Context, db *pgxpool.Pool, q string, scan func(pgx.Rows) (T, error), args ...any) ([]T, error) {
+	rows, err := db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []T{}
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, rows.Err()
+}
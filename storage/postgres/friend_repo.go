@@ -24,9 +24,11 @@ func (r *friendRepo) AddFriend(ctx context.Context, userID, friendID string) err
 	if userID == friendID {
 		return fmt.Errorf("cannot add yourself as friend")
 	}
+	// o‘chirilgan (soft-deleted) userni do‘st qilib bo‘lmaydi
 	const q = `
 INSERT INTO friends (user_id, friend_user_id)
-VALUES ($1, $2)
+SELECT $1, $2
+WHERE EXISTS (SELECT 1 FROM users WHERE id = $2 AND deleted_at IS NULL)
 ON CONFLICT DO NOTHING`
 	_, err := r.db.Exec(ctx, q, userID, friendID)
 	if err != nil {
@@ -47,7 +49,12 @@ func (r *friendRepo) RemoveFriend(ctx context.Context, userID, friendID string)
 }
 
 func (r *friendRepo) ListFriends(ctx context.Context, userID string) ([]string, error) {
-	const q = `SELECT friend_user_id FROM friends WHERE user_id=$1 ORDER BY created_at DESC`
+	const q = `
+SELECT f.friend_user_id
+FROM friends f
+JOIN users u ON u.id = f.friend_user_id AND u.deleted_at IS NULL
+WHERE f.user_id=$1
+ORDER BY f.created_at DESC`
 	rows, err := r.db.Query(ctx, q, userID)
 	if err != nil {
 		r.log.Error("ListFriends: query failed", logger.Error(err), logger.String("user_id", userID))
@@ -70,7 +77,10 @@ func (r *friendRepo) ListFriends(ctx context.Context, userID string) ([]string,
 }
 
 func (r *friendRepo) IsFriend(ctx context.Context, userID, friendID string) (bool, error) {
-	const q = `SELECT 1 FROM friends WHERE user_id=$1 AND friend_user_id=$2 LIMIT 1`
+	const q = `
+SELECT 1 FROM friends f
+JOIN users u ON u.id = f.friend_user_id AND u.deleted_at IS NULL
+WHERE f.user_id=$1 AND f.friend_user_id=$2 LIMIT 1`
 	var tmp int
 	err := r.db.QueryRow(ctx, q, userID, friendID).Scan(&tmp)
 	if err != nil {
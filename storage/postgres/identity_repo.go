@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type identityRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewIdentityRepo(db *pgxpool.Pool, log logger.ILogger) storage.IIdentityStorage {
+	return &identityRepo{db: db, log: log}
+}
+
+func (r *identityRepo) Create(ctx context.Context, i models.UserIdentity) error {
+	const q = `
+INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+VALUES ($1, $2, $3, NULLIF($4, ''), now())`
+	email := ""
+	if i.Email != nil {
+		email = *i.Email
+	}
+	if _, err := r.db.Exec(ctx, q, i.UserID, i.Provider, i.Subject, email); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyExists
+		}
+		r.log.Error("Identity.Create: insert failed", logger.Error(err), logger.String("provider", i.Provider))
+		return err
+	}
+	return nil
+}
+
+func (r *identityRepo) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	const q = `
+SELECT id, user_id, provider, subject, email::text, created_at, last_login_at
+FROM user_identities
+WHERE provider = $1 AND subject = $2`
+	var i models.UserIdentity
+	if err := r.db.QueryRow(ctx, q, provider, subject).Scan(
+		&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt,
+	); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *identityRepo) ListByUser(ctx context.Context, userID string) ([]models.UserIdentity, error) {
+	const q = `
+SELECT id, user_id, provider, subject, email::text, created_at, last_login_at
+FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at`
+	rows, err := r.db.Query(ctx, q, userID)
+	if err != nil {
+		r.log.Error("Identity.ListByUser: query failed", logger.Error(err), logger.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.UserIdentity
+	for rows.Next() {
+		var i models.UserIdentity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+			return nil, err
+		}
+		out = append(out, i)
+	}
+	return out, rows.Err()
+}
+
+// Unlink: tekshiruv va o‘chirish bitta tranzaksiyada — parallel unlink'lar oxirgi kirish usulini birga o‘chira olmaydi.
+func (r *identityRepo) Unlink(ctx context.Context, userID, provider string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var hasPassword, hasGoogleID bool
+	if err := tx.QueryRow(ctx,
+		`SELECT COALESCE(password_hash, '') <> '', google_id IS NOT NULL FROM users WHERE id = $1 FOR UPDATE`, userID,
+	).Scan(&hasPassword, &hasGoogleID); err != nil {
+		return err
+	}
+
+	var linked bool
+	var others, passkeys int
+	if err := tx.QueryRow(ctx, `
+SELECT COALESCE(bool_or(provider = $2), false), COUNT(*) FILTER (WHERE provider <> $2)
+FROM user_identities WHERE user_id = $1`, userID, provider,
+	).Scan(&linked, &others); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM webauthn_credentials WHERE user_id = $1`, userID).Scan(&passkeys); err != nil {
+		return err
+	}
+
+	// eski google_id ustuni ham google bog‘lanishi hisoblanadi
+	isGoogle := provider == models.ProviderGoogle
+	if isGoogle {
+		linked = linked || hasGoogleID
+	} else if hasGoogleID {
+		others++
+	}
+	if !linked {
+		return pgx.ErrNoRows
+	}
+	if !hasPassword && others == 0 && passkeys == 0 {
+		return storage.ErrLastLoginMethod
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider); err != nil {
+		r.log.Error("Identity.Unlink: delete failed", logger.Error(err), logger.String("provider", provider))
+		return err
+	}
+	if isGoogle && hasGoogleID {
+		if _, err := tx.Exec(ctx, `UPDATE users SET google_id = NULL, updated_at = NOW() WHERE id = $1`, userID); err != nil {
+			r.log.Error("Identity.Unlink: clear google_id failed", logger.Error(err), logger.String("user_id", userID))
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *identityRepo) TouchLogin(ctx context.Context, id string) error {
+	const q = `UPDATE user_identities SET last_login_at = now() WHERE id = $1`
+	_, err := r.db.Exec(ctx, q, id)
+	return err
+}
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type matchAttemptRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewMatchAttemptRepo(db *pgxpool.Pool, log logger.ILogger) storage.IMatchAttemptStorage {
+	return &matchAttemptRepo{db: db, log: log}
+}
+
+const matchAttemptColumns = `id, user_id, COALESCE(desired_language, ''), desired_level, status, mode, tandem_direction,
+       matched_with, session_id, created_at, updated_at`
+
+func scanMatchAttempt(row pgx.Row) (*models.MatchAttempt, error) {
+	var a models.MatchAttempt
+	var level *int16
+	if err := row.Scan(&a.ID, &a.UserID, &a.DesiredLanguage, &level, &a.Status, &a.Mode, &a.TandemDirection,
+		&a.MatchedWith, &a.SessionID, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if level != nil {
+		l := int(*level)
+		a.DesiredLevel = &l
+	}
+	return &a, nil
+}
+
+func (r *matchAttemptRepo) CreateQueued(ctx context.Context, userID, language string, level *int, mode string) (*models.MatchAttempt, error) {
+	q := `
+INSERT INTO match_attempts (user_id, desired_language, desired_level, status, mode)
+VALUES ($1, $2, $3, 'queued', $4)
+RETURNING ` + matchAttemptColumns
+	a, err := scanMatchAttempt(r.db.QueryRow(ctx, q, userID, language, level, mode))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, storage.ErrAlreadyExists
+		}
+		r.log.Error("MatchAttempt.CreateQueued: insert failed", logger.Error(err), logger.String("user_id", userID))
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r *matchAttemptRepo) GetLatestByUser(ctx context.Context, userID string) (*models.MatchAttempt, error) {
+	q := `SELECT ` + matchAttemptColumns + ` FROM match_attempts WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`
+	return scanMatchAttempt(r.db.QueryRow(ctx, q, userID))
+}
+
+func (r *matchAttemptRepo) SetStatus(ctx context.Context, id, from, to string) (bool, error) {
+	const q = `UPDATE match_attempts SET status = $3, updated_at = now() WHERE id = $1 AND status = $2`
+	tag, err := r.db.Exec(ctx, q, id, from, to)
+	if err != nil {
+		r.log.Error("MatchAttempt.SetStatus: failed", logger.Error(err), logger.String("id", id))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *matchAttemptRepo) Match(ctx context.Context, a, b models.MatchCandidate, direction string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// ikkala attempt lock qilinadi: parallel cancel yoki boshqa matcher bilan poyga bo‘lmaydi
+	var n int
+	if err := tx.QueryRow(ctx, `
+SELECT count(*) FROM (
+  SELECT 1 FROM match_attempts ma
+  JOIN users u ON u.id = ma.user_id AND u.deleted_at IS NULL
+  WHERE ma.id IN ($1, $2) AND ma.status = 'queued'
+  FOR UPDATE OF ma
+) t`, a.AttemptID, b.AttemptID).Scan(&n); err != nil {
+		return "", err
+	}
+	if n != 2 {
+		return "", pgx.ErrNoRows
+	}
+	// navbatga qo‘yilgandan keyin blok qilingan bo‘lishi mumkin
+	var blocked bool
+	if err := tx.QueryRow(ctx, `
+SELECT EXISTS (
+  SELECT 1 FROM blocks
+  WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+)`, a.UserID, b.UserID).Scan(&blocked); err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", pgx.ErrNoRows
+	}
+
+	mode, fit, dirA, dirB := tandemFields(a, direction)
+	var sessionID string
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO sessions (a_user_id, b_user_id, mode, tandem_fit) VALUES ($1, $2, $3, $4) RETURNING id`,
+		a.UserID, b.UserID, mode, fit,
+	).Scan(&sessionID); err != nil {
+		r.log.Error("MatchAttempt.Match: create session failed", logger.Error(err))
+		return "", err
+	}
+
+	const upd = `
+UPDATE match_attempts SET status = 'matched', matched_with = $2, session_id = $3, tandem_direction = $4, updated_at = now()
+WHERE id = $1`
+	if _, err := tx.Exec(ctx, upd, a.AttemptID, b.UserID, sessionID, dirA); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, upd, b.AttemptID, a.UserID, sessionID, dirB); err != nil {
+		return "", err
+	}
+	return sessionID, tx.Commit(ctx)
+}
+
+// tandemFields - sessions.mode/tandem_fit va har ikki attempt uchun tandem_direction (o‘z nuqtai nazaridan).
+func tandemFields(a models.MatchCandidate, direction string) (mode string, fit, dirA, dirB *string) {
+	if a.Mode != models.MatchModeTandem {
+		return models.MatchModeStandard, nil, nil, nil
+	}
+	str := func(s string) *string { return &s }
+	switch direction {
+	case models.TandemMutual:
+		return a.Mode, str(models.TandemFitMutual), str(models.TandemMutual), str(models.TandemMutual)
+	case models.MatchDirectionATeaches:
+		return a.Mode, str(models.TandemFitOneWay), str(models.TandemTeaches), str(models.TandemLearns)
+	case models.MatchDirectionBTeaches:
+		return a.Mode, str(models.TandemFitOneWay), str(models.TandemLearns), str(models.TandemTeaches)
+	}
+	return a.Mode, nil, nil, nil
+}
+
+func (r *matchAttemptRepo) ExpireStale(ctx context.Context, before time.Time) (int64, error) {
+	const q = `UPDATE match_attempts SET status = 'expired', updated_at = now() WHERE status = 'queued' AND created_at < $1`
+	tag, err := r.db.Exec(ctx, q, before)
+	if err != nil {
+		r.log.Error("MatchAttempt.ExpireStale: failed", logger.Error(err))
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// WaitStats - since'dan beri matched bo‘lgan attemptlar kutish vaqti medianasi; nativeLang bo‘sh bo‘lsa faqat til bo‘yicha.
+func (r *matchAttemptRepo) WaitStats(ctx context.Context, language, nativeLang, mode string, since time.Time) (time.Duration, int, error) {
+	const q = `
+SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM ma.updated_at - ma.created_at)),
+       count(*)
+FROM match_attempts ma
+JOIN users u ON u.id = ma.user_id
+WHERE ma.status = 'matched' AND ma.desired_language = $1 AND ma.mode = $3 AND ma.created_at >= $4
+  AND ($2 = '' OR u.native_lang = $2)`
+	var (
+		median *float64
+		n      int
+	)
+	if err := r.db.QueryRow(ctx, q, language, nativeLang, mode, since).Scan(&median, &n); err != nil {
+		r.log.Error("MatchAttempt.WaitStats: failed", logger.Error(err))
+		return 0, 0, err
+	}
+	if median == nil {
+		return 0, 0, nil
+	}
+	return time.Duration(*median * float64(time.Second)), n, nil
+}
+
+// LoadCandidate - profil, preferences, reyting, qiziqishlar va bloklar bitta so‘rovda.
+// Tili: match_preferences.target_lang, bo‘lmasa users.target_lang (so‘rov ustidan yozishi mumkin).
+func (r *matchAttemptRepo) LoadCandidate(ctx context.Context, userID string) (*models.MatchCandidate, error) {
+	const q = `
+SELECT u.id,
+       COALESCE(mp.target_lang, u.target_lang, ''),
+       COALESCE(u.level, 0),
+       COALESCE(u.native_lang, ''),
+       COALESCE(u.gender, ''),
+       COALESCE(u.country_code, ''),
+       (SELECT avg(f.rating)::float8 FROM session_feedback f WHERE f.ratee_id = u.id),
+       u.age,
+       COALESCE(u.timezone, ''),
+       ARRAY(SELECT ui.interest_id FROM user_interests ui WHERE ui.user_id = u.id ORDER BY ui.interest_id),
+       mp.min_level, mp.max_level,
+       COALESCE(mp.gender_filter, ''),
+       mp.min_rating,
+       COALESCE(mp.countries_allow, '{}'),
+       ARRAY(
+         SELECT blocked_id::text FROM blocks WHERE blocker_id = u.id
+         UNION
+         SELECT blocker_id::text FROM blocks WHERE blocked_id = u.id
+       )
+FROM users u
+LEFT JOIN match_preferences mp ON mp.user_id = u.id
+WHERE u.id = $1 AND u.deleted_at IS NULL`
+	var (
+		c                  models.MatchCandidate
+		minLevel, maxLevel *int16
+		minRating          *int16
+	)
+	if err := r.db.QueryRow(ctx, q, userID).Scan(
+		&c.UserID, &c.Language, &c.Level, &c.NativeLang, &c.Gender, &c.Country, &c.Rating, &c.Age, &c.Timezone, &c.Interests,
+		&minLevel, &maxLevel, &c.GenderFilter, &minRating, &c.Countries, &c.Blocked,
+	); err != nil {
+		return nil, err
+	}
+	c.MinLevel, c.MaxLevel, c.MinRating = int16Ptr(minLevel), int16Ptr(maxLevel), int16Ptr(minRating)
+	return &c, nil
+}
+
+func int16Ptr(v *int16) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
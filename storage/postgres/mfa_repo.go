@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type mfaRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewMFARepo(db *pgxpool.Pool, log logger.ILogger) storage.IMFAStorage {
+	return &mfaRepo{db: db, log: log}
+}
+
+// UpsertPending - tasdiqlanmagan yozuvni yangi secret bilan almashtiradi; tasdiqlangan bo‘lsa tegmaydi.
+func (r *mfaRepo) UpsertPending(ctx context.Context, userID, secret string) (bool, error) {
+	const q = `
+INSERT INTO user_mfa (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+WHERE user_mfa.confirmed_at IS NULL`
+	tag, err := r.db.Exec(ctx, q, userID, secret)
+	if err != nil {
+		r.log.Error("MFA.UpsertPending: failed", logger.Error(err), logger.String("user_id", userID))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *mfaRepo) Get(ctx context.Context, userID string) (*models.UserMFA, error) {
+	const q = `
+SELECT user_id, secret, confirmed_at, last_used_step, created_at
+FROM user_mfa
+WHERE user_id = $1`
+	var m models.UserMFA
+	if err := r.db.QueryRow(ctx, q, userID).Scan(&m.UserID, &m.Secret, &m.ConfirmedAt, &m.LastUsedStep, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Confirm - enrollment'ni tasdiqlaydi va tiklash kodlarini bitta tranzaksiyada yozadi.
+func (r *mfaRepo) Confirm(ctx context.Context, userID string, step int64, recoveryHashes []string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+UPDATE user_mfa SET confirmed_at = now(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL`, userID, step)
+	if err != nil {
+		r.log.Error("MFA.Confirm: update failed", logger.Error(err), logger.String("user_id", userID))
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// UseStep - replay'dan himoya: qadam faqat oxirgi ishlatilganidan katta bo‘lsa qabul qilinadi (CAS).
+func (r *mfaRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	const q = `
+UPDATE user_mfa SET last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2`
+	tag, err := r.db.Exec(ctx, q, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *mfaRepo) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *mfaRepo) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	const q = `
+UPDATE mfa_recovery_codes SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.db.Exec(ctx, q, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, hashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
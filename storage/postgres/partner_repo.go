@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type partnerRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewPartnerRepo(db *pgxpool.Pool, log logger.ILogger) storage.IPartnerStorage {
+	return &partnerRepo{db: db, log: log}
+}
+
+// Search - o‘chirilgan, discoverable=false, har ikki yo‘nalishda bloklangan va yosh chegarasidan o‘tmagan
+// userlar hech qachon qaytmaydi.
+// Tartib: last_seen (NULL — eng oxirida), keyin id. Til/daraja/mamlakat/jins shartlari users_match_idx'ga mos.
+func (r *partnerRepo) Search(ctx context.Context, s models.PartnerSearch) ([]models.Partner, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	viewer := arg(s.ViewerID)
+	where := []string{
+		"u.id <> " + viewer,
+		"u.deleted_at IS NULL",
+		"COALESCE(us.discoverable, true)",
+		"NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = " + viewer + " AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = " + viewer + "))",
+	}
+	if s.NativeLang != "" {
+		where = append(where, "u.native_lang = "+arg(s.NativeLang))
+	}
+	if s.TargetLang != "" {
+		where = append(where, "u.target_lang = "+arg(s.TargetLang))
+	}
+	if s.AnyLang != "" {
+		p := arg(s.AnyLang)
+		where = append(where, "(u.target_lang = "+p+" OR u.native_lang = "+p+")")
+	}
+	if s.MinLevel != nil {
+		where = append(where, "u.level >= "+arg(*s.MinLevel))
+	}
+	if s.MaxLevel != nil {
+		where = append(where, "u.level <= "+arg(*s.MaxLevel))
+	}
+	if s.Gender != "" {
+		where = append(where, "u.gender = "+arg(s.Gender))
+	}
+	if len(s.Countries) > 0 {
+		where = append(where, "u.country_code = ANY("+arg(s.Countries)+")")
+	}
+	if s.AdultAge > 0 {
+		if s.MinorsOnly {
+			where = append(where, "u.age IS NOT NULL AND u.age < "+arg(s.AdultAge))
+		} else {
+			where = append(where, "(u.age IS NULL OR u.age >= "+arg(s.AdultAge)+")")
+		}
+	}
+	if s.MinRating != nil {
+		// reytingi yo‘q (yangi) userlar filtrdan o‘tadi
+		where = append(where, "(rt.rating IS NULL OR rt.rating >= "+arg(*s.MinRating)+")")
+	}
+	if len(s.Interests) > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM user_interests ui WHERE ui.user_id = u.id AND ui.interest_id = ANY("+arg(s.Interests)+"))")
+	}
+	if s.OnlineSince != nil {
+		where = append(where, "u.last_seen >= "+arg(*s.OnlineSince))
+	}
+	if s.AfterSeen != nil {
+		t, id := arg(*s.AfterSeen), arg(s.AfterID)
+		where = append(where, "(COALESCE(u.last_seen, 'epoch') < "+t+" OR (COALESCE(u.last_seen, 'epoch') = "+t+" AND u.id > "+id+"))")
+	}
+
+	q := `
+SELECT u.id, u.display_name, u.avatar_url, u.age, u.gender, u.country_code, u.native_lang, u.target_lang,
+       u.level, u.about, u.timezone,
+       ARRAY(SELECT ui.interest_id FROM user_interests ui WHERE ui.user_id = u.id ORDER BY ui.interest_id),
+       rt.rating, u.last_seen
+FROM users u
+LEFT JOIN user_settings us ON us.user_id = u.id
+LEFT JOIN LATERAL (
+  SELECT avg(f.rating)::float8 AS rating FROM session_feedback f WHERE f.ratee_id = u.id
+) rt ON true
+WHERE ` + strings.Join(where, "\n  AND ") + `
+ORDER BY COALESCE(u.last_seen, 'epoch') DESC, u.id
+LIMIT ` + arg(s.Limit)
+
+	list, err := queryList(ctx, r.db, q, func(row pgx.Rows) (p models.Partner, err error) {
+		err = row.Scan(&p.ID, &p.DisplayName, &p.AvatarURL, &p.Age, &p.Gender, &p.CountryCode, &p.NativeLang, &p.TargetLang,
+			&p.Level, &p.About, &p.Timezone, &p.Interests, &p.Rating, &p.LastSeen)
+		return
+	}, args...)
+	if err != nil {
+		r.log.Error("Partner.Search: query failed", logger.Error(err), logger.String("viewer_id", s.ViewerID))
+		return nil, err
+	}
+	return list, nil
+}
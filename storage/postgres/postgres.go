@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"speakpall/config"
@@ -94,6 +96,53 @@ func (s *Store) Interest() storage.IUserInterestsStorage {
 func (s *Store) Friend() storage.IFriendStorage {
 	return NewFriendRepo(s.pool, s.log)
 }
+
+func (s *Store) AuthEmailToken() storage.IAuthEmailTokenStorage {
+	return NewAuthEmailTokenRepo(s.pool, s.log)
+}
+
+func (s *Store) EmailChange() storage.IEmailChangeStorage {
+	return NewEmailChangeRepo(s.pool, s.log)
+}
+
+func (s *Store) Session() storage.ISessionStorage {
+	return NewSessionRepo(s.pool, s.log)
+}
+
+func (s *Store) Identity() storage.IIdentityStorage {
+	return NewIdentityRepo(s.pool, s.log)
+}
+
+func (s *Store) MFA() storage.IMFAStorage {
+	return NewMFARepo(s.pool, s.log)
+}
+
 func (s *Store) Redis() storage.IRedisStorage {
 	return s.redis
 }
+
+// isUniqueViolation - Postgres 23505 (unique_violation) xatosini aniqlaydi.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *Store) WebAuthn() storage.IWebAuthnStorage {
+	return NewWebAuthnRepo(s.pool, s.log)
+}
+
+func (s *Store) Export() storage.IExportStorage {
+	return NewExportRepo(s.pool, s.log)
+}
+
+func (s *Store) Audit() storage.IAuditStorage {
+	return NewAuditRepo(s.pool, s.log)
+}
+
+func (s *Store) MatchAttempt() storage.IMatchAttemptStorage {
+	return NewMatchAttemptRepo(s.pool, s.log)
+}
+
+func (s *Store) Partner() storage.IPartnerStorage {
+	return NewPartnerRepo(s.pool, s.log)
+}
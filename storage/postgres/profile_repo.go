@@ -24,14 +24,14 @@ func NewProfileRepo(db *pgxpool.Pool, log logger.ILogger) storage.IProfileStorag
 func (r *profileRepo) GetProfile(ctx context.Context, userID string) (*models.Profile, error) {
 	const q = `
 SELECT id, email, display_name, avatar_url, age, gender, country_code,
-       native_lang, target_lang, level, about, timezone,
+       native_lang, target_lang, level, about, timezone, email_verified,
        to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS created_at
 FROM users
 WHERE id = $1`
 	var p models.Profile
 	err := r.db.QueryRow(ctx, q, userID).Scan(
 		&p.ID, &p.Email, &p.DisplayName, &p.AvatarURL, &p.Age, &p.Gender, &p.CountryCode,
-		&p.NativeLang, &p.TargetLang, &p.Level, &p.About, &p.Timezone, &p.CreatedAt,
+		&p.NativeLang, &p.TargetLang, &p.Level, &p.About, &p.Timezone, &p.EmailVerified, &p.CreatedAt,
 	)
 	if err != nil {
 		r.log.Error("GetProfile: query failed", logger.Error(err), logger.String("user_id", userID))
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type sessionRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewSessionRepo(db *pgxpool.Pool, log logger.ILogger) storage.ISessionStorage {
+	return &sessionRepo{db: db, log: log}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s models.AuthSession) error {
+	const q = `
+INSERT INTO auth_sessions (id, user_id, refresh_jti, expires_at, user_agent, ip_address, last_used_at)
+VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6::text, '')::inet, now())`
+	if _, err := r.db.Exec(ctx, q, s.ID, s.UserID, s.RefreshJTI, s.ExpiresAt, s.UserAgent, s.IPAddress); err != nil {
+		r.log.Error("Session.Create: insert failed", logger.Error(err), logger.String("user_id", s.UserID))
+		return err
+	}
+	return nil
+}
+
+func (r *sessionRepo) GetByID(ctx context.Context, id string) (*models.AuthSession, error) {
+	const q = `
+SELECT id, user_id, COALESCE(refresh_jti, ''), COALESCE(user_agent, ''), COALESCE(host(ip_address), ''),
+       created_at, last_used_at, expires_at, revoked_at
+FROM auth_sessions
+WHERE id = $1`
+	var s models.AuthSession
+	if err := r.db.QueryRow(ctx, q, id).Scan(
+		&s.ID, &s.UserID, &s.RefreshJTI, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepo) Rotate(ctx context.Context, id, oldJTI, newJTI string, expiresAt time.Time, userAgent, ip string) (bool, error) {
+	const q = `
+UPDATE auth_sessions
+SET refresh_jti = $3,
+    expires_at = $4,
+    user_agent = COALESCE(NULLIF($5, ''), user_agent),
+    ip_address = COALESCE(NULLIF($6::text, '')::inet, ip_address),
+    last_used_at = now()
+WHERE id = $1 AND refresh_jti = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, q, id, oldJTI, newJTI, expiresAt, userAgent, ip)
+	if err != nil {
+		r.log.Error("Session.Rotate: update failed", logger.Error(err), logger.String("id", id))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, id string) error {
+	const q = `UPDATE auth_sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := r.db.Exec(ctx, q, id); err != nil {
+		r.log.Error("Session.Revoke: update failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+	return nil
+}
+
+// RevokeAllByUser: exceptID bo‘sh bo‘lmasa, o‘sha sessiya saqlanib qoladi.
+func (r *sessionRepo) RevokeAllByUser(ctx context.Context, userID, exceptID string) ([]string, error) {
+	const q = `
+UPDATE auth_sessions SET revoked_at = now()
+WHERE user_id = $1 AND revoked_at IS NULL AND ($2 = '' OR id::text <> $2)
+RETURNING id`
+	rows, err := r.db.Query(ctx, q, userID, exceptID)
+	if err != nil {
+		r.log.Error("Session.RevokeAllByUser: update failed", logger.Error(err), logger.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *sessionRepo) ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]models.AuthSession, error) {
+	const q = `
+SELECT id, user_id, COALESCE(refresh_jti, ''), COALESCE(user_agent, ''), COALESCE(host(ip_address), ''),
+       created_at, last_used_at, expires_at, revoked_at
+FROM auth_sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+ORDER BY COALESCE(last_used_at, created_at) DESC`
+	rows, err := r.db.Query(ctx, q, userID, now)
+	if err != nil {
+		r.log.Error("Session.ListActiveByUser: query failed", logger.Error(err), logger.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.AuthSession
+	for rows.Next() {
+		var s models.AuthSession
+		if err := rows.Scan(
+			&s.ID, &s.UserID, &s.RefreshJTI, &s.UserAgent, &s.IPAddress,
+			&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"speakpall/api/models"
@@ -46,22 +47,69 @@ func (r *userRepo) CreateUser(ctx context.Context, req models.SignupRequest) (st
 	return id, nil
 }
 
+// CreateGoogleUser: Google orqali kelgan user — parolsiz, email Google tomonidan tasdiqlangan.
+func (r *userRepo) CreateGoogleUser(ctx context.Context, gu models.GoogleUser) (string, error) {
+	id := uuid.New().String()
+	const q = `
+		INSERT INTO users (
+			id, email, display_name, google_id, avatar_url, email_verified
+		) VALUES ($1,$2,$3,$4,$5,$6)
+	`
+	_, err := r.db.Exec(ctx, q, id, gu.Email, gu.Name, gu.GoogleID, gu.Picture, gu.EmailVerified)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return "", storage.ErrAlreadyExists
+		}
+		r.log.Error("google user insert failed", logger.Error(err))
+		return "", err
+	}
+	return id, nil
+}
+
+// CreateExternalUser: OIDC provayder orqali kelgan user — parolsiz; bog‘lanish user_identities'da.
+func (r *userRepo) CreateExternalUser(ctx context.Context, ei models.ExternalIdentity) (string, error) {
+	id := uuid.New().String()
+	const q = `
+		INSERT INTO users (
+			id, email, display_name, avatar_url, email_verified
+		) VALUES ($1,$2,$3,$4,$5)
+	`
+	_, err := r.db.Exec(ctx, q, id, ei.Email, ei.Name, ei.Picture, ei.EmailVerified)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return "", storage.ErrAlreadyExists
+		}
+		r.log.Error("external user insert failed", logger.Error(err), logger.String("provider", ei.Provider))
+		return "", err
+	}
+	return id, nil
+}
+
 func (r *userRepo) GetLoginByEmail(ctx context.Context, email string) (models.LoginUser, error) {
-	const q = `SELECT id, password_hash, role FROM users WHERE email = $1`
+	const q = `SELECT id, COALESCE(password_hash, ''), role, email_verified FROM users WHERE email = $1`
 	var u models.LoginUser
-	if err := r.db.QueryRow(ctx, q, email).Scan(&u.ID, &u.PasswordHash, &u.Role); err != nil {
+	if err := r.db.QueryRow(ctx, q, email).Scan(&u.ID, &u.PasswordHash, &u.Role, &u.EmailVerified); err != nil {
 		r.log.Error("get login by email failed", logger.Error(err))
 		return models.LoginUser{}, err
 	}
 	return u, nil
 }
 
+func (r *userRepo) GetLoginByGoogleID(ctx context.Context, googleID string) (models.LoginUser, error) {
+	const q = `SELECT id, COALESCE(password_hash, ''), role, email_verified FROM users WHERE google_id = $1`
+	var u models.LoginUser
+	if err := r.db.QueryRow(ctx, q, googleID).Scan(&u.ID, &u.PasswordHash, &u.Role, &u.EmailVerified); err != nil {
+		return models.LoginUser{}, err
+	}
+	return u, nil
+}
+
 func (r *userRepo) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	const q = `
 		SELECT
-			id, email, display_name, password_hash, google_id, avatar_url,
+			id, email, display_name, COALESCE(password_hash, ''), google_id, avatar_url,
 			age, gender, country_code, target_lang, level, role,
-			created_at, updated_at
+			email_verified, created_at, updated_at, deleted_at
 		FROM users
 		WHERE id = $1
 	`
@@ -69,7 +117,7 @@ func (r *userRepo) GetUserByID(ctx context.Context, id string) (*models.User, er
 	if err := r.db.QueryRow(ctx, q, id).Scan(
 		&u.ID, &u.Email, &u.DisplayName, &u.PasswordHash, &u.GoogleID, &u.AvatarURL,
 		&u.Age, &u.Gender, &u.CountryCode, &u.TargetLang, &u.Level, &u.Role,
-		&u.CreatedAt, &u.UpdatedAt,
+		&u.EmailVerified, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt,
 	); err != nil {
 		r.log.Error("get user by id failed", logger.Error(err))
 		return nil, err
@@ -86,47 +134,111 @@ func (r *userRepo) UpdatePasswordHash(ctx context.Context, userID, newHash strin
 	return nil
 }
 
+func (r *userRepo) ReplacePasswordHash(ctx context.Context, userID, currentHash, newHash string) (bool, error) {
+	const q = `UPDATE users SET password_hash=$1, updated_at=NOW() WHERE id=$2 AND password_hash=$3`
+	tag, err := r.db.Exec(ctx, q, newHash, userID, currentHash)
+	if err != nil {
+		r.log.Error("replace password hash failed", logger.Error(err))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *userRepo) UpdateRole(ctx context.Context, userID, role string) error {
 	const q = `UPDATE users SET role=$1, updated_at=NOW() WHERE id=$2`
-	if _, err := r.db.Exec(ctx, q, role, userID); err != nil {
+	tag, err := r.db.Exec(ctx, q, role, userID)
+	if err != nil {
 		r.log.Error("update role failed", logger.Error(err))
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *userRepo) UpdateGoogleID(ctx context.Context, userID string, googleID *string) error {
+	const q = `UPDATE users SET google_id=$1, updated_at=NOW() WHERE id=$2`
+	if _, err := r.db.Exec(ctx, q, googleID, userID); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrAlreadyExists
+		}
+		r.log.Error("update google_id failed", logger.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepo) SetAvatarIfEmpty(ctx context.Context, userID, avatarURL string) error {
+	const q = `UPDATE users SET avatar_url=$1, updated_at=NOW() WHERE id=$2 AND (avatar_url IS NULL OR avatar_url = '')`
+	if _, err := r.db.Exec(ctx, q, avatarURL, userID); err != nil {
+		r.log.Error("set avatar failed", logger.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *userRepo) MarkEmailVerified(ctx context.Context, email string) error {
+	const q = `UPDATE users SET email_verified=true, updated_at=NOW() WHERE email=$1`
+	if _, err := r.db.Exec(ctx, q, email); err != nil {
+		r.log.Error("mark email verified failed", logger.Error(err))
+		return err
+	}
 	return nil
 }
 
 // --- Password reset (repo token yaratmaydi) ---
 
-func (r *userRepo) SavePasswordResetToken(ctx context.Context, userID, token string, expiresAt time.Time) error {
+func (r *userRepo) SavePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
 	const q = `
-		INSERT INTO password_reset_tokens (user_id, token, expires_at, created_at)
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
 		VALUES ($1, $2, $3, NOW())
 	`
-	if _, err := r.db.Exec(ctx, q, userID, token, expiresAt); err != nil {
+	if _, err := r.db.Exec(ctx, q, userID, tokenHash, expiresAt); err != nil {
 		r.log.Error("save reset token failed", logger.Error(err))
 		return err
 	}
 	return nil
 }
 
-func (r *userRepo) GetUserIDByPasswordResetToken(ctx context.Context, token string, now time.Time) (string, error) {
+func (r *userRepo) ConsumePasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (string, error) {
+	const q = `
+		UPDATE password_reset_tokens
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING user_id
+	`
+	var userID string
+	if err := r.db.QueryRow(ctx, q, tokenHash, now).Scan(&userID); err != nil {
+		r.log.Error("consume reset token failed", logger.Error(err))
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *userRepo) PeekPasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (string, error) {
 	const q = `
-		SELECT user_id
-		FROM password_reset_tokens
-		WHERE token = $1 AND expires_at > $2
-		ORDER BY created_at DESC
-		LIMIT 1
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
 	`
 	var userID string
-	if err := r.db.QueryRow(ctx, q, token, now).Scan(&userID); err != nil {
-		r.log.Error("validate reset token failed", logger.Error(err))
+	if err := r.db.QueryRow(ctx, q, tokenHash, now).Scan(&userID); err != nil {
 		return "", err
 	}
 	return userID, nil
 }
 
+func (r *userRepo) InvalidatePasswordResetTokens(ctx context.Context, userID string) error {
+	const q = `UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`
+	if _, err := r.db.Exec(ctx, q, userID); err != nil {
+		r.log.Error("invalidate reset tokens failed", logger.Error(err))
+		return err
+	}
+	return nil
+}
+
 func (r *userRepo) GetPasswordByID(ctx context.Context, userID string) (string, error) {
-	const q = `SELECT password_hash FROM users WHERE id = $1`
+	const q = `SELECT COALESCE(password_hash, '') FROM users WHERE id = $1`
 	var ph string
 	if err := r.db.QueryRow(ctx, q, userID).Scan(&ph); err != nil {
 		r.log.Error("get password_hash by id failed", logger.Error(err))
@@ -134,3 +246,121 @@ func (r *userRepo) GetPasswordByID(ctx context.Context, userID string) (string,
 	}
 	return ph, nil
 }
+
+func (r *userRepo) SoftDelete(ctx context.Context, userID string) error {
+	const q = `UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	tag, err := r.db.Exec(ctx, q, userID)
+	if err != nil {
+		r.log.Error("soft delete user failed", logger.Error(err), logger.String("user_id", userID))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *userRepo) Restore(ctx context.Context, userID string) (bool, error) {
+	const q = `UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL`
+	tag, err := r.db.Exec(ctx, q, userID)
+	if err != nil {
+		r.log.Error("restore user failed", logger.Error(err), logger.String("user_id", userID))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *userRepo) ListPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]string, error) {
+	const q = `
+		SELECT id FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND purged_at IS NULL
+		ORDER BY deleted_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, q, deletedBefore, limit)
+	if err != nil {
+		r.log.Error("list purgeable users failed", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Anonymize: bitta tranzaksiyada. users qatori FK (sessions, session_feedback, match_attempts) uchun qoladi.
+func (r *userRepo) Anonymize(ctx context.Context, userID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var email string
+	if err := tx.QueryRow(ctx,
+		`SELECT email::text FROM users WHERE id = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL FOR UPDATE`, userID,
+	).Scan(&email); err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`DELETE FROM messages WHERE sender_id = $1`,
+		`DELETE FROM user_interests WHERE user_id = $1`,
+		`UPDATE session_feedback SET comment = NULL WHERE rater_id = $1 OR ratee_id = $1`,
+		`DELETE FROM friends WHERE user_id = $1 OR friend_user_id = $1`,
+		`DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1`,
+		`DELETE FROM user_settings WHERE user_id = $1`,
+		`DELETE FROM match_preferences WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM device_tokens WHERE user_id = $1`,
+		`DELETE FROM auth_sessions WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM webauthn_credentials WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM email_change_requests WHERE user_id = $1`,
+		// arxiv fayllari eksport cleanup job'i orqali blob store'dan o‘chiriladi
+		`UPDATE data_exports SET expires_at = NOW() WHERE user_id = $1 AND status = 'ready'`,
+		`UPDATE users SET
+			email = 'deleted-' || id::text || '@deleted.invalid',
+			password_hash = NULL, google_id = NULL,
+			display_name = 'Deleted user', avatar_url = NULL,
+			age = NULL, gender = NULL, country_code = NULL,
+			native_lang = NULL, target_lang = NULL, level = NULL,
+			about = NULL, timezone = NULL, last_seen = NULL,
+			email_verified = false, purged_at = NOW()
+		WHERE id = $1`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(ctx, q, userID); err != nil {
+			r.log.Error("anonymize user failed", logger.Error(err), logger.String("user_id", userID))
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM auth_email_tokens WHERE email = $1`, email); err != nil {
+		return err
+	}
+	// audit_events append-only: hodisalar qoladi, faqat ip/user agent/payload tozalanadi
+	if _, err := tx.Exec(ctx, `SELECT audit_events_scrub_user($1)`, userID); err != nil {
+		r.log.Error("scrub audit events failed", logger.Error(err), logger.String("user_id", userID))
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *userRepo) TouchLastSeen(ctx context.Context, userID string, interval time.Duration) error {
+	const q = `
+UPDATE users SET last_seen = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+  AND (last_seen IS NULL OR last_seen < NOW() - make_interval(secs => $2))`
+	_, err := r.db.Exec(ctx, q, userID, interval.Seconds())
+	return err
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"speakpall/api/models"
+	"speakpall/pkg/logger"
+	"speakpall/storage"
+)
+
+type webAuthnRepo struct {
+	db  *pgxpool.Pool
+	log logger.ILogger
+}
+
+func NewWebAuthnRepo(db *pgxpool.Pool, log logger.ILogger) storage.IWebAuthnStorage {
+	return &webAuthnRepo{db: db, log: log}
+}
+
+const webAuthnColumns = `id, user_id, credential_id, public_key, sign_count, aaguid, transports, backup_eligible, name, created_at, last_used_at`
+
+func (r *webAuthnRepo) Create(ctx context.Context, c models.WebAuthnCredential) (string, error) {
+	const q = `
+INSERT INTO webauthn_credentials (user_id, credential_id, public_key, sign_count, aaguid, transports, backup_eligible, name)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id`
+	transports := c.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	var id string
+	if err := r.db.QueryRow(ctx, q,
+		c.UserID, c.CredentialID, c.PublicKey, int64(c.SignCount), c.AAGUID, transports, c.BackupEligible, c.Name,
+	).Scan(&id); err != nil {
+		if isUniqueViolation(err) {
+			return "", storage.ErrAlreadyExists
+		}
+		r.log.Error("WebAuthn.Create: insert failed", logger.Error(err), logger.String("user_id", c.UserID))
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *webAuthnRepo) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error) {
+	q := `SELECT ` + webAuthnColumns + ` FROM webauthn_credentials WHERE credential_id = $1`
+	return scanWebAuthnCredential(r.db.QueryRow(ctx, q, credentialID))
+}
+
+func (r *webAuthnRepo) ListByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	q := `SELECT ` + webAuthnColumns + ` FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, q, userID)
+	if err != nil {
+		r.log.Error("WebAuthn.ListByUser: query failed", logger.Error(err), logger.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.WebAuthnCredential{}
+	for rows.Next() {
+		c, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *c)
+	}
+	return list, rows.Err()
+}
+
+func (r *webAuthnRepo) UpdateSignCount(ctx context.Context, id string, oldCount, newCount uint32) (bool, error) {
+	const q = `
+UPDATE webauthn_credentials
+SET sign_count = $3, last_used_at = now()
+WHERE id = $1 AND sign_count = $2`
+	tag, err := r.db.Exec(ctx, q, id, int64(oldCount), int64(newCount))
+	if err != nil {
+		r.log.Error("WebAuthn.UpdateSignCount: failed", logger.Error(err), logger.String("id", id))
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *webAuthnRepo) Rename(ctx context.Context, userID, id, name string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE webauthn_credentials SET name = $3 WHERE id = $1 AND user_id = $2`, id, userID, name)
+	if err != nil {
+		r.log.Error("WebAuthn.Rename: failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *webAuthnRepo) Delete(ctx context.Context, userID, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.log.Error("WebAuthn.Delete: failed", logger.Error(err), logger.String("id", id))
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func scanWebAuthnCredential(row pgx.Row) (*models.WebAuthnCredential, error) {
+	var (
+		c     models.WebAuthnCredential
+		count int64
+	)
+	if err := row.Scan(
+		&c.ID, &c.UserID, &c.CredentialID, &c.PublicKey, &count, &c.AAGUID,
+		&c.Transports, &c.BackupEligible, &c.Name, &c.CreatedAt, &c.LastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	c.SignCount = uint32(count)
+	return &c, nil
+}
@@ -2,8 +2,10 @@ package redis
 
 import (
 	"context"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"speakpall/config"
@@ -35,6 +37,141 @@ func (r *redisRepo) Get(ctx context.Context, key string) (string, error) {
 	return result, nil
 }
 
+func (r *redisRepo) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.db.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 func (r *redisRepo) Delete(ctx context.Context, key string) error {
 	return r.db.Del(ctx, key).Err()
 }
+
+func (r *redisRepo) GetDel(ctx context.Context, key string) (string, error) {
+	return r.db.GetDel(ctx, key).Result()
+}
+
+func (r *redisRepo) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.db.PTTL(ctx, key).Result()
+}
+
+func (r *redisRepo) SetNX(ctx context.Context, key string, value interface{}, duration time.Duration) (bool, error) {
+	return r.db.SetNX(ctx, key, value, duration).Result()
+}
+
+// slidingWindowScript - ZSET: score = hodisa vaqti (ms); eskilari tozalanadi.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count < limit then
+  redis.call('ZADD', KEYS[1], now, ARGV[4])
+  redis.call('PEXPIRE', KEYS[1], window)
+  return {1, count + 1, 0}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return {0, count, window - (now - tonumber(oldest[2]))}
+`)
+
+func (r *redisRepo) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	member := strconv.FormatInt(now, 10) + "-" + uuid.NewString()
+	res, err := slidingWindowScript.Run(ctx, r.db, []string{key}, now, window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return res[0] == 1, int(res[1]), time.Duration(res[2]) * time.Millisecond, nil
+}
+
+// enqueueUniqueScript - KEYS: queue, data; ARGV: member, score, data, ttl (ms).
+var enqueueUniqueScript = redis.NewScript(`
+if redis.call('ZADD', KEYS[1], 'NX', ARGV[2], ARGV[1]) == 0 then
+  return 0
+end
+redis.call('SET', KEYS[2], ARGV[3], 'PX', ARGV[4])
+return 1
+`)
+
+func (r *redisRepo) EnqueueUnique(ctx context.Context, queueKey, member string, score int64, dataKey, data string, ttl time.Duration) (bool, error) {
+	n, err := enqueueUniqueScript.Run(ctx, r.db, []string{queueKey, dataKey}, member, score, data, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *redisRepo) QueueRange(ctx context.Context, queueKey string, maxScore int64, limit int) ([]string, error) {
+	return r.db.ZRangeByScore(ctx, queueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(maxScore, 10),
+		Count: int64(limit),
+	}).Result()
+}
+
+func (r *redisRepo) QueueRank(ctx context.Context, queueKey, member string) (int, int, bool, error) {
+	pipe := r.db.Pipeline()
+	rank := pipe.ZRank(ctx, queueKey, member)
+	size := pipe.ZCard(ctx, queueKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, 0, false, err
+	}
+	n, err := rank.Result()
+	if err == redis.Nil {
+		return 0, int(size.Val()), false, nil
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return int(n), int(size.Val()), true, nil
+}
+
+func (r *redisRepo) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	vals, err := r.db.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(vals))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[i] = s
+		}
+	}
+	return out, nil
+}
+
+// claimMembersScript - KEYS[1]: queue, KEYS[2..]: data kalitlari; ARGV: memberlar.
+// Bittasi ham yo‘q bo‘lsa hech narsa o‘chirilmaydi.
+var claimMembersScript = redis.NewScript(`
+for i = 1, #ARGV do
+  if not redis.call('ZSCORE', KEYS[1], ARGV[i]) then
+    return 0
+  end
+end
+redis.call('ZREM', KEYS[1], unpack(ARGV))
+for i = 2, #KEYS do
+  redis.call('DEL', KEYS[i])
+end
+return 1
+`)
+
+func (r *redisRepo) ClaimMembers(ctx context.Context, queueKey string, members, dataKeys []string) (bool, error) {
+	if len(members) == 0 {
+		return false, nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	n, err := claimMembersScript.Run(ctx, r.db, append([]string{queueKey}, dataKeys...), args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
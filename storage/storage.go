@@ -2,11 +2,18 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"speakpall/api/models"
 )
 
+// ErrAlreadyExists - unique constraint buzilganda repo shu xatoni qaytaradi.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrLastLoginMethod - o‘chirilsa userda kirish usuli (parol, provayder, passkey) qolmaydi.
+var ErrLastLoginMethod = errors.New("last login method")
+
 type IStorage interface {
 	User() IUserStorage
 	Profile() IProfileStorage
@@ -15,26 +22,197 @@ type IStorage interface {
 	Matchs() IMatchPreferencesStorage
 	Interest() IUserInterestsStorage
 	Friend() IFriendStorage
+	AuthEmailToken() IAuthEmailTokenStorage
+	EmailChange() IEmailChangeStorage
+	Session() ISessionStorage
+	Identity() IIdentityStorage
+	MFA() IMFAStorage
+	WebAuthn() IWebAuthnStorage
+	Export() IExportStorage
+	Audit() IAuditStorage
+	MatchAttempt() IMatchAttemptStorage
+	Partner() IPartnerStorage
 
 	Close()
 }
 
 type IUserStorage interface {
 	CreateUser(ctx context.Context, req models.SignupRequest) (string, error)
+	CreateGoogleUser(ctx context.Context, gu models.GoogleUser) (string, error)
+	CreateExternalUser(ctx context.Context, ei models.ExternalIdentity) (string, error)
 	GetLoginByEmail(ctx context.Context, email string) (models.LoginUser, error)
+	GetLoginByGoogleID(ctx context.Context, googleID string) (models.LoginUser, error)
+	// UpdateGoogleID: googleID nil bo‘lsa bog‘lanish uziladi
+	UpdateGoogleID(ctx context.Context, userID string, googleID *string) error
+	SetAvatarIfEmpty(ctx context.Context, userID, avatarURL string) error
 	GetUserByID(ctx context.Context, id string) (*models.User, error)
 	UpdatePasswordHash(ctx context.Context, userID, newHash string) error
+	// ReplacePasswordHash hash hali currentHash bo‘lsagina yozadi (compare-and-swap); o‘zgargan bo‘lsa false
+	ReplacePasswordHash(ctx context.Context, userID, currentHash, newHash string) (bool, error)
 	UpdateRole(ctx context.Context, userID, role string) error
+	MarkEmailVerified(ctx context.Context, email string) error
 	GetPasswordByID(ctx context.Context, userID string) (string, error)
 
-	SavePasswordResetToken(ctx context.Context, userID, token string, expiresAt time.Time) error
-	GetUserIDByPasswordResetToken(ctx context.Context, token string, now time.Time) (string, error)
+	SavePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error
+	// ConsumePasswordResetToken tokenni ishlatilgan deb belgilaydi va user_id ni qaytaradi (bir martalik)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (string, error)
+	// PeekPasswordResetToken tokenni ishlatmasdan user_id ni qaytaradi (parol tekshiruvidan oldin)
+	PeekPasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (string, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID string) error
+
+	SoftDelete(ctx context.Context, userID string) error
+	// Restore faqat hali purge qilinmagan soft-deleted userni tiklaydi
+	Restore(ctx context.Context, userID string) (bool, error)
+	ListPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]string, error)
+	// Anonymize PII, xabarlar va qiziqishlarni o‘chiradi; sessions/session_feedback statistikasi qoladi
+	Anonymize(ctx context.Context, userID string) error
+	// TouchLastSeen last_seen'ni yangilaydi (oxirgi yangilanishdan beri interval o‘tgan bo‘lsagina)
+	TouchLastSeen(ctx context.Context, userID string, interval time.Duration) error
+}
+
+type IAuthEmailTokenStorage interface {
+	Create(ctx context.Context, email, codeHash, purpose string, expiresAt time.Time) (string, error)
+	GetLatestActive(ctx context.Context, email, purpose string, now time.Time) (*models.AuthEmailToken, error)
+	IncrementAttempts(ctx context.Context, id string) (int, error)
+	MarkUsed(ctx context.Context, id string) (bool, error)
+	InvalidateActive(ctx context.Context, email, purpose string) error
+}
+
+type IEmailChangeStorage interface {
+	Create(ctx context.Context, userID, oldEmail, newEmail string) (string, error)
+	GetPending(ctx context.Context, userID string, since time.Time) (*models.EmailChange, error)
+	// Confirm users.email ni yangilaydi va revert tokenini saqlaydi (bitta tranzaksiyada)
+	Confirm(ctx context.Context, id, revertTokenHash string, revertExpiresAt time.Time) error
+	GetByRevertToken(ctx context.Context, revertTokenHash string, now time.Time) (*models.EmailChange, error)
+	// Revert users.email ni eski manzilga qaytaradi (bitta tranzaksiyada)
+	Revert(ctx context.Context, id string) error
+}
+
+type ISessionStorage interface {
+	Create(ctx context.Context, s models.AuthSession) error
+	GetByID(ctx context.Context, id string) (*models.AuthSession, error)
+	// Rotate refresh_jti ni faqat oldJTI hali joriy bo‘lsa almashtiradi (compare-and-swap)
+	Rotate(ctx context.Context, id, oldJTI, newJTI string, expiresAt time.Time, userAgent, ip string) (bool, error)
+	Revoke(ctx context.Context, id string) error
+	// RevokeAllByUser bekor qilingan sessiya ID'larini qaytaradi
+	RevokeAllByUser(ctx context.Context, userID, exceptID string) ([]string, error)
+	ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]models.AuthSession, error)
+}
+
+type IIdentityStorage interface {
+	// Create (provider, subject) yoki (user_id, provider) band bo‘lsa ErrAlreadyExists qaytaradi
+	Create(ctx context.Context, i models.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	ListByUser(ctx context.Context, userID string) ([]models.UserIdentity, error)
+	// Unlink user qatorini FOR UPDATE bilan qulflab, boshqa kirish usuli qolsagina bog‘lanishni uzadi
+	// (google uchun users.google_id ham tozalanadi). Bog‘lanmagan bo‘lsa pgx.ErrNoRows, oxirgi usul bo‘lsa ErrLastLoginMethod.
+	Unlink(ctx context.Context, userID, provider string) error
+	TouchLogin(ctx context.Context, id string) error
+}
+
+type IMFAStorage interface {
+	// UpsertPending tasdiqlangan 2FA bo‘lsa false qaytaradi (ustidan yozilmaydi)
+	UpsertPending(ctx context.Context, userID, secret string) (bool, error)
+	Get(ctx context.Context, userID string) (*models.UserMFA, error)
+	// Confirm tasdiqlash va tiklash kodlari bitta tranzaksiyada
+	Confirm(ctx context.Context, userID string, step int64, recoveryHashes []string) (bool, error)
+	// UseStep TOTP qadami faqat bir marta ishlatiladi (compare-and-swap)
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	Delete(ctx context.Context, userID string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+}
+
+type IWebAuthnStorage interface {
+	// Create credential_id band bo‘lsa ErrAlreadyExists qaytaradi
+	Create(ctx context.Context, c models.WebAuthnCredential) (string, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*models.WebAuthnCredential, error)
+	ListByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error)
+	// UpdateSignCount hisoblagich faqat oshsa yoziladi (parallel loginlar uchun CAS)
+	UpdateSignCount(ctx context.Context, id string, oldCount, newCount uint32) (bool, error)
+	// Rename va Delete boshqa userning credential'i bo‘lsa pgx.ErrNoRows qaytaradi
+	Rename(ctx context.Context, userID, id, name string) error
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type IExportStorage interface {
+	// Create since'dan keyin (failed'dan tashqari) eksport bo‘lsa ErrAlreadyExists qaytaradi
+	Create(ctx context.Context, userID string, since time.Time) (string, error)
+	GetLatestByUser(ctx context.Context, userID string) (*models.DataExport, error)
+	// ClaimNext navbatdagi (yoki staleBefore'dan beri running qolgan) eksportni running qiladi; bo‘lmasa pgx.ErrNoRows
+	ClaimNext(ctx context.Context, staleBefore time.Time) (*models.DataExport, error)
+	MarkReady(ctx context.Context, id, blobKey string, size int64, tokenHash string, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id, reason string) error
+	GetByToken(ctx context.Context, tokenHash string, now time.Time) (*models.DataExport, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error)
+	MarkExpired(ctx context.Context, id string) error
+
+	// arxiv uchun: profil, sozlamalar, qiziqishlar va do‘stlar o‘z repolaridan olinadi
+	ListBlocks(ctx context.Context, userID string) ([]models.ExportBlock, error)
+	ListCallSessions(ctx context.Context, userID string) ([]models.ExportCallSession, error)
+	ListAuthSessions(ctx context.Context, userID string) ([]models.ExportAuthSession, error)
+	ListFeedbackGiven(ctx context.Context, userID string) ([]models.ExportFeedback, error)
+	ListFeedbackReceived(ctx context.Context, userID string) ([]models.ExportFeedback, error)
+	// ListMessages faqat user yuborgan xabarlar (suhbatdoshnikilar uning ma'lumoti)
+	ListMessages(ctx context.Context, userID string) ([]models.ExportMessage, error)
+	ListNotifications(ctx context.Context, userID string) ([]models.ExportNotification, error)
+}
+
+type IAuditStorage interface {
+	Insert(ctx context.Context, e models.AuditEvent) error
+	// List id bo‘yicha kamayish tartibida; filter.UserID actor yoki target bilan mos keladi (TargetOnly bo‘lmasa)
+	List(ctx context.Context, filter models.AuditEventFilter) ([]models.AuditEvent, error)
+}
+
+type IMatchAttemptStorage interface {
+	// CreateQueued userda queued attempt bo‘lsa ErrAlreadyExists qaytaradi
+	CreateQueued(ctx context.Context, userID, language string, level *int, mode string) (*models.MatchAttempt, error)
+	GetLatestByUser(ctx context.Context, userID string) (*models.MatchAttempt, error)
+	// SetStatus faqat joriy status from bo‘lsa o‘zgartiradi
+	SetStatus(ctx context.Context, id, from, to string) (bool, error)
+	// Match ikkala attempt hali queued, userlar o‘chirilmagan va bir-birini bloklamagan bo‘lsa sessions qatorini yaratadi
+	// va attemptlarni matched qiladi (bitta tranzaksiyada); aks holda pgx.ErrNoRows.
+	// direction — tandem rejimida ScoreAt natijasi (mutual | a_teaches | b_teaches), aks holda bo‘sh
+	Match(ctx context.Context, a, b models.MatchCandidate, direction string) (string, error)
+	// ExpireStale Redis'da yo‘qolgan (masalan restart) eski queued attemptlarni expired qiladi
+	ExpireStale(ctx context.Context, before time.Time) (int64, error)
+	// WaitStats since'dan beri shu til (va ona tili) bo‘yicha matched attemptlar kutish medianasi va soni
+	WaitStats(ctx context.Context, language, nativeLang, mode string, since time.Time) (median time.Duration, samples int, err error)
+	// LoadCandidate profil, match_preferences, reyting va bloklar; o‘chirilgan user uchun pgx.ErrNoRows
+	LoadCandidate(ctx context.Context, userID string) (*models.MatchCandidate, error)
+}
+
+type IPartnerStorage interface {
+	// Search browse uchun; natija last_seen DESC, id bo‘yicha tartiblangan
+	Search(ctx context.Context, s models.PartnerSearch) ([]models.Partner, error)
 }
 
 type IRedisStorage interface {
 	SetX(ctx context.Context, key string, value interface{}, duration time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	Exists(ctx context.Context, key string) (bool, error)
 	Delete(ctx context.Context, key string) error
+	// GetDel qiymatni o‘qiydi va atomar o‘chiradi (bir martalik kalitlar uchun)
+	GetDel(ctx context.Context, key string) (string, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// SetNX kalit yo‘q bo‘lsagina yozadi (SET NX EX); yozilgan bo‘lsa true
+	SetNX(ctx context.Context, key string, value interface{}, duration time.Duration) (bool, error)
+	// SlidingWindow atomar: oynadagi hodisalar limitdan kam bo‘lsa yangisini qo‘shadi.
+	// Rad etilsa retryAfter — eng eski hodisa oynadan chiqquncha qolgan vaqt.
+	SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, count int, retryAfter time.Duration, err error)
+
+	// Navbat (ZSET, score — unix ms) va har bir member uchun alohida qiymat kaliti.
+	// EnqueueUnique ZADD NX va qiymatni bitta atomar amalda yozadi; member navbatda bo‘lsa false
+	EnqueueUnique(ctx context.Context, queueKey, member string, score int64, dataKey, data string, ttl time.Duration) (bool, error)
+	// QueueRange score <= maxScore bo‘lgan eng eski limit ta member
+	QueueRange(ctx context.Context, queueKey string, maxScore int64, limit int) ([]string, error)
+	// QueueRank 0 dan boshlanadi; member navbatda bo‘lmasa ok=false
+	QueueRank(ctx context.Context, queueKey, member string) (rank int, size int, ok bool, err error)
+	// MGet yo‘q kalitlar uchun bo‘sh satr
+	MGet(ctx context.Context, keys ...string) ([]string, error)
+	// ClaimMembers barcha memberlar hali navbatda bo‘lsagina ularni va dataKeys'ni o‘chiradi (atomar).
+	// Bir nechta instance bir xil memberni ikki marta ololmaydi
+	ClaimMembers(ctx context.Context, queueKey string, members, dataKeys []string) (bool, error)
 }
 
 type IProfileStorage interface {
@@ -63,4 +241,3 @@ type IFriendStorage interface {
 	ListFriends(ctx context.Context, userID string) ([]string, error) // return friend user IDs
 	IsFriend(ctx context.Context, userID, friendID string) (bool, error)
 }
-